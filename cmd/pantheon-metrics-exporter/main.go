@@ -3,28 +3,102 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
-	"strings"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/app"
 	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/collector"
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/filter"
 	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/refresh"
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/version"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 func main() {
 	// Parse command-line flags
 	environment := flag.String("env", "live", "Pantheon environment (default: live)")
+	allowAnyEnv := flag.Bool("allowAnyEnv", false, "Skip validation of the -env value")
+	fallbackEnv := flag.String("fallbackEnv", "", "Environment to fetch metrics from for sites with no data in -env, adding an environment label to per-site metrics (optional)")
 	port := flag.String("port", "8080", "HTTP server port (default: 8080)")
-	refreshInterval := flag.Int("refreshInterval", 60, "Refresh interval in minutes (default: 60)")
+	refreshInterval := flag.String("refreshInterval", "60", "Refresh interval, in minutes such as 60 or as a duration such as 90m, 2h or 30s (default: 60)")
+	sitelistInterval := flag.Int("sitelistInterval", 0, "Site list refresh interval in minutes (0 = same as -refreshInterval, but at least 1 minute)")
+	apiBaseURL := flag.String("apiBaseURL", "", "Pantheon API base URL, e.g. a mock server for testing (default: "+pantheon.DefaultAPIBaseURL+")")
+	httpProxy := flag.String("httpProxy", "", "Proxy URL for Pantheon API requests (default: HTTP_PROXY/HTTPS_PROXY environment variables)")
+	maxIdleConns := flag.Int("maxIdleConns", pantheon.DefaultTransportOptions.MaxIdleConns, "Idle connections to the Pantheon API kept for reuse (0 = no limit)")
+	maxIdleConnsPerHost := flag.Int("maxIdleConnsPerHost", pantheon.DefaultTransportOptions.MaxIdleConnsPerHost, "Idle connections kept for reuse per Pantheon API host (0 = Go's default of 2)")
+	idleConnTimeout := flag.Int("idleConnTimeout", int(pantheon.DefaultTransportOptions.IdleConnTimeout/time.Second), "Seconds an idle connection to the Pantheon API is kept for reuse (0 = no limit)")
+	logFormat := flag.String("logFormat", refresh.LogFormatText, "Format of the summary logged after each metrics refresh cycle: text or json")
 	debug := flag.Bool("debug", false, "Enable debug logging of HTTP requests and responses to stderr")
+	apiVerbosity := flag.String("apiVerbosity", "", "API logging verbosity: none, info, debug, or trace (default: trace with -debug, otherwise none)")
 	siteLimit := flag.Int("siteLimit", 0, "Maximum number of sites to query (0 = no limit)")
+	accountLimit := flag.Int("accountLimit", 0, "Maximum number of accounts to process, taking the first tokens in PANTHEON_MACHINE_TOKENS (0 = no limit)")
 	orgID := flag.String("orgID", "", "Limit metrics to sites from this organization ID (optional)")
+	onlyAccount := flag.String("onlyAccount", "", "Only collect from the token whose account ID (last 8 characters of the token) or account label matches this value, for debugging (optional)")
+	allowAccounts := flag.String("allowAccounts", "", "Comma-separated account emails to collect from; tokens authenticating as any other account are skipped (optional, empty = all accounts)")
+	accountLabel := flag.String("accountLabel", pantheon.AccountLabelEmail, "Value of the account label on metrics: email, user_id, or token_suffix (last 8 characters of the token)")
+	sites := flag.String("sites", "", "Comma-separated list of exact site names to monitor (optional, empty = all sites)")
+	framework := flag.String("framework", "", "Comma-separated site frameworks to monitor, e.g. drupal8,wordpress (optional, empty = all frameworks)")
+	prioritySites := flag.String("prioritySites", "", "Comma-separated site names refreshed every minute in addition to the normal rotation (optional)")
+	dedupeSites := flag.Bool("dedupeSites", false, "Report sites accessible by several accounts under only one account")
+	preferAccounts := flag.String("preferAccounts", "", "Comma-separated accounts (as shown in the account label) that own shared sites when -dedupeSites is set, most preferred first (default: token order)")
+	granularity := flag.String("granularity", pantheon.GranularityDaily, "Metrics granularity: daily, weekly, or monthly")
+	metricsStart := flag.String("metricsStart", "", "First day (YYYY-MM-DD, UTC) of a fixed window of metrics to fetch instead of the recent days, for backfills (optional)")
+	metricsEnd := flag.String("metricsEnd", "", "Last day (YYYY-MM-DD, UTC) of the window set by -metricsStart (default: today)")
+	orgConcurrency := flag.Int("orgConcurrency", pantheon.DefaultOrgConcurrency, "Number of organizations whose site lists are fetched at once")
+	orgCacheTTL := flag.Int("orgCacheTTL", 360, "Minutes to cache each account's organization list (0 = no caching)")
+	initialFailureThreshold := flag.Float64("initialFailureThreshold", app.DefaultInitialFailureThreshold, "Fraction (0-1) of sites whose initial metrics fetch may fail before an error is logged and the initial collection is reported unhealthy")
+	jitter := flag.Float64("jitter", 10, "Percentage (0-100) by which refresh intervals are randomized to spread API load")
+	waitForFirstCollection := flag.Bool("waitForFirstCollection", false, "Return 503 from /metrics until metrics have been collected for at least one site")
+	fetchLabels := flag.Bool("fetchLabels", false, "Look up each site's human-readable label (one extra API call per site, cached)")
+	resolveOwners := flag.Bool("resolveOwners", false, "Look up each site owner's email for the owner label of pantheon_site_info (one extra API call per owner, cached)")
+	constLabels := flag.String("constLabels", "", "Comma-separated key=value labels added to every per-site metric, e.g. region=us,cluster=prod (optional)")
+	siteTagLabels := flag.String("siteTagLabels", "", "Comma-separated site tag keys to export as tag_<key> labels, from tags written as key:value (one extra API call per site on each site list refresh)")
+	retryBudget := flag.Int("retryBudget", pantheon.DefaultRetryBudget, "Pantheon API retries allowed per minute across all accounts; failed requests beyond it aren't retried (0 = unlimited)")
+	breakerThreshold := flag.Int("breakerThreshold", refresh.DefaultBreakerThreshold, "Consecutive metrics fetch failures after which an account is paused for a cooldown (0 = never pause)")
+	unauthorizedCooldown := flag.Int("unauthorizedCooldown", int(refresh.DefaultUnauthorizedCooldown/time.Minute), "Minutes to skip a site after the Pantheon API forbids reading its metrics (0 = retry every cycle)")
+	legacyMetrics := flag.Bool("legacyMetrics", false, "Also export site metrics under the deprecated legacy names and labels, for migrating dashboards")
+	initialCollectionTimeout := flag.Int("initialCollectionTimeout", 0, "Minutes to spend on the initial metrics collection before leaving remaining sites to the refresh queue (0 = no limit)")
+	blockingInitialCollection := flag.Bool("blockingInitialCollection", false, "Finish the initial metrics collection before starting the HTTP server, so the first scrape has complete data")
+	metrics := flag.String("metrics", "", "Comma-separated metric families to export: visits, pages_served, cache_hits, cache_misses, cache_hit_ratio, cache_miss_ratio (default: all)")
+	snapshotFile := flag.String("snapshotFile", "", "JSON file the collected metrics are saved to after each refresh cycle and on shutdown, and loaded from at startup so /metrics has data immediately (optional)")
+	skipHistory := flag.Bool("skipHistory", false, "Fetch only the latest day of metrics for new sites instead of 28 days of history, for faster cold starts")
+	planLimits := flag.String("planLimits", "", "Semicolon-separated plan limits exported as pantheon_site_quota_* gauges, e.g. \"Basic:visits=25000,pages_served=125000;Performance Small:visits=35000\" (optional)")
+	emitSince := flag.String("emitSince", "", "Only export historical samples within this window, e.g. 7d or 36h, while still fetching and keeping the full history (default: all)")
+	staleAfter := flag.String("staleAfter", "", "Mark sites whose latest sample is older than this, e.g. 3d or 60h, as stale in pantheon_site_stale (default: never stale)")
+	dropStale := flag.Bool("dropStale", false, "Stop exporting the sample-based metrics of sites marked stale by -staleAfter")
+	noTimestamps := flag.Bool("noTimestamps", false, "Export only the latest sample of each site, without a timestamp, so Prometheus uses the scrape time")
+	cacheHitRatioNaN := flag.Bool("cacheHitRatioNaN", false, "Export pantheon_cache_hit_ratio and pantheon_cache_miss_ratio as NaN instead of 0 for samples with no pages served")
+	dailyDeltas := flag.Bool("dailyDeltas", false, "Also export day-over-day changes (pantheon_visits_daily etc.) computed from consecutive daily samples")
+	minVisits := flag.Int("minVisits", 0, "Only expose metrics for sites whose latest sample has at least this many visits (0 = all sites)")
+	maxLabelLength := flag.Int("maxLabelLength", 0, "Truncate per-site label values longer than this many characters, ending them with \"...\"; site_id and account are kept whole (0 = no limit)")
+	rootPageLimit := flag.Int("rootPageLimit", 100, "Maximum number of sites listed on the root status page (0 = no limit)")
+	enableReset := flag.Bool("enableReset", false, "Serve POST /metrics/reset, which clears all sites and metrics until the next refresh, for testing alerting rules (requires -adminToken)")
+	adminToken := flag.String("adminToken", "", "Bearer token required by admin endpoints such as POST /api/site/{account}/{name}/refresh, which are disabled if unset (optional)")
+	noRootPage := flag.Bool("noRootPage", false, "Serve a bare \"ok\" at / instead of the status page listing sites, and disable the unauthenticated /api/accounts and /api/site/{account}/{name}/metrics routes")
+	pushgateway := flag.String("pushgateway", "", "Pushgateway URL; if set, collect metrics once, push them, and exit instead of serving /metrics (optional)")
+	pushJob := flag.String("pushJob", app.DefaultPushJob, "Job name used when pushing to the Pushgateway (default: "+app.DefaultPushJob+")")
+	remoteWriteURL := flag.String("remoteWriteURL", "", "Prometheus remote-write URL the latest sample of each series is sent to after each metrics refresh (optional)")
+	failIfNoAccounts := flag.Bool("failIfNoAccounts", false, "Exit with an error at startup if no account authenticates successfully")
+	showVersion := flag.Bool("version", false, "Print version information and exit")
 	flag.Parse()
+	if err := app.ApplyEnvFlags(flag.CommandLine, os.LookupEnv); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	if *showVersion {
+		if err := version.Print(os.Stdout); err != nil {
+			log.Fatalf("Error printing version: %v", err)
+		}
+		return
+	}
 
 	// Read machine tokens from environment variable
 	tokensEnv := os.Getenv("PANTHEON_MACHINE_TOKENS")
@@ -32,71 +106,334 @@ func main() {
 		log.Fatal("PANTHEON_MACHINE_TOKENS environment variable is not set")
 	}
 
-	// Split tokens by space
-	tokens := strings.Fields(tokensEnv)
+	// Split tokens by whitespace, dropping comments and stray quotes or commas
+	tokens, tokenAliases, rejected := pantheon.ParseAliasedTokens(tokensEnv)
+	for _, token := range rejected {
+		log.Printf("Warning: Ignoring malformed machine token ending in %q", pantheon.GetAccountID(token))
+	}
 	if len(tokens) == 0 {
 		log.Fatal("No tokens found in PANTHEON_MACHINE_TOKENS")
 	}
 
+	if !*allowAnyEnv {
+		if err := pantheon.ValidateEnvironment(*environment); err != nil {
+			log.Fatalf("Invalid -env: %v (pass -allowAnyEnv to skip this check)", err)
+		}
+		if *fallbackEnv != "" {
+			if err := pantheon.ValidateEnvironment(*fallbackEnv); err != nil {
+				log.Fatalf("Invalid -fallbackEnv: %v (pass -allowAnyEnv to skip this check)", err)
+			}
+		}
+	}
+
+	if err := refresh.ValidateLogFormat(*logFormat); err != nil {
+		log.Fatalf("Invalid -logFormat: %v", err)
+	}
+
+	refreshIntervalDuration, err := refresh.ParseRefreshInterval(*refreshInterval)
+	if err != nil {
+		log.Fatalf("Invalid -refreshInterval: %v", err)
+	}
+	if *sitelistInterval < 0 {
+		log.Fatalf("Invalid -sitelistInterval value %d: must be 0 or more", *sitelistInterval)
+	}
+	if *maxLabelLength < 0 {
+		log.Fatalf("Invalid -maxLabelLength value %d: must be 0 or more", *maxLabelLength)
+	}
+	if *initialFailureThreshold < 0 || *initialFailureThreshold > 1 {
+		log.Fatalf("Invalid -initialFailureThreshold value %.2f: must be between 0 and 1", *initialFailureThreshold)
+	}
+	if *jitter < 0 || *jitter > 100 {
+		log.Fatalf("Invalid -jitter value %.1f: must be between 0 and 100", *jitter)
+	}
+
+	if *accountLimit < 0 {
+		log.Fatalf("Invalid -accountLimit value %d: must be 0 or more", *accountLimit)
+	}
+
 	log.Printf("Found %d Pantheon account(s) to process", len(tokens))
 
 	// Create the Pantheon API client with debug logging if enabled
 	client := pantheon.NewClient(*debug)
+	if *apiVerbosity != "" {
+		if err := client.SetAPIVerbosity(*apiVerbosity); err != nil {
+			log.Fatalf("Invalid -apiVerbosity: %v", err)
+		}
+	}
+	if err := client.SetGranularity(*granularity); err != nil {
+		log.Fatalf("Invalid -granularity: %v", err)
+	}
+	timeRange, err := pantheon.ParseTimeRange(*metricsStart, *metricsEnd, time.Now())
+	if err != nil {
+		log.Fatalf("Invalid -metricsStart/-metricsEnd: %v", err)
+	}
+	client.SetTimeRange(timeRange)
+	if err := client.SetAccountLabel(*accountLabel); err != nil {
+		log.Fatalf("Invalid -accountLabel: %v", err)
+	}
+	client.SetTokenAliases(tokenAliases)
+	client.SetOrgCacheTTL(time.Duration(*orgCacheTTL) * time.Minute)
+	client.SetOrgConcurrency(*orgConcurrency)
+	client.SetRetryBudget(*retryBudget)
+	client.SetFetchLabels(*fetchLabels)
+	client.SetResolveOwners(*resolveOwners)
+	tagKeys := filter.ParseList(*siteTagLabels)
+	client.SetSiteTagKeys(tagKeys)
+	transportOptions := pantheon.TransportOptions{
+		MaxIdleConns:        *maxIdleConns,
+		MaxIdleConnsPerHost: *maxIdleConnsPerHost,
+		IdleConnTimeout:     time.Duration(*idleConnTimeout) * time.Second,
+	}
+	if err := client.SetAPIBaseURL(*apiBaseURL); err != nil {
+		log.Fatalf("Invalid -apiBaseURL: %v", err)
+	}
+	if err := client.SetHTTPTransport(*httpProxy, transportOptions); err != nil {
+		log.Fatalf("Invalid -httpProxy or connection options: %v", err)
+	}
+	requestDuration := collector.NewRequestDurationCollector()
+	client.SetRequestObserver(requestDuration.Observe)
 	ctx := context.Background()
 
+	if *onlyAccount != "" {
+		tokens = app.SelectAccount(ctx, client, tokens, *onlyAccount)
+		if len(tokens) == 0 {
+			log.Fatalf("No token in PANTHEON_MACHINE_TOKENS matches -onlyAccount %q (by account ID or account label)", *onlyAccount)
+		}
+		log.Printf("Limiting collection to account %s (%d token(s))", *onlyAccount, len(tokens))
+	}
+
+	if limited := app.LimitAccounts(tokens, *accountLimit); len(limited) < len(tokens) {
+		log.Printf("Account limit reached: processing %d of %d account(s)", len(limited), len(tokens))
+		tokens = limited
+	}
+
 	// Log organization filter if specified
 	if *orgID != "" {
 		log.Printf("Filtering sites to organization: %s", *orgID)
 	}
 
+	siteFilter := filter.Sites{
+		Names:          filter.ParseList(*sites),
+		Frameworks:     filter.ParseList(*framework),
+		Accounts:       filter.ParseList(*allowAccounts),
+		Dedupe:         *dedupeSites,
+		PreferAccounts: filter.ParseList(*preferAccounts),
+	}
+	if len(siteFilter.Names) > 0 {
+		log.Printf("Limiting metrics to sites: %v", siteFilter.Names)
+	}
+	if len(siteFilter.Accounts) > 0 {
+		log.Printf("Limiting metrics to accounts: %v", siteFilter.Accounts)
+	}
+	if len(siteFilter.Frameworks) > 0 {
+		log.Printf("Limiting metrics to frameworks: %v", siteFilter.Frameworks)
+	}
+
 	// Collect site lists first (fast - no metrics)
 	log.Printf("Loading site lists...")
-	allSites, preFetchedSites := app.CollectAllSiteLists(ctx, client, tokens, *siteLimit, *orgID)
+	allSites, preFetchedSites := app.CollectAllSiteLists(ctx, client, tokens, *siteLimit, *orgID, siteFilter)
+	if err := app.CheckAccounts(*failIfNoAccounts, preFetchedSites); err != nil {
+		log.Fatalf("Error: %v (-failIfNoAccounts is set)", err)
+	}
+
+	// How much history to fetch for each site on its first fetch
+	initialDuration := app.InitialMetricsDuration
+	if *skipHistory {
+		initialDuration = refresh.RefreshMetricsDuration
+	}
 
 	// Create collector with sites (empty metrics initially)
 	pantheonCollector := collector.NewPantheonCollector(allSites)
+	pantheonCollector.SetMinVisits(*minVisits)
+	pantheonCollector.SetMaxLabelLength(*maxLabelLength)
+	if err := pantheonCollector.SetTagLabels(tagKeys); err != nil {
+		log.Fatalf("Invalid -siteTagLabels: %v", err)
+	}
+	if *fallbackEnv != "" {
+		pantheonCollector.SetEnvironmentLabel(*environment)
+	}
+	pantheonCollector.SetDailyDeltas(*dailyDeltas)
+	pantheonCollector.SetCacheHitRatioNaN(*cacheHitRatioNaN)
+	pantheonCollector.SetNoTimestamps(*noTimestamps)
+	emitWindow, err := collector.ParseDaysOrDuration(*emitSince)
+	if err != nil {
+		log.Fatalf("Invalid -emitSince: %v", err)
+	}
+	pantheonCollector.SetEmitSince(emitWindow)
+	staleAge, err := collector.ParseDaysOrDuration(*staleAfter)
+	if err != nil {
+		log.Fatalf("Invalid -staleAfter: %v", err)
+	}
+	if *dropStale && staleAge == 0 {
+		log.Printf("Warning: -dropStale has no effect without -staleAfter")
+	}
+	pantheonCollector.SetStaleAfter(staleAge, *dropStale)
+	if err := pantheonCollector.SetMetrics(filter.ParseList(*metrics)); err != nil {
+		log.Fatalf("Invalid -metrics: %v", err)
+	}
+	labels, err := collector.ParseConstLabels(*constLabels)
+	if err == nil {
+		err = pantheonCollector.SetConstLabels(labels)
+	}
+	if err != nil {
+		log.Fatalf("Invalid -constLabels: %v", err)
+	}
+	limits, err := collector.ParsePlanLimits(*planLimits)
+	if err != nil {
+		log.Fatalf("Invalid -planLimits: %v", err)
+	}
+	pantheonCollector.SetPlanLimits(limits)
+
+	// Serve the last saved metrics until fresh ones are collected
+	if *snapshotFile != "" && *pushgateway == "" {
+		restored, err := pantheonCollector.LoadSnapshot(*snapshotFile)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			log.Printf("No metrics snapshot at %s, starting empty", *snapshotFile)
+		case err != nil:
+			log.Printf("Warning: Ignoring metrics snapshot: %v", err)
+		default:
+			log.Printf("Restored metrics for %d sites from %s", restored, *snapshotFile)
+		}
+	}
 
 	// Register the collector
 	registry := prometheus.NewRegistry()
 	registry.MustRegister(pantheonCollector)
+	if *legacyMetrics {
+		registry.MustRegister(collector.NewLegacyCollector(pantheonCollector))
+	}
+	registry.MustRegister(collector.NewSessionCollector(client))
+	registry.MustRegister(collector.NewSiteCountCollector(client))
+	registry.MustRegister(collector.NewFrozenSiteCollector(pantheonCollector))
+	registry.MustRegister(collector.NewCollectErrorCollector(pantheonCollector))
+	registry.MustRegister(collector.NewRetryCollector(client))
+	registry.MustRegister(requestDuration)
+	initialProgress := app.NewCollectionProgress(app.DefaultProgressLogInterval)
+	initialProgress.SetFailureThreshold(*initialFailureThreshold)
+	registry.MustRegister(collector.NewInitialCollectionCollector(initialProgress))
+
+	// In push mode, collect once and push instead of serving and refreshing
+	if *pushgateway != "" {
+		allSiteMetrics := app.CollectInitialMetrics(ctx, client, tokens, *environment, *fallbackEnv, initialDuration, preFetchedSites, *siteLimit, pantheonCollector, 0, initialProgress)
+		log.Printf("Metrics collection complete: %d sites with metrics", len(allSiteMetrics))
+
+		if err := app.PushMetrics(*pushgateway, *pushJob, registry); err != nil {
+			log.Fatalf("Error pushing metrics: %v", err)
+		}
+		log.Printf("Pushed metrics to %s (job: %s)", *pushgateway, *pushJob)
+		if *remoteWriteURL != "" {
+			if err := app.RemoteWriteMetrics(*remoteWriteURL, registry, time.Now()); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+			log.Printf("Sent metrics to %s", *remoteWriteURL)
+		}
+		return
+	}
 
 	// Setup HTTP handlers
-	app.SetupHTTPHandlers(registry, *environment, tokens, pantheonCollector)
+	mux := app.SetupHTTPHandlers(http.NewServeMux(), registry, *environment, tokens, pantheonCollector, *waitForFirstCollection, *rootPageLimit, *noRootPage)
 
 	// Start refresh manager
-	refreshIntervalDuration := time.Duration(*refreshInterval) * time.Minute
-	refreshManager := app.StartRefreshManager(client, tokens, *environment, refreshIntervalDuration, pantheonCollector, *siteLimit, *orgID)
-	refreshManager.InitializeDiscoveredSites()
-	refreshManager.InitializeAccountTokenMap()
-	log.Printf("Refresh manager started (interval: %d minutes)", *refreshInterval)
-
-	// Collect initial metrics in background goroutine (using pre-fetched site lists)
-	// Metrics are updated incrementally as each site is processed
-	go func() {
-		log.Printf("Starting initial metrics collection in background...")
-		// Update collector incrementally as each site's metrics are fetched
-		onMetricsFetched := func(accountID, siteName string, metricsData map[string]pantheon.MetricData) {
-			pantheonCollector.UpdateSiteMetrics(accountID, siteName, metricsData)
+	refreshManager := app.StartRefreshManager(client, tokens, *environment, refreshIntervalDuration, pantheonCollector, *siteLimit, *orgID, func(rm *refresh.Manager) {
+		rm.SetJitter(*jitter)
+		rm.SetSiteListInterval(time.Duration(*sitelistInterval) * time.Minute)
+		if refreshIntervalDuration < time.Minute {
+			// Refresh every site on each tick, as often as the interval asks
+			rm.SetTickerInterval(refreshIntervalDuration)
+		}
+		rm.SetSiteFilter(siteFilter)
+		rm.SetBreakerThreshold(*breakerThreshold)
+		rm.SetUnauthorizedCooldown(time.Duration(*unauthorizedCooldown) * time.Minute)
+		rm.SetFallbackEnvironment(*fallbackEnv)
+		rm.SetPrioritySites(filter.ParseList(*prioritySites))
+		rm.SetLogFormat(*logFormat)
+		rm.SetSkipHistory(*skipHistory)
+		rm.SetSnapshotFile(*snapshotFile)
+		if *remoteWriteURL != "" {
+			rm.SetAfterRefresh(func() {
+				if err := app.RemoteWriteMetrics(*remoteWriteURL, registry, time.Now()); err != nil {
+					log.Printf("Warning: %v", err)
+				}
+			})
+		}
+		rm.InitializeDiscoveredSites()
+		rm.InitializeAccountTokenMap()
+	})
+	if !*noRootPage {
+		app.SetupAccountsHandler(mux, refreshManager, pantheonCollector)
+	}
+	app.SetupSiteRefreshHandler(mux, refreshManager, pantheonCollector, *adminToken)
+	app.SetupReloadHandler(mux, refreshManager, pantheonCollector, *adminToken)
+	if *enableReset {
+		if *adminToken == "" {
+			log.Printf("Warning: -enableReset has no effect without -adminToken")
 		}
-		allSiteMetrics := app.CollectAllMetricsWithSites(ctx, client, tokens, *environment, preFetchedSites, *siteLimit, onMetricsFetched)
+		// Admin endpoint for testing alerting rules against repopulating metrics
+		app.SetupResetHandler(mux, refreshManager, pantheonCollector, *adminToken)
+	}
+	registry.MustRegister(collector.NewRefreshCollector(refreshManager))
+	registry.MustRegister(collector.NewCircuitBreakerCollector(refreshManager))
+	registry.MustRegister(collector.NewUnauthorizedCollector(refreshManager))
+	registry.MustRegister(collector.NewEnvironmentCollector(refreshManager))
+	registry.MustRegister(collector.NewAccountsCollector(refreshManager))
+	log.Printf("Refresh manager started (interval: %v)", refreshIntervalDuration)
 
+	// Collect initial metrics using the pre-fetched site lists. Metrics are updated
+	// incrementally as each site is processed.
+	collectInitialMetrics := func() {
+		allSiteMetrics := app.CollectInitialMetrics(ctx, client, tokens, *environment, *fallbackEnv, initialDuration, preFetchedSites, *siteLimit, pantheonCollector, time.Duration(*initialCollectionTimeout)*time.Minute, initialProgress)
 		log.Printf("Initial metrics collection complete: %d sites with metrics", len(allSiteMetrics))
-	}()
+	}
+	if *blockingInitialCollection {
+		log.Printf("Collecting initial metrics before starting server...")
+		collectInitialMetrics()
+	} else {
+		log.Printf("Starting initial metrics collection in background...")
+		go collectInitialMetrics()
+	}
 
 	// Start server with timeouts
 	serverAddr := ":" + *port
 	log.Printf("Starting Pantheon metrics exporter on %s", serverAddr)
 	log.Printf("Metrics available at http://localhost%s/metrics", serverAddr)
-	log.Printf("Server is ready to serve requests (metrics collection running in background)")
+	if *blockingInitialCollection {
+		log.Printf("Server is ready to serve requests")
+	} else {
+		log.Printf("Server is ready to serve requests (metrics collection running in background)")
+	}
 
 	server := &http.Server{
 		Addr:         serverAddr,
+		Handler:      mux,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
-	if err := server.ListenAndServe(); err != nil {
+	// Shut down gracefully on SIGINT/SIGTERM, letting in-flight metrics refreshes
+	// finish writing to the collector before exiting
+	shutdownCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-shutdownCtx.Done()
+		log.Printf("Shutting down...")
+		timeoutCtx, cancel := context.WithTimeout(context.Background(), refresh.DefaultDrainTimeout)
+		defer cancel()
+		if err := server.Shutdown(timeoutCtx); err != nil {
+			log.Printf("Warning: HTTP server shutdown: %v", err)
+		}
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("Error starting server: %v", err)
 	}
+	if !refreshManager.Stop(refresh.DefaultDrainTimeout) {
+		log.Printf("Warning: Metrics refreshes still running after %v, exiting anyway", refresh.DefaultDrainTimeout)
+	}
+	if *snapshotFile != "" {
+		if err := pantheonCollector.SaveSnapshot(*snapshotFile); err != nil {
+			log.Printf("Warning: Failed to save metrics snapshot: %v", err)
+		}
+	}
 }
@@ -2,25 +2,55 @@
 package app
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"log"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/collector"
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/filter"
 	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
 	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/refresh"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
 )
 
 // InitialMetricsDuration is used for the first metrics fetch (28 days of history).
 const InitialMetricsDuration = "28d"
 
-// MetricsUpdateFunc is a callback function called when metrics are fetched for a site.
-// It receives the account ID, site name, and the fetched metrics data.
-type MetricsUpdateFunc func(accountID, siteName string, metricsData map[string]pantheon.MetricData)
+// siteMetricsPattern is the route for querying a single site's metrics as JSON.
+const siteMetricsPattern = "GET /api/site/{account}/{name}/metrics"
+
+// accountsPattern is the route for the per-account status summary.
+const accountsPattern = "GET /api/accounts"
+
+// refreshSitePattern is the admin route refreshing a single site's metrics immediately.
+const refreshSitePattern = "POST /api/site/{account}/{name}/refresh"
+
+// reloadPattern is the admin route reloading every account's site list immediately.
+const reloadPattern = "POST /api/sitelists/reload"
+
+// resetPattern is the route pattern for the admin endpoint clearing all sites and metrics
+const resetPattern = "POST /metrics/reset"
+
+// DefaultPushJob is the Pushgateway job name used when none is configured.
+const DefaultPushJob = "pantheon_metrics"
+
+// MetricsUpdateFunc is a callback function called after each attempt to fetch metrics for a site.
+// It receives the account ID, site name, the environment the metrics were fetched from, and the
+// fetched metrics data. If the fetch failed, metricsData is nil and err describes the failure.
+type MetricsUpdateFunc func(accountID, siteName, environment string, metricsData map[string]pantheon.MetricData, err error)
 
 // AccountSiteData holds pre-fetched site data for an account
 type AccountSiteData struct {
@@ -29,26 +59,35 @@ type AccountSiteData struct {
 }
 
 // createSiteMetrics creates a SiteMetrics struct from site list entry and metrics data
-func createSiteMetrics(siteName, siteID, accountID, planName string, metricsData map[string]pantheon.MetricData) pantheon.SiteMetrics {
+func createSiteMetrics(siteName, siteID, accountID, planName string, created int64, metricsData map[string]pantheon.MetricData) pantheon.SiteMetrics {
 	return pantheon.SiteMetrics{
 		SiteName:    siteName,
 		SiteID:      siteID,
-		Label:       siteName, // site:list doesn't provide a label field, using name
+		Label:       siteName, // site:list doesn't provide a label field, using name unless labels are fetched
 		PlanName:    planName,
 		Account:     accountID,
+		Created:     created,
 		MetricsData: metricsData,
 	}
 }
 
 // processAccountSiteList processes a list of sites for an account and collects metrics
 // siteLimit and currentCount are used to limit the total number of sites processed globally.
-// If onMetricsFetched is provided, it will be called after each site's metrics are successfully fetched.
-func processAccountSiteList(ctx context.Context, client *pantheon.Client, token, accountID, environment string, siteList map[string]pantheon.SiteListEntry, siteLimit, currentCount int, onMetricsFetched MetricsUpdateFunc) ([]pantheon.SiteMetrics, int, int) {
+// If fallbackEnv is non-empty, sites with no metrics in environment are fetched from it instead.
+// duration is how much history to fetch for each site, e.g. InitialMetricsDuration.
+// If onMetricsFetched is provided, it will be called after each site's metrics fetch attempt.
+func processAccountSiteList(ctx context.Context, client pantheon.ClientInterface, token, accountID, environment, fallbackEnv, duration string, siteList map[string]pantheon.SiteListEntry, siteLimit, currentCount int, onMetricsFetched MetricsUpdateFunc) ([]pantheon.SiteMetrics, int, int) {
 	siteMetrics := make([]pantheon.SiteMetrics, 0, len(siteList))
 	successCount := 0
 	failCount := 0
 
-	for siteID, site := range siteList {
+	for _, siteID := range pantheon.SortedSiteIDs(siteList) {
+		site := siteList[siteID]
+		// Stop once the collection deadline has passed
+		if ctx.Err() != nil {
+			break
+		}
+
 		// Check if we've reached the global site limit
 		if siteLimit > 0 && (currentCount+len(siteMetrics)) >= siteLimit {
 			log.Printf("Site limit reached (%d sites), stopping metrics collection", siteLimit)
@@ -57,21 +96,31 @@ func processAccountSiteList(ctx context.Context, client *pantheon.Client, token,
 
 		log.Printf("Account %s: Processing site %s (plan: %s)", accountID, site.Name, site.PlanName)
 
-		// Fetch metrics for this site (use 28d for initial fetch)
-		metricsData, err := client.FetchMetricsData(ctx, token, siteID, environment, InitialMetricsDuration)
+		// Fetch metrics for this site
+		metricsData, usedEnv, err := pantheon.FetchMetricsWithFallback(ctx, client, token, siteID, environment, fallbackEnv, duration)
 		if err != nil {
 			log.Printf("Warning: Failed to fetch metrics for %s.%s: %v", accountID, site.Name, err)
 			failCount++
+			if onMetricsFetched != nil {
+				onMetricsFetched(accountID, site.Name, usedEnv, nil, err)
+			}
 			continue
 		}
 
 		// Call the callback to update metrics incrementally if provided
 		if onMetricsFetched != nil {
-			onMetricsFetched(accountID, site.Name, metricsData)
+			onMetricsFetched(accountID, site.Name, usedEnv, metricsData, nil)
 		}
 
 		// Create SiteMetrics entry with account label
-		metrics := createSiteMetrics(site.Name, siteID, accountID, site.PlanName, metricsData)
+		metrics := createSiteMetrics(site.Name, siteID, accountID, site.PlanName, site.Created, metricsData)
+		metrics.Label = site.DisplayLabel()
+		metrics.Tags = site.Tags
+		metrics.Owner = site.DisplayOwner()
+		metrics.Environment = usedEnv
+		metrics.Frozen = site.Frozen
+		metrics.Framework = site.Framework
+		metrics.Source = site.Source
 		siteMetrics = append(siteMetrics, metrics)
 		successCount++
 		log.Printf("Account %s: Successfully loaded %d metric entries for %s", accountID, len(metricsData), site.Name)
@@ -83,8 +132,8 @@ func processAccountSiteList(ctx context.Context, client *pantheon.Client, token,
 // collectAccountMetrics collects metrics for a single account
 // siteLimit and currentCount are used to limit the total number of sites processed globally.
 // If orgID is non-empty, only sites from that organization will be fetched.
-// If onMetricsFetched is provided, it will be called after each site's metrics are successfully fetched.
-func collectAccountMetrics(ctx context.Context, client *pantheon.Client, token, environment string, siteLimit, currentCount int, orgID string, onMetricsFetched MetricsUpdateFunc) ([]pantheon.SiteMetrics, int, int) {
+// If onMetricsFetched is provided, it will be called after each site's metrics fetch attempt.
+func collectAccountMetrics(ctx context.Context, client pantheon.ClientInterface, token, environment, fallbackEnv string, siteLimit, currentCount int, orgID string, onMetricsFetched MetricsUpdateFunc) ([]pantheon.SiteMetrics, int, int) {
 	var siteMetrics []pantheon.SiteMetrics
 	successCount := 0
 	failCount := 0
@@ -108,21 +157,48 @@ func collectAccountMetrics(ctx context.Context, client *pantheon.Client, token,
 	log.Printf("Account %s: Found %d sites", accountID, len(siteList))
 
 	// Process all sites
-	siteMetrics, successCount, failCount = processAccountSiteList(ctx, client, token, accountID, environment, siteList, siteLimit, currentCount, onMetricsFetched)
+	siteMetrics, successCount, failCount = processAccountSiteList(ctx, client, token, accountID, environment, fallbackEnv, InitialMetricsDuration, siteList, siteLimit, currentCount, onMetricsFetched)
 
 	log.Printf("Account %s: Metrics collection complete: %d successful, %d failed", accountID, successCount, failCount)
 	return siteMetrics, successCount, failCount
 }
 
+// accountAllowed reports whether the account a token authenticated as is
+// selected by siteFilter's allowed accounts, logging accounts that aren't.
+// The email comes from the token's existing session.
+func accountAllowed(ctx context.Context, client pantheon.ClientInterface, siteFilter filter.Sites, token, accountID string) bool {
+	if len(siteFilter.Accounts) == 0 {
+		return true
+	}
+	email, err := client.GetEmail(ctx, token)
+	if err != nil || !siteFilter.AllowsAccount(email) {
+		log.Printf("Skipping account %s: not in the allowed accounts", accountID)
+		return false
+	}
+	return true
+}
+
 // CollectAllSiteLists collects site lists for all accounts without fetching metrics.
 // Returns the site metrics for the collector and a map of token -> AccountSiteData for later use.
 // If siteLimit > 0, only the first siteLimit sites are returned.
 // If orgID is non-empty, only sites from that organization will be returned.
-func CollectAllSiteLists(ctx context.Context, client *pantheon.Client, tokens []string, siteLimit int, orgID string) ([]pantheon.SiteMetrics, map[string]AccountSiteData) {
+// Only sites selected by siteFilter are returned; requested site names that aren't
+// found under any account are logged as a warning. If siteFilter.Dedupe is set, sites
+// shared by several accounts are returned only for the preferred account.
+func CollectAllSiteLists(ctx context.Context, client pantheon.ClientInterface, tokens []string, siteLimit int, orgID string, siteFilter filter.Sites) ([]pantheon.SiteMetrics, map[string]AccountSiteData) {
 	var allSiteMetrics []pantheon.SiteMetrics
 	tokenSiteData := make(map[string]AccountSiteData)
+	foundSiteNames := make(map[string]bool)
 
 	for tokenIdx, token := range tokens {
+		// Stop early once every explicitly requested site has been found. With
+		// dedupe, a later account may be the preferred owner of a shared site,
+		// so every account is listed.
+		if len(siteFilter.Names) > 0 && !siteFilter.Dedupe && len(siteFilter.Missing(foundSiteNames)) == 0 {
+			log.Printf("All requested sites found, skipping remaining accounts")
+			break
+		}
+
 		log.Printf("Loading site list for account %d/%d", tokenIdx+1, len(tokens))
 
 		// Authenticate with this token
@@ -133,6 +209,9 @@ func CollectAllSiteLists(ctx context.Context, client *pantheon.Client, tokens []
 			log.Printf("Warning: Failed to authenticate account %s: %v", accountID, err)
 			continue
 		}
+		if !accountAllowed(ctx, client, siteFilter, token, accountID) {
+			continue
+		}
 
 		// Fetch all sites for this account (filtered by orgID if provided)
 		siteList, err := client.FetchAllSites(ctx, token, orgID)
@@ -141,7 +220,11 @@ func CollectAllSiteLists(ctx context.Context, client *pantheon.Client, tokens []
 			continue
 		}
 
+		siteList = siteFilter.Apply(siteList)
 		log.Printf("Account %s: Found %d sites", accountID, len(siteList))
+		for _, site := range siteList {
+			foundSiteNames[site.Name] = true
+		}
 
 		// Store the fetched data for later use
 		tokenSiteData[token] = AccountSiteData{
@@ -149,14 +232,22 @@ func CollectAllSiteLists(ctx context.Context, client *pantheon.Client, tokens []
 			Sites:     siteList,
 		}
 
-		// Create site metrics entries with empty metrics data
-		for siteID, site := range siteList {
+		// Create site metrics entries with empty metrics data, in name order so
+		// the site limit keeps the same sites on every run
+		for _, siteID := range pantheon.SortedSiteIDs(siteList) {
+			site := siteList[siteID]
 			siteMetrics := pantheon.SiteMetrics{
 				SiteName:    site.Name,
 				SiteID:      siteID,
-				Label:       site.Name,
+				Label:       site.DisplayLabel(),
 				PlanName:    site.PlanName,
 				Account:     accountID,
+				Owner:       site.DisplayOwner(),
+				Created:     site.Created,
+				Frozen:      site.Frozen,
+				Framework:   site.Framework,
+				Tags:        site.Tags,
+				Source:      site.Source,
 				MetricsData: make(map[string]pantheon.MetricData),
 			}
 			allSiteMetrics = append(allSiteMetrics, siteMetrics)
@@ -174,15 +265,123 @@ func CollectAllSiteLists(ctx context.Context, client *pantheon.Client, tokens []
 		}
 	}
 
+	if missing := siteFilter.Missing(foundSiteNames); len(missing) > 0 {
+		log.Printf("Warning: Requested sites not found under any account: %v", missing)
+	}
+
+	if siteFilter.Dedupe {
+		allSiteMetrics = siteFilter.DedupeShared(allSiteMetrics)
+		pruneSiteData(tokenSiteData, allSiteMetrics)
+	}
+
 	log.Printf("Site list collection complete: %d sites found across %d accounts", len(allSiteMetrics), len(tokens))
 	return allSiteMetrics, tokenSiteData
 }
 
+// CollectInitialMetrics fetches metrics for the pre-fetched site lists, updating
+// the collector as each site is processed. If timeout is positive, collection
+// stops once it elapses and the remaining sites are left to the refresh queue.
+// duration is how much history to fetch for each site, e.g. InitialMetricsDuration.
+// If progress is non-nil, it tracks how many of the sites have been processed,
+// and whether too many of them failed.
+func CollectInitialMetrics(ctx context.Context, client pantheon.ClientInterface, tokens []string, environment, fallbackEnv, duration string, preFetchedSites map[string]AccountSiteData, siteLimit int, c *collector.PantheonCollector, timeout time.Duration, progress *CollectionProgress) []pantheon.SiteMetrics {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if progress != nil {
+		progress.start(countSites(tokens, preFetchedSites, siteLimit))
+	}
+	onMetricsFetched := func(accountID, siteName, usedEnv string, metricsData map[string]pantheon.MetricData, err error) {
+		if progress != nil {
+			progress.siteDone(err != nil)
+		}
+		if err != nil {
+			c.RecordSiteFailure(accountID, siteName, err)
+			return
+		}
+		c.UpdateSiteMetrics(accountID, siteName, metricsData)
+		c.SetSiteEnvironment(accountID, siteName, usedEnv)
+	}
+	allSiteMetrics := CollectAllMetricsWithSites(ctx, client, tokens, environment, fallbackEnv, duration, preFetchedSites, siteLimit, onMetricsFetched)
+	if progress != nil {
+		progress.finish()
+	}
+	return allSiteMetrics
+}
+
+// CheckAccounts returns an error if failIfNoAccounts is set and no account
+// authenticated and returned a site list.
+func CheckAccounts(failIfNoAccounts bool, tokenSiteData map[string]AccountSiteData) error {
+	if failIfNoAccounts && len(tokenSiteData) == 0 {
+		return fmt.Errorf("no accounts authenticated successfully")
+	}
+	return nil
+}
+
+// SelectAccount returns the tokens belonging to account, which is matched
+// against the account ID derived from each token (its last 8 characters) or,
+// if no token matches that way, the account label each token authenticates
+// as, ignoring case. The label is the email only with the default
+// -accountLabel and no token alias. Tokens that fail to authenticate are
+// skipped.
+func SelectAccount(ctx context.Context, client pantheon.ClientInterface, tokens []string, account string) []string {
+	var selected []string
+	for _, token := range tokens {
+		if pantheon.GetAccountID(token) == account {
+			selected = append(selected, token)
+		}
+	}
+	if len(selected) > 0 {
+		return selected
+	}
+
+	for _, token := range tokens {
+		email, err := client.Authenticate(ctx, token)
+		if err != nil {
+			log.Printf("Warning: Failed to authenticate account %s while looking for %s: %v", pantheon.GetAccountID(token), account, err)
+			continue
+		}
+		if strings.EqualFold(email, account) {
+			selected = append(selected, token)
+		}
+	}
+	return selected
+}
+
+// LimitAccounts returns the first limit tokens, or all of them if limit is 0
+// or there are no more than limit tokens.
+func LimitAccounts(tokens []string, limit int) []string {
+	if limit <= 0 || len(tokens) <= limit {
+		return tokens
+	}
+	return tokens[:limit]
+}
+
+// pruneSiteData removes sites from each account's pre-fetched data that aren't in kept,
+// so metrics aren't fetched for sites deduplicated to another account.
+func pruneSiteData(tokenSiteData map[string]AccountSiteData, kept []pantheon.SiteMetrics) {
+	keptKeys := make(map[string]bool, len(kept))
+	for _, site := range kept {
+		keptKeys[site.Account+":"+site.SiteID] = true
+	}
+
+	for _, data := range tokenSiteData {
+		for siteID := range data.Sites {
+			if !keptKeys[data.AccountID+":"+siteID] {
+				delete(data.Sites, siteID)
+			}
+		}
+	}
+}
+
 // CollectAllMetrics collects metrics for all accounts (fetches site lists fresh)
 // If siteLimit > 0, only the first siteLimit sites are processed.
 // If orgID is non-empty, only sites from that organization will be returned.
-// If onMetricsFetched is provided, it will be called after each site's metrics are successfully fetched.
-func CollectAllMetrics(ctx context.Context, client *pantheon.Client, tokens []string, environment string, siteLimit int, orgID string, onMetricsFetched MetricsUpdateFunc) []pantheon.SiteMetrics {
+// If onMetricsFetched is provided, it will be called after each site's metrics fetch attempt.
+func CollectAllMetrics(ctx context.Context, client pantheon.ClientInterface, tokens []string, environment, fallbackEnv string, siteLimit int, orgID string, onMetricsFetched MetricsUpdateFunc) []pantheon.SiteMetrics {
 	var allSiteMetrics []pantheon.SiteMetrics
 	totalSuccessCount := 0
 	totalFailCount := 0
@@ -190,7 +389,7 @@ func CollectAllMetrics(ctx context.Context, client *pantheon.Client, tokens []st
 	for tokenIdx, token := range tokens {
 		log.Printf("Processing account %d/%d", tokenIdx+1, len(tokens))
 
-		siteMetrics, successCount, failCount := collectAccountMetrics(ctx, client, token, environment, siteLimit, len(allSiteMetrics), orgID, onMetricsFetched)
+		siteMetrics, successCount, failCount := collectAccountMetrics(ctx, client, token, environment, fallbackEnv, siteLimit, len(allSiteMetrics), orgID, onMetricsFetched)
 		allSiteMetrics = append(allSiteMetrics, siteMetrics...)
 		totalSuccessCount += successCount
 		totalFailCount += failCount
@@ -207,8 +406,10 @@ func CollectAllMetrics(ctx context.Context, client *pantheon.Client, tokens []st
 
 // CollectAllMetricsWithSites collects metrics using pre-fetched site data (avoids duplicate site fetch)
 // If siteLimit > 0, only the first siteLimit sites are processed.
-// If onMetricsFetched is provided, it will be called after each site's metrics are successfully fetched.
-func CollectAllMetricsWithSites(ctx context.Context, client *pantheon.Client, tokens []string, environment string, preFetchedSites map[string]AccountSiteData, siteLimit int, onMetricsFetched MetricsUpdateFunc) []pantheon.SiteMetrics {
+// If ctx is cancelled or its deadline passes, collection stops and the sites collected so far are returned.
+// duration is how much history to fetch for each site, e.g. InitialMetricsDuration.
+// If onMetricsFetched is provided, it will be called after each site's metrics fetch attempt.
+func CollectAllMetricsWithSites(ctx context.Context, client pantheon.ClientInterface, tokens []string, environment, fallbackEnv, duration string, preFetchedSites map[string]AccountSiteData, siteLimit int, onMetricsFetched MetricsUpdateFunc) []pantheon.SiteMetrics {
 	var allSiteMetrics []pantheon.SiteMetrics
 	totalSuccessCount := 0
 	totalFailCount := 0
@@ -223,13 +424,18 @@ func CollectAllMetricsWithSites(ctx context.Context, client *pantheon.Client, to
 		}
 
 		// Process sites using the pre-fetched data
-		siteMetrics, successCount, failCount := processAccountSiteList(ctx, client, token, siteData.AccountID, environment, siteData.Sites, siteLimit, len(allSiteMetrics), onMetricsFetched)
+		siteMetrics, successCount, failCount := processAccountSiteList(ctx, client, token, siteData.AccountID, environment, fallbackEnv, duration, siteData.Sites, siteLimit, len(allSiteMetrics), onMetricsFetched)
 		allSiteMetrics = append(allSiteMetrics, siteMetrics...)
 		totalSuccessCount += successCount
 		totalFailCount += failCount
 
 		log.Printf("Account %s: Metrics collection complete: %d successful, %d failed", siteData.AccountID, successCount, failCount)
 
+		if ctx.Err() != nil {
+			log.Printf("Warning: Metrics collection stopped (%v) after %d sites; remaining sites will be collected by the refresh queue", ctx.Err(), totalSuccessCount+totalFailCount)
+			return allSiteMetrics
+		}
+
 		// Check if limit reached after processing account
 		if siteLimit > 0 && len(allSiteMetrics) >= siteLimit {
 			break
@@ -240,11 +446,32 @@ func CollectAllMetricsWithSites(ctx context.Context, client *pantheon.Client, to
 	return allSiteMetrics
 }
 
-// createRootHandler creates the HTTP handler for the root path
-func createRootHandler(environment string, tokens []string, c *collector.PantheonCollector) http.HandlerFunc {
+// createBareRootHandler creates a root handler that responds "ok" without listing
+// any environment, account, or site details. Other paths return 404.
+func createBareRootHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = fmt.Fprintln(w, "ok")
+	}
+}
+
+// createRootHandler creates the HTTP handler for the root path.
+// At most pageLimit sites are listed (0 = no limit).
+func createRootHandler(environment string, tokens []string, c *collector.PantheonCollector, pageLimit int) http.HandlerFunc {
 	return func(w http.ResponseWriter, _ *http.Request) {
 		allSiteMetrics := c.GetSites()
 
+		failingSites := 0
+		for _, site := range allSiteMetrics {
+			if c.GetSiteStatus(site.Account, site.SiteName).LastFailed {
+				failingSites++
+			}
+		}
+
 		w.Header().Set("Content-Type", "text/html")
 		_, _ = fmt.Fprintf(w, `
 <html>
@@ -254,12 +481,33 @@ func createRootHandler(environment string, tokens []string, c *collector.Pantheo
 <p><strong>Environment:</strong> %s</p>
 <p><strong>Accounts monitored:</strong> %d</p>
 <p><strong>Sites monitored:</strong> %d</p>
-<ul>
-`, environment, len(tokens), len(allSiteMetrics))
+<p><strong>Failing sites:</strong> %d</p>
+`, environment, len(tokens), len(allSiteMetrics), failingSites)
 
-		for _, site := range allSiteMetrics {
-			_, _ = fmt.Fprintf(w, "<li>[%s] %s (plan: %s, %d metrics)</li>\n",
-				site.Account, site.SiteName, site.PlanName, len(site.MetricsData))
+		listedSites := allSiteMetrics
+		if pageLimit > 0 && len(listedSites) > pageLimit {
+			listedSites = listedSites[:pageLimit]
+			_, _ = fmt.Fprintf(w, "<p>Showing %d of %d sites</p>\n", len(listedSites), len(allSiteMetrics))
+		}
+		_, _ = fmt.Fprintln(w, "<ul>")
+
+		for _, site := range listedSites {
+			status := c.GetSiteStatus(site.Account, site.SiteName)
+			lastRefresh := "never"
+			if !status.LastSuccess.IsZero() {
+				lastRefresh = status.LastSuccess.UTC().Format(time.RFC3339)
+			}
+			health := "ok"
+			if status.LastFailed {
+				health = "failed"
+			}
+			// The last error explains why a site has no data without digging through logs
+			lastError := ""
+			if status.LastFailed && status.LastError != "" {
+				lastError = ": <code>" + html.EscapeString(status.LastError) + "</code>"
+			}
+			_, _ = fmt.Fprintf(w, "<li>[%s] %s (plan: %s, %d metrics, last refresh: %s, status: %s)%s</li>\n",
+				site.Account, site.SiteName, site.PlanName, len(site.MetricsData), lastRefresh, health, lastError)
 		}
 
 		_, _ = fmt.Fprintf(w, `
@@ -271,18 +519,341 @@ func createRootHandler(environment string, tokens []string, c *collector.Pantheo
 	}
 }
 
-// SetupHTTPHandlers sets up HTTP routes for the metrics exporter
-func SetupHTTPHandlers(registry *prometheus.Registry, environment string, tokens []string, c *collector.PantheonCollector) {
+// createSiteMetricsHandler creates the HTTP handler returning a single site's metrics as JSON
+func createSiteMetricsHandler(c *collector.PantheonCollector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account := r.PathValue("account")
+		name := r.PathValue("name")
+
+		site, ok := c.GetSite(account, name)
+		if !ok {
+			http.Error(w, fmt.Sprintf("site %s not found for account %s", name, account), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(site.MetricsData); err != nil {
+			log.Printf("Error encoding metrics for %s.%s: %v", account, name, err)
+		}
+	}
+}
+
+// AccountStatusSource provides the status of each monitored account
+type AccountStatusSource interface {
+	AccountStatuses() []refresh.AccountStatus
+}
+
+// accountSummary is the JSON form of one account in the /api/accounts response
+type accountSummary struct {
+	Account             string     `json:"account"`
+	Authenticated       bool       `json:"authenticated"`
+	Sites               int        `json:"sites"`
+	LastSiteListRefresh *time.Time `json:"last_site_list_refresh"`
+	Error               string     `json:"error,omitempty"`
+}
+
+// createAccountsHandler creates the HTTP handler summarizing each account's status
+// as JSON, with site counts taken from the sites currently in the collector
+func createAccountsHandler(source AccountStatusSource, c *collector.PantheonCollector) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		siteCounts := make(map[string]int)
+		c.ForEachSite(func(site pantheon.SiteMetrics) {
+			siteCounts[site.Account]++
+		})
+
+		statuses := source.AccountStatuses()
+		accounts := make([]accountSummary, 0, len(statuses))
+		for _, status := range statuses {
+			summary := accountSummary{
+				Account:       status.Account,
+				Authenticated: status.Authenticated,
+				Sites:         siteCounts[status.Account],
+				Error:         status.LastError,
+			}
+			if !status.LastSiteListRefresh.IsZero() {
+				refreshed := status.LastSiteListRefresh.UTC()
+				summary.LastSiteListRefresh = &refreshed
+			}
+			accounts = append(accounts, summary)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(accounts); err != nil {
+			log.Printf("Error encoding account statuses: %v", err)
+		}
+	}
+}
+
+// SetupAccountsHandler adds the /api/accounts route to mux, or to
+// http.DefaultServeMux if mux is nil. It needs the refresh manager and so is
+// registered once the manager has started.
+func SetupAccountsHandler(mux *http.ServeMux, source AccountStatusSource, c *collector.PantheonCollector) {
+	if mux == nil {
+		mux = http.DefaultServeMux
+	}
+	mux.HandleFunc(accountsPattern, createAccountsHandler(source, c))
+}
+
+// SiteRefresher refreshes a single site's metrics on demand
+type SiteRefresher interface {
+	RefreshSite(accountID, siteName string) error
+}
+
+// requireAdminToken wraps handler so it only serves requests carrying
+// "Authorization: Bearer <token>", answering others with 401.
+func requireAdminToken(token string, handler http.HandlerFunc) http.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// createSiteRefreshHandler creates the HTTP handler that refreshes a site's
+// metrics and returns them as JSON once the refresh has finished
+func createSiteRefreshHandler(refresher SiteRefresher, c *collector.PantheonCollector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account := r.PathValue("account")
+		name := r.PathValue("name")
+
+		if err := refresher.RefreshSite(account, name); err != nil {
+			if errors.Is(err, refresh.ErrUnknownSite) {
+				http.Error(w, fmt.Sprintf("site %s not found for account %s", name, account), http.StatusNotFound)
+				return
+			}
+			http.Error(w, fmt.Sprintf("failed to refresh site %s: %v", name, err), http.StatusBadGateway)
+			return
+		}
+		log.Printf("Site %s.%s refreshed via %s", account, name, refreshSitePattern)
+
+		site, ok := c.GetSite(account, name)
+		if !ok {
+			http.Error(w, fmt.Sprintf("site %s not found for account %s", name, account), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(site.MetricsData); err != nil {
+			log.Printf("Error encoding metrics for %s.%s: %v", account, name, err)
+		}
+	}
+}
+
+// SetupSiteRefreshHandler adds the admin route refreshing a single site to
+// mux, or to http.DefaultServeMux if mux is nil. The route requires
+// adminToken as a bearer token and isn't registered if adminToken is empty.
+func SetupSiteRefreshHandler(mux *http.ServeMux, refresher SiteRefresher, c *collector.PantheonCollector, adminToken string) {
+	if adminToken == "" {
+		return
+	}
+	if mux == nil {
+		mux = http.DefaultServeMux
+	}
+	mux.HandleFunc(refreshSitePattern, requireAdminToken(adminToken, createSiteRefreshHandler(refresher, c)))
+}
+
+// SiteListReloader reloads every account's site list on demand
+type SiteListReloader interface {
+	ReloadSiteLists()
+}
+
+// createReloadHandler creates the HTTP handler that reloads every account's
+// site list, bypassing the organization cache, and returns the number of
+// monitored sites once the reload has finished
+func createReloadHandler(reloader SiteListReloader, c *collector.PantheonCollector) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		reloader.ReloadSiteLists()
+		log.Printf("Site lists reloaded via %s", reloadPattern)
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = fmt.Fprintf(w, "site lists reloaded: %d sites\n", len(c.GetSites()))
+	}
+}
+
+// SetupReloadHandler adds the admin route reloading every account's site list
+// to mux, or to http.DefaultServeMux if mux is nil. The route requires
+// adminToken as a bearer token and isn't registered if adminToken is empty.
+func SetupReloadHandler(mux *http.ServeMux, reloader SiteListReloader, c *collector.PantheonCollector, adminToken string) {
+	if adminToken == "" {
+		return
+	}
+	if mux == nil {
+		mux = http.DefaultServeMux
+	}
+	mux.HandleFunc(reloadPattern, requireAdminToken(adminToken, createReloadHandler(reloader, c)))
+}
+
+// DiscoveryResetter forgets which sites have been fetched
+type DiscoveryResetter interface {
+	ResetDiscoveredSites()
+}
+
+// createResetHandler creates the HTTP handler that clears every site and its
+// metrics from the collector. Sites reappear on the next site list refresh,
+// and as resetter forgets they were fetched, their full history is fetched again.
+func createResetHandler(resetter DiscoveryResetter, c *collector.PantheonCollector) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		c.UpdateSites(nil)
+		resetter.ResetDiscoveredSites()
+		log.Printf("Metrics reset via %s", resetPattern)
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = fmt.Fprintln(w, "metrics reset")
+	}
+}
+
+// SetupResetHandler adds the admin route clearing all sites and metrics to
+// mux, or to http.DefaultServeMux if mux is nil. The route requires
+// adminToken as a bearer token and isn't registered if adminToken is empty.
+func SetupResetHandler(mux *http.ServeMux, resetter DiscoveryResetter, c *collector.PantheonCollector, adminToken string) {
+	if adminToken == "" {
+		return
+	}
+	if mux == nil {
+		mux = http.DefaultServeMux
+	}
+	mux.HandleFunc(resetPattern, requireAdminToken(adminToken, createResetHandler(resetter, c)))
+}
+
+// createMetricsHandler creates the HTTP handler for the metrics endpoint.
+// If waitForFirstCollection is true, the handler returns 503 until metrics
+// have been loaded for at least one site, so scrapes right after startup
+// don't record empty data. Responses are compressed when the client accepts
+// gzip or zstd, which promhttp negotiates by default.
+func createMetricsHandler(registry *prometheus.Registry, c *collector.PantheonCollector, waitForFirstCollection bool) http.Handler {
+	metricsHandler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	if !waitForFirstCollection {
+		return metricsHandler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !c.HasAnyMetrics() {
+			http.Error(w, "metrics collection has not completed for any site yet", http.StatusServiceUnavailable)
+			return
+		}
+		metricsHandler.ServeHTTP(w, r)
+	})
+}
+
+// SetupHTTPHandlers sets up HTTP routes for the metrics exporter on mux, or on
+// http.DefaultServeMux if mux is nil, and returns the mux used. Each exporter
+// embedded in a process needs its own mux and registry. With noRootPage, / is
+// a bare health response and the JSON route for a site's metrics isn't
+// registered, so no route lists sites or accounts without the admin token.
+func SetupHTTPHandlers(mux *http.ServeMux, registry *prometheus.Registry, environment string, tokens []string, c *collector.PantheonCollector, waitForFirstCollection bool, rootPageLimit int, noRootPage bool) *http.ServeMux {
+	if mux == nil {
+		mux = http.DefaultServeMux
+	}
+
 	// Create HTTP handler for metrics
-	http.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	mux.Handle("/metrics", createMetricsHandler(registry, c, waitForFirstCollection))
+
+	// Bare health response that doesn't list sites
+	if noRootPage {
+		mux.HandleFunc("/", createBareRootHandler())
+		return mux
+	}
+
+	// JSON API for spot-checking a single site
+	mux.HandleFunc(siteMetricsPattern, createSiteMetricsHandler(c))
 
 	// Root handler with instructions
-	http.HandleFunc("/", createRootHandler(environment, tokens, c))
+	mux.HandleFunc("/", createRootHandler(environment, tokens, c, rootPageLimit))
+	return mux
 }
 
-// StartRefreshManager creates and starts the refresh manager
-func StartRefreshManager(client *pantheon.Client, tokens []string, environment string, refreshInterval time.Duration, c *collector.PantheonCollector, siteLimit int, orgID string) *refresh.Manager {
+// StartRefreshManager creates and starts the refresh manager.
+// Any configure functions are applied to the manager before it is started.
+func StartRefreshManager(client *pantheon.Client, tokens []string, environment string, refreshInterval time.Duration, c *collector.PantheonCollector, siteLimit int, orgID string, configure ...func(*refresh.Manager)) *refresh.Manager {
 	refreshManager := refresh.NewManager(client, tokens, environment, refreshInterval, c, siteLimit, orgID)
+	for _, fn := range configure {
+		fn(refreshManager)
+	}
 	refreshManager.Start()
 	return refreshManager
 }
+
+// latestOnlyGatherer wraps a Gatherer, keeping only the most recent sample of each
+// series and dropping its timestamp. The Pushgateway rejects timestamped samples,
+// and the collector emits one timestamped sample per day of history.
+type latestOnlyGatherer struct {
+	gatherer prometheus.Gatherer
+}
+
+// Gather implements prometheus.Gatherer
+func (g latestOnlyGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mf := range families {
+		latest := make(map[string]*dto.Metric)
+		var keys []string
+		for _, m := range mf.GetMetric() {
+			key := labelKey(m)
+			existing, ok := latest[key]
+			if !ok {
+				keys = append(keys, key)
+			}
+			if !ok || m.GetTimestampMs() > existing.GetTimestampMs() {
+				latest[key] = m
+			}
+		}
+
+		sort.Strings(keys)
+		metrics := make([]*dto.Metric, 0, len(keys))
+		for _, key := range keys {
+			m := latest[key]
+			m.TimestampMs = nil
+			metrics = append(metrics, m)
+		}
+		mf.Metric = metrics
+	}
+	return families, nil
+}
+
+// labelKey returns a string identifying the label set of a metric
+func labelKey(m *dto.Metric) string {
+	var b strings.Builder
+	for _, lp := range m.GetLabel() {
+		b.WriteString(lp.GetName())
+		b.WriteByte('=')
+		b.WriteString(lp.GetValue())
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// PushMetrics pushes the latest sample of each series in the registry to a Prometheus
+// Pushgateway, replacing any metrics previously pushed under the same job.
+func PushMetrics(pushgatewayURL, job string, registry prometheus.Gatherer) error {
+	err := push.New(pushgatewayURL, job).
+		Gatherer(latestOnlyGatherer{gatherer: registry}).
+		Push()
+	if err != nil {
+		return fmt.Errorf("failed to push metrics to %s: %w", pushgatewayURL, err)
+	}
+	return nil
+}
+
+// RenderText gathers every metric from gatherer and renders it in the
+// Prometheus text exposition format, as served at /metrics, for golden tests
+// and debugging. A prometheus.Registry returns families sorted by name, so
+// the output only changes with the metrics themselves.
+func RenderText(gatherer prometheus.Gatherer) (string, error) {
+	families, err := gatherer.Gather()
+	if err != nil {
+		return "", fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	var buf bytes.Buffer
+	encoder := expfmt.NewEncoder(&buf, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, mf := range families {
+		if err := encoder.Encode(mf); err != nil {
+			return "", fmt.Errorf("failed to encode %s: %w", mf.GetName(), err)
+		}
+	}
+	return buf.String(), nil
+}
@@ -1,16 +1,26 @@
 package app
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/collector"
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/filter"
 	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/refresh"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
 )
 
 const (
@@ -56,8 +66,12 @@ func TestCreateRootHandler(t *testing.T) {
 	// Create collector with test data
 	c := collector.NewPantheonCollector(allSiteMetrics)
 
+	// Record one successful refresh and one failed refresh
+	c.UpdateSiteMetrics("account1", "testsite1", metricsData)
+	c.RecordSiteFailure("account2", "testsite2", errors.New(`failed to fetch metrics: <api> error 500`))
+
 	// Create the handler
-	handler := createRootHandler(environment, tokens, c)
+	handler := createRootHandler(environment, tokens, c, 0)
 
 	// Test the handler
 	req := httptest.NewRequest("GET", "/", nil)
@@ -88,6 +102,21 @@ func TestCreateRootHandler(t *testing.T) {
 	if !strings.Contains(body, "Sites monitored:</strong> 2") {
 		t.Error("Response should contain '2' sites")
 	}
+	if !strings.Contains(body, "Failing sites:</strong> 1") {
+		t.Error("Response should contain '1' failing site")
+	}
+	if !strings.Contains(body, "testsite2 (plan: Performance, 1 metrics, last refresh: never, status: failed)") {
+		t.Error("Response should show testsite2 as never refreshed and failed")
+	}
+	if !strings.Contains(body, "status: failed): <code>failed to fetch metrics: &lt;api&gt; error 500</code>") {
+		t.Error("Response should show testsite2's last error, escaped")
+	}
+	if strings.Count(body, "last refresh: never") != 1 {
+		t.Error("Response should show a last refresh timestamp for testsite1")
+	}
+	if !strings.Contains(body, "status: ok") {
+		t.Error("Response should show testsite1 status as ok")
+	}
 }
 
 // TestCreateRootHandlerEmptyMetrics tests createRootHandler with empty metrics
@@ -97,7 +126,7 @@ func TestCreateRootHandlerEmptyMetrics(t *testing.T) {
 	environment := testEnvLive
 
 	c := collector.NewPantheonCollector(allSiteMetrics)
-	handler := createRootHandler(environment, tokens, c)
+	handler := createRootHandler(environment, tokens, c, 0)
 
 	req := httptest.NewRequest("GET", "/", nil)
 	w := httptest.NewRecorder()
@@ -114,6 +143,97 @@ func TestCreateRootHandlerEmptyMetrics(t *testing.T) {
 	}
 }
 
+// TestCreateBareRootHandler tests that the bare root page doesn't list sites
+func TestCreateBareRootHandler(t *testing.T) {
+	handler := createBareRootHandler()
+
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if strings.TrimSpace(body) != "ok" {
+		t.Errorf("Expected bare ok response, got %q", body)
+	}
+	for _, leaked := range []string{"site1", "account1", testEnvLive} {
+		if strings.Contains(body, leaked) {
+			t.Errorf("Response should not contain %q", leaked)
+		}
+	}
+
+	req = httptest.NewRequest("GET", "/status", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for other paths, got %d", w.Code)
+	}
+}
+
+// TestSetupHTTPHandlersNoRootPage tests that no unauthenticated route lists
+// sites or accounts with noRootPage
+func TestSetupHTTPHandlersNoRootPage(t *testing.T) {
+	c := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{
+			SiteName:    "site1",
+			Account:     "account1",
+			MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 100}},
+		},
+	})
+	mux := SetupHTTPHandlers(http.NewServeMux(), prometheus.NewRegistry(), testEnvLive, []string{"token1"}, c, false, 0, true)
+
+	for _, path := range []string{"/", "/api/site/account1/site1/metrics", "/api/accounts"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if path != "/" && w.Code != http.StatusNotFound {
+			t.Errorf("Expected %s to return 404, got %d", path, w.Code)
+		}
+		for _, leaked := range []string{"site1", "account1"} {
+			if strings.Contains(w.Body.String(), leaked) {
+				t.Errorf("Expected %s not to contain %q, got %q", path, leaked, w.Body.String())
+			}
+		}
+	}
+}
+
+// TestCreateRootHandlerPageLimit tests that the root page truncates the site list
+func TestCreateRootHandlerPageLimit(t *testing.T) {
+	var sites []pantheon.SiteMetrics
+	for i := 0; i < 25; i++ {
+		sites = append(sites, pantheon.SiteMetrics{
+			SiteName: fmt.Sprintf("site%02d", i),
+			PlanName: "Basic",
+			Account:  "account1",
+		})
+	}
+
+	c := collector.NewPantheonCollector(sites)
+	handler := createRootHandler(testEnvLive, []string{"token1"}, c, 10)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, "Sites monitored:</strong> 25") {
+		t.Error("Response should show the total number of sites")
+	}
+	if !strings.Contains(body, "Showing 10 of 25 sites") {
+		t.Error("Response should show how many sites are listed")
+	}
+	if strings.Index(body, "Showing 10 of 25 sites") > strings.Index(body, "<ul>") {
+		t.Error("The listed sites summary should come before the list, not inside it")
+	}
+	if count := strings.Count(body, "<li>"); count != 10 {
+		t.Errorf("Expected 10 listed sites, got %d", count)
+	}
+	if strings.Contains(body, "site10") {
+		t.Error("Response should not list sites beyond the limit")
+	}
+}
+
 // TestCreateRootHandlerMultipleEnvironments tests createRootHandler with different environments
 func TestCreateRootHandlerMultipleEnvironments(t *testing.T) {
 	tests := []struct {
@@ -128,7 +248,7 @@ func TestCreateRootHandlerMultipleEnvironments(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := collector.NewPantheonCollector([]pantheon.SiteMetrics{})
-			handler := createRootHandler(tt.env, []string{}, c)
+			handler := createRootHandler(tt.env, []string{}, c, 0)
 
 			req := httptest.NewRequest("GET", "/", nil)
 			w := httptest.NewRecorder()
@@ -159,7 +279,7 @@ func TestCreateSiteMetrics(t *testing.T) {
 		},
 	}
 
-	result := createSiteMetrics(siteName, siteID, accountID, planName, metricsData)
+	result := createSiteMetrics(siteName, siteID, accountID, planName, 0, metricsData)
 
 	if result.SiteName != siteName {
 		t.Errorf("Expected SiteName %s, got %s", siteName, result.SiteName)
@@ -189,7 +309,7 @@ func TestCreateSiteMetricsWithEmptyMetrics(t *testing.T) {
 	planName := "Basic"
 	metricsData := map[string]pantheon.MetricData{}
 
-	result := createSiteMetrics(siteName, siteID, accountID, planName, metricsData)
+	result := createSiteMetrics(siteName, siteID, accountID, planName, 0, metricsData)
 
 	if len(result.MetricsData) != 0 {
 		t.Errorf("Expected empty metrics, got %d entries", len(result.MetricsData))
@@ -229,7 +349,7 @@ func TestCreateSiteMetricsWithMultipleMetrics(t *testing.T) {
 		},
 	}
 
-	result := createSiteMetrics(siteName, siteID, accountID, planName, metricsData)
+	result := createSiteMetrics(siteName, siteID, accountID, planName, 0, metricsData)
 
 	if len(result.MetricsData) != 3 {
 		t.Errorf("Expected 3 metrics entries, got %d", len(result.MetricsData))
@@ -243,8 +363,53 @@ func TestSetupHTTPHandlers(t *testing.T) {
 	tokens := []string{"token1"}
 	c := collector.NewPantheonCollector([]pantheon.SiteMetrics{})
 
-	// This should not panic
-	SetupHTTPHandlers(registry, environment, tokens, c)
+	// This should not panic, and a nil mux means http.DefaultServeMux
+	if mux := SetupHTTPHandlers(nil, registry, environment, tokens, c, false, 0, false); mux != http.DefaultServeMux {
+		t.Error("Expected handlers to be registered on http.DefaultServeMux")
+	}
+}
+
+// TestSetupHTTPHandlersIndependentExporters checks that two exporters can run
+// in one process, each with its own collector, registry, and mux.
+func TestSetupHTTPHandlersIndependentExporters(t *testing.T) {
+	newExporter := func(siteName string) *http.ServeMux {
+		c := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+			{
+				SiteName:    siteName,
+				Account:     "account1",
+				MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 10, CacheHitRatio: "50%"}},
+			},
+		})
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(c)
+
+		mux := SetupHTTPHandlers(http.NewServeMux(), registry, testEnvLive, []string{"token1"}, c, false, 0, false)
+		SetupAccountsHandler(mux, staticAccountStatuses{}, c)
+		return mux
+	}
+
+	first := newExporter("first")
+	second := newExporter("second")
+
+	for name, mux := range map[string]*http.ServeMux{"first": first, "second": second} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", name, rec.Code)
+		}
+
+		body := rec.Body.String()
+		other := "second"
+		if name == "second" {
+			other = "first"
+		}
+		if !strings.Contains(body, `site_id="`+name+`"`) {
+			t.Errorf("%s: expected its own site in /metrics", name)
+		}
+		if strings.Contains(body, `site_id="`+other+`"`) {
+			t.Errorf("%s: expected no metrics from the other exporter", name)
+		}
+	}
 }
 
 // TestStartRefreshManager tests the StartRefreshManager function
@@ -293,7 +458,7 @@ func TestCollectAllSiteListsEmptyTokens(t *testing.T) {
 	ctx := context.Background()
 	tokens := []string{}
 
-	result, tokenSiteData := CollectAllSiteLists(ctx, client, tokens, 0, "")
+	result, tokenSiteData := CollectAllSiteLists(ctx, client, tokens, 0, "", filter.Sites{})
 
 	if len(result) != 0 {
 		t.Errorf("Expected 0 sites with empty tokens, got %d", len(result))
@@ -311,7 +476,7 @@ func TestCollectAllSiteListsInvalidTokens(t *testing.T) {
 	tokens := []string{"invalid-token-1", "invalid-token-2"}
 
 	// This should complete without panic, handling auth failures gracefully
-	result, tokenSiteData := CollectAllSiteLists(ctx, client, tokens, 0, "")
+	result, tokenSiteData := CollectAllSiteLists(ctx, client, tokens, 0, "", filter.Sites{})
 
 	// With invalid tokens, we expect 0 sites (auth will fail for all)
 	if len(result) != 0 {
@@ -329,7 +494,7 @@ func TestCollectAllMetricsEmptyTokens(t *testing.T) {
 	tokens := []string{}
 	environment := testEnvLive
 
-	result := CollectAllMetrics(ctx, client, tokens, environment, 0, "", nil)
+	result := CollectAllMetrics(ctx, client, tokens, environment, "", 0, "", nil)
 
 	if len(result) != 0 {
 		t.Errorf("Expected 0 sites with empty tokens, got %d", len(result))
@@ -345,7 +510,7 @@ func TestCollectAllMetricsInvalidTokens(t *testing.T) {
 	environment := testEnvLive
 
 	// This should complete without panic, handling auth failures gracefully
-	result := CollectAllMetrics(ctx, client, tokens, environment, 0, "", nil)
+	result := CollectAllMetrics(ctx, client, tokens, environment, "", 0, "", nil)
 
 	// With invalid tokens, we expect 0 sites
 	if len(result) != 0 {
@@ -361,7 +526,7 @@ func TestCollectAllMetricsWithSitesEmptyTokens(t *testing.T) {
 	environment := testEnvLive
 	preFetchedSites := map[string]AccountSiteData{}
 
-	result := CollectAllMetricsWithSites(ctx, client, tokens, environment, preFetchedSites, 0, nil)
+	result := CollectAllMetricsWithSites(ctx, client, tokens, environment, "", InitialMetricsDuration, preFetchedSites, 0, nil)
 
 	if len(result) != 0 {
 		t.Errorf("Expected 0 sites with empty tokens, got %d", len(result))
@@ -376,7 +541,7 @@ func TestCollectAllMetricsWithSitesMissingToken(t *testing.T) {
 	environment := testEnvLive
 	preFetchedSites := map[string]AccountSiteData{} // Empty, no matching token
 
-	result := CollectAllMetricsWithSites(ctx, client, tokens, environment, preFetchedSites, 0, nil)
+	result := CollectAllMetricsWithSites(ctx, client, tokens, environment, "", InitialMetricsDuration, preFetchedSites, 0, nil)
 
 	if len(result) != 0 {
 		t.Errorf("Expected 0 sites with missing token data, got %d", len(result))
@@ -404,7 +569,7 @@ func TestCollectAllMetricsWithSitesWithData(t *testing.T) {
 	}
 
 	// This will fail to fetch metrics (invalid token) but should use the pre-fetched data
-	result := CollectAllMetricsWithSites(ctx, client, tokens, environment, preFetchedSites, 0, nil)
+	result := CollectAllMetricsWithSites(ctx, client, tokens, environment, "", InitialMetricsDuration, preFetchedSites, 0, nil)
 
 	// With invalid token, metrics fetch will fail, so result should be empty
 	if len(result) != 0 {
@@ -421,7 +586,7 @@ func TestProcessAccountSiteListEmpty(t *testing.T) {
 	environment := testEnvLive
 	siteList := map[string]pantheon.SiteListEntry{}
 
-	siteMetrics, successCount, failCount := processAccountSiteList(ctx, client, token, accountID, environment, siteList, 0, 0, nil)
+	siteMetrics, successCount, failCount := processAccountSiteList(ctx, client, token, accountID, environment, "", InitialMetricsDuration, siteList, 0, 0, nil)
 
 	if len(siteMetrics) != 0 {
 		t.Errorf("Expected 0 site metrics with empty site list, got %d", len(siteMetrics))
@@ -456,7 +621,7 @@ func TestProcessAccountSiteListWithSites(t *testing.T) {
 	}
 
 	// This will fail to fetch metrics (invalid token) but should not panic
-	siteMetrics, successCount, failCount := processAccountSiteList(ctx, client, token, accountID, environment, siteList, 0, 0, nil)
+	siteMetrics, successCount, failCount := processAccountSiteList(ctx, client, token, accountID, environment, "", InitialMetricsDuration, siteList, 0, 0, nil)
 
 	// Expect 0 successful, 2 failed (can't fetch metrics with invalid token)
 	if len(siteMetrics) != 0 {
@@ -478,7 +643,7 @@ func TestCollectAccountMetricsInvalidToken(t *testing.T) {
 	environment := testEnvLive
 
 	// This should complete without panic, handling auth failure gracefully
-	siteMetrics, successCount, failCount := collectAccountMetrics(ctx, client, token, environment, 0, 0, "", nil)
+	siteMetrics, successCount, failCount := collectAccountMetrics(ctx, client, token, environment, "", 0, 0, "", nil)
 
 	// With invalid token, we expect 0 metrics (auth will fail)
 	if len(siteMetrics) != 0 {
@@ -500,7 +665,7 @@ func TestCollectAllSiteListsWithOrgID(t *testing.T) {
 	orgID := "org-uuid-12345"
 
 	// This should complete without panic, handling auth failure gracefully
-	result, tokenSiteData := CollectAllSiteLists(ctx, client, tokens, 0, orgID)
+	result, tokenSiteData := CollectAllSiteLists(ctx, client, tokens, 0, orgID, filter.Sites{})
 
 	// With invalid tokens, we expect 0 sites (auth will fail)
 	if len(result) != 0 {
@@ -520,7 +685,7 @@ func TestCollectAllMetricsWithOrgID(t *testing.T) {
 	orgID := "org-uuid-12345"
 
 	// This should complete without panic, handling auth failure gracefully
-	result := CollectAllMetrics(ctx, client, tokens, environment, 0, orgID, nil)
+	result := CollectAllMetrics(ctx, client, tokens, environment, "", 0, orgID, nil)
 
 	// With invalid tokens, we expect 0 sites
 	if len(result) != 0 {
@@ -537,7 +702,7 @@ func TestCollectAccountMetricsWithOrgID(t *testing.T) {
 	orgID := "org-uuid-12345"
 
 	// This should complete without panic, handling auth failure gracefully
-	siteMetrics, successCount, failCount := collectAccountMetrics(ctx, client, token, environment, 0, 0, orgID, nil)
+	siteMetrics, successCount, failCount := collectAccountMetrics(ctx, client, token, environment, "", 0, 0, orgID, nil)
 
 	// With invalid token, we expect 0 metrics (auth will fail)
 	if len(siteMetrics) != 0 {
@@ -550,3 +715,946 @@ func TestCollectAccountMetricsWithOrgID(t *testing.T) {
 		t.Errorf("Expected 0 fail count, got %d", failCount)
 	}
 }
+
+// TestStartRefreshManagerWithConfigure tests that configure functions run before start
+func TestStartRefreshManagerWithConfigure(t *testing.T) {
+	client := pantheon.NewClient(false)
+	c := collector.NewPantheonCollector([]pantheon.SiteMetrics{})
+
+	called := false
+	manager := StartRefreshManager(client, []string{}, testEnvLive, 1*time.Minute, c, 0, "", func(rm *refresh.Manager) {
+		called = true
+		rm.SetJitter(10)
+	})
+
+	if manager == nil {
+		t.Fatal("Expected refresh manager to be created, got nil")
+	}
+	if !called {
+		t.Error("Expected configure function to be called")
+	}
+}
+
+// TestCreateSiteMetricsHandler tests querying a single site's metrics as JSON
+func TestCreateSiteMetricsHandler(t *testing.T) {
+	metricsData := map[string]pantheon.MetricData{
+		"1762732800": {
+			DateTime:      "2025-11-10T00:00:00",
+			Visits:        100,
+			PagesServed:   500,
+			CacheHits:     50,
+			CacheMisses:   450,
+			CacheHitRatio: "10%",
+		},
+	}
+	c := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{
+			SiteName:    "testsite1",
+			SiteID:      "site-uuid-1",
+			Label:       "testsite1",
+			PlanName:    "Basic",
+			Account:     "account1@example.com",
+			MetricsData: metricsData,
+		},
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(siteMetricsPattern, createSiteMetricsHandler(c))
+
+	req := httptest.NewRequest("GET", "/api/site/account1@example.com/testsite1/metrics", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %s", contentType)
+	}
+
+	var result map[string]pantheon.MetricData
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(result) != 1 {
+		t.Fatalf("Expected 1 metric entry, got %d", len(result))
+	}
+	if result["1762732800"].Visits != 100 {
+		t.Errorf("Expected 100 visits, got %d", result["1762732800"].Visits)
+	}
+}
+
+// TestCreateSiteMetricsHandlerNotFound tests querying a site that doesn't exist
+func TestCreateSiteMetricsHandlerNotFound(t *testing.T) {
+	c := collector.NewPantheonCollector([]pantheon.SiteMetrics{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(siteMetricsPattern, createSiteMetricsHandler(c))
+
+	req := httptest.NewRequest("GET", "/api/site/account1/missing/metrics", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Result().StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Result().StatusCode)
+	}
+}
+
+// stubDiscoveryResetter is a DiscoveryResetter counting its resets
+type stubDiscoveryResetter struct {
+	resets int
+}
+
+func (s *stubDiscoveryResetter) ResetDiscoveredSites() {
+	s.resets++
+}
+
+// TestResetHandler tests that the reset endpoint requires the admin token and
+// clears all sites
+func TestResetHandler(t *testing.T) {
+	c := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{
+			SiteName:    "testsite1",
+			Account:     "account1",
+			MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 100}},
+		},
+	})
+	resetter := &stubDiscoveryResetter{}
+	mux := http.NewServeMux()
+	SetupResetHandler(mux, resetter, c, "secret")
+
+	tests := []struct {
+		name   string
+		method string
+		auth   string
+		status int
+	}{
+		{"GET", "GET", "Bearer secret", http.StatusMethodNotAllowed},
+		{"no token", "POST", "", http.StatusUnauthorized},
+		{"wrong token", "POST", "Bearer wrong", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/metrics/reset", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+	if len(c.GetSites()) != 1 || resetter.resets != 0 {
+		t.Fatal("Expected rejected requests to leave sites untouched")
+	}
+
+	req := httptest.NewRequest("POST", "/metrics/reset", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if sites := c.GetSites(); len(sites) != 0 {
+		t.Errorf("Expected no sites after reset, got %d", len(sites))
+	}
+	if resetter.resets != 1 {
+		t.Errorf("Expected the reset to forget discovered sites, got %d resets", resetter.resets)
+	}
+}
+
+func TestSetupResetHandlerWithoutToken(t *testing.T) {
+	c := collector.NewPantheonCollector([]pantheon.SiteMetrics{{SiteName: "testsite1", Account: "account1"}})
+	mux := http.NewServeMux()
+	SetupResetHandler(mux, &stubDiscoveryResetter{}, c, "")
+
+	req := httptest.NewRequest("POST", "/metrics/reset", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound || len(c.GetSites()) != 1 {
+		t.Errorf("Expected the route to be disabled without an admin token, got status %d", w.Code)
+	}
+}
+
+// stubSiteListReloader is a SiteListReloader counting its reloads
+type stubSiteListReloader struct {
+	reloads int
+}
+
+func (s *stubSiteListReloader) ReloadSiteLists() {
+	s.reloads++
+}
+
+func TestReloadHandler(t *testing.T) {
+	c := collector.NewPantheonCollector([]pantheon.SiteMetrics{{SiteName: "testsite1", Account: "account1"}})
+	reloader := &stubSiteListReloader{}
+	mux := http.NewServeMux()
+	SetupReloadHandler(mux, reloader, c, "secret")
+
+	req := httptest.NewRequest("POST", "/api/sitelists/reload", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized || reloader.reloads != 0 {
+		t.Errorf("Expected a request without the token to be rejected, got status %d and %d reloads", w.Code, reloader.reloads)
+	}
+
+	req = httptest.NewRequest("POST", "/api/sitelists/reload", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || reloader.reloads != 1 {
+		t.Fatalf("Expected the site lists to be reloaded, got status %d and %d reloads", w.Code, reloader.reloads)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "1 sites") {
+		t.Errorf("Expected the response to count the monitored sites, got %q", body)
+	}
+
+	// Without an admin token the route isn't registered
+	mux = http.NewServeMux()
+	SetupReloadHandler(mux, reloader, c, "")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/sitelists/reload", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected the route to be disabled without an admin token, got status %d", w.Code)
+	}
+}
+
+// TestCreateMetricsHandlerWaitForFirstCollection tests the readiness gate on /metrics
+func TestCreateMetricsHandlerWaitForFirstCollection(t *testing.T) {
+	c := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{
+			SiteName:    "testsite1",
+			SiteID:      "site-uuid-1",
+			Label:       "testsite1",
+			PlanName:    "Basic",
+			Account:     "account1",
+			MetricsData: make(map[string]pantheon.MetricData),
+		},
+	})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	handler := createMetricsHandler(registry, c, true)
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Result().StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 before first collection, got %d", w.Result().StatusCode)
+	}
+
+	c.UpdateSiteMetrics("account1", "testsite1", map[string]pantheon.MetricData{
+		"1762732800": {Visits: 100, PagesServed: 500, CacheHitRatio: "10%"},
+	})
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Result().StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 after first collection, got %d", w.Result().StatusCode)
+	}
+	if !strings.Contains(w.Body.String(), "pantheon_visits_total") {
+		t.Error("Expected metrics output after first collection")
+	}
+}
+
+// TestCreateMetricsHandlerNoWait tests that /metrics is served immediately by default
+func TestCreateMetricsHandlerNoWait(t *testing.T) {
+	c := collector.NewPantheonCollector([]pantheon.SiteMetrics{})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	handler := createMetricsHandler(registry, c, false)
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Result().StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Result().StatusCode)
+	}
+}
+
+// TestCreateSiteMetricsWithCreated tests that the site creation timestamp is carried through
+func TestCreateSiteMetricsWithCreated(t *testing.T) {
+	result := createSiteMetrics("testsite", "site-uuid", "account1", "Basic", 1735689600, map[string]pantheon.MetricData{})
+
+	if result.Created != 1735689600 {
+		t.Errorf("Expected Created 1735689600, got %d", result.Created)
+	}
+}
+
+// TestCollectAllSiteListsWithSiteFilterMissing tests that requested sites are reported when not found
+func TestCollectAllSiteListsWithSiteFilterMissing(t *testing.T) {
+	client := pantheon.NewClient(false)
+	ctx := context.Background()
+	siteFilter := filter.Sites{Names: []string{"site-a", "site-typo"}}
+
+	// Invalid tokens will fail to authenticate, so no sites are found
+	result, tokenSiteData := CollectAllSiteLists(ctx, client, []string{"invalid-token"}, 0, "", siteFilter)
+
+	if len(result) != 0 {
+		t.Errorf("Expected 0 sites, got %d", len(result))
+	}
+	if len(tokenSiteData) != 0 {
+		t.Errorf("Expected empty token site data, got %d", len(tokenSiteData))
+	}
+}
+
+// siteListClient is a pantheon.ClientInterface that lists a fixed set of
+// sites for each token
+type siteListClient struct {
+	stallingClient
+	accounts map[string]string                            // token -> account
+	sites    map[string]map[string]pantheon.SiteListEntry // token -> site ID -> site
+	listed   []string                                     // Tokens whose sites were listed
+}
+
+func (c *siteListClient) Authenticate(_ context.Context, token string) (string, error) {
+	return c.accounts[token], nil
+}
+
+func (c *siteListClient) FetchAllSites(_ context.Context, token, _ string) (map[string]pantheon.SiteListEntry, error) {
+	c.listed = append(c.listed, token)
+	return c.sites[token], nil
+}
+
+func TestCollectAllSiteListsDedupePreferredAccountListedLater(t *testing.T) {
+	shared := map[string]pantheon.SiteListEntry{"uuid-a": {Name: "site-a", ID: "uuid-a"}}
+	client := &siteListClient{
+		accounts: map[string]string{"agency-token": "agency@example.com", "client-token": "client@example.com"},
+		sites:    map[string]map[string]pantheon.SiteListEntry{"agency-token": shared, "client-token": shared},
+	}
+	tokens := []string{"agency-token", "client-token"}
+
+	// Without dedupe, listing stops once every requested site is found
+	result, _ := CollectAllSiteLists(context.Background(), client, tokens, 0, "", filter.Sites{Names: []string{"site-a"}})
+	if len(result) != 1 || len(client.listed) != 1 {
+		t.Errorf("Expected listing to stop after the first account, got %v listed and %v", client.listed, result)
+	}
+
+	// With dedupe, the preferred account listed second still owns the site
+	client.listed = nil
+	siteFilter := filter.Sites{Names: []string{"site-a"}, Dedupe: true, PreferAccounts: []string{"client@example.com"}}
+	result, tokenSiteData := CollectAllSiteLists(context.Background(), client, tokens, 0, "", siteFilter)
+	if len(client.listed) != 2 {
+		t.Errorf("Expected every account to be listed with dedupe, got %v", client.listed)
+	}
+	if len(result) != 1 || result[0].Account != "client@example.com" {
+		t.Fatalf("Expected site-a to be attributed to the preferred account, got %v", result)
+	}
+	if len(tokenSiteData["agency-token"].Sites) != 0 || len(tokenSiteData["client-token"].Sites) != 1 {
+		t.Errorf("Expected site-a only under the preferred account's token, got %v", tokenSiteData)
+	}
+}
+
+func TestPushMetrics(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName: "site1",
+			Label:    "Site 1",
+			PlanName: "Basic",
+			Account:  "account1",
+			MetricsData: map[string]pantheon.MetricData{
+				"1762646400": {Visits: 100, PagesServed: 400, CacheHitRatio: "10%"},
+				"1762732800": {Visits: 200, PagesServed: 800, CacheHitRatio: "20%"},
+			},
+		},
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector.NewPantheonCollector(sites))
+
+	var method, path string
+	families := make(map[string][]float64)
+	hasTimestamp := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		path = r.URL.Path
+		decoder := expfmt.NewDecoder(r.Body, expfmt.ResponseFormat(r.Header))
+		for {
+			var mf dto.MetricFamily
+			if err := decoder.Decode(&mf); err != nil {
+				break
+			}
+			for _, m := range mf.GetMetric() {
+				families[mf.GetName()] = append(families[mf.GetName()], m.GetGauge().GetValue())
+				if m.TimestampMs != nil {
+					hasTimestamp = true
+				}
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	if err := PushMetrics(server.URL, "test_job", registry); err != nil {
+		t.Fatalf("Expected push to succeed, got %v", err)
+	}
+
+	if method != http.MethodPut {
+		t.Errorf("Expected PUT request, got %s", method)
+	}
+	if path != "/metrics/job/test_job" {
+		t.Errorf("Expected path /metrics/job/test_job, got %s", path)
+	}
+	if hasTimestamp {
+		t.Error("Expected pushed metrics to have no timestamps")
+	}
+
+	for _, name := range []string{"pantheon_visits_total", "pantheon_pages_served_total", "pantheon_cache_hits_total", "pantheon_cache_misses_total", "pantheon_cache_hit_ratio"} {
+		if len(families[name]) != 1 {
+			t.Errorf("Expected 1 sample for %s, got %d", name, len(families[name]))
+		}
+	}
+	if visits := families["pantheon_visits_total"]; len(visits) == 1 && visits[0] != 200 {
+		t.Errorf("Expected latest visits of 200, got %v", visits[0])
+	}
+}
+
+func TestPushMetricsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	if err := PushMetrics(server.URL, "test_job", prometheus.NewRegistry()); err == nil {
+		t.Error("Expected error when the Pushgateway rejects the push")
+	}
+}
+
+// stallingClient is a pantheon.ClientInterface whose metrics fetches succeed
+// for the first fastFetches calls and then block until the context is done
+type stallingClient struct {
+	mu          sync.Mutex
+	fastFetches int
+	fetches     int
+}
+
+func (c *stallingClient) Authenticate(_ context.Context, _ string) (string, error) {
+	return "account@example.com", nil
+}
+
+func (c *stallingClient) GetEmail(_ context.Context, _ string) (string, error) {
+	return "account@example.com", nil
+}
+
+func (c *stallingClient) FetchAllSites(_ context.Context, _, _ string) (map[string]pantheon.SiteListEntry, error) {
+	return nil, nil
+}
+
+func (c *stallingClient) FetchMetricsData(ctx context.Context, _, _, _, _ string) (map[string]pantheon.MetricData, error) {
+	c.mu.Lock()
+	c.fetches++
+	fast := c.fetches <= c.fastFetches
+	c.mu.Unlock()
+
+	if fast {
+		return map[string]pantheon.MetricData{"1762732800": {Visits: 1}}, nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (c *stallingClient) FetchSiteInfo(_ context.Context, _, _ string) (*pantheon.SiteInfo, error) {
+	return nil, nil
+}
+
+func (c *stallingClient) InvalidateOrgCache() {}
+
+func (c *stallingClient) InvalidateSession(_ string) {}
+
+func TestCollectAllMetricsWithSitesTimeout(t *testing.T) {
+	client := &stallingClient{fastFetches: 1}
+	tokens := []string{"token1", "token2"}
+	preFetchedSites := map[string]AccountSiteData{
+		"token1": {AccountID: "account1", Sites: map[string]pantheon.SiteListEntry{
+			"site-1": {Name: "site1"},
+			"site-2": {Name: "site2"},
+			"site-3": {Name: "site3"},
+		}},
+		"token2": {AccountID: "account2", Sites: map[string]pantheon.SiteListEntry{
+			"site-4": {Name: "site4"},
+		}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result := CollectAllMetricsWithSites(ctx, client, tokens, testEnvLive, "", InitialMetricsDuration, preFetchedSites, 0, nil)
+	elapsed := time.Since(start)
+
+	if elapsed > time.Second {
+		t.Errorf("Expected collection to stop at the deadline, took %v", elapsed)
+	}
+	if len(result) != 1 {
+		t.Errorf("Expected the 1 site collected before the deadline, got %d", len(result))
+	}
+	if client.fetches != 2 {
+		t.Errorf("Expected no fetches after the deadline, got %d fetches", client.fetches)
+	}
+}
+
+func TestPruneSiteData(t *testing.T) {
+	tokenSiteData := map[string]AccountSiteData{
+		"token1": {AccountID: "agency@example.com", Sites: map[string]pantheon.SiteListEntry{
+			"shared":      {Name: "client-site"},
+			"agency-only": {Name: "agency-site"},
+		}},
+		"token2": {AccountID: "client@example.com", Sites: map[string]pantheon.SiteListEntry{
+			"shared": {Name: "client-site"},
+		}},
+	}
+	kept := []pantheon.SiteMetrics{
+		{SiteID: "agency-only", Account: "agency@example.com"},
+		{SiteID: "shared", Account: "client@example.com"},
+	}
+
+	pruneSiteData(tokenSiteData, kept)
+
+	if _, ok := tokenSiteData["token1"].Sites["shared"]; ok {
+		t.Error("Expected shared site to be pruned from the non-owning account")
+	}
+	if _, ok := tokenSiteData["token1"].Sites["agency-only"]; !ok {
+		t.Error("Expected unshared site to be kept")
+	}
+	if _, ok := tokenSiteData["token2"].Sites["shared"]; !ok {
+		t.Error("Expected shared site to be kept for the owning account")
+	}
+}
+
+func TestCheckAccounts(t *testing.T) {
+	noAccounts := map[string]AccountSiteData{}
+	oneAccount := map[string]AccountSiteData{
+		"token1": {AccountID: "account1", Sites: map[string]pantheon.SiteListEntry{}},
+	}
+
+	if err := CheckAccounts(true, noAccounts); err == nil {
+		t.Error("Expected error when no accounts authenticated and failIfNoAccounts is set")
+	}
+	if err := CheckAccounts(false, noAccounts); err != nil {
+		t.Errorf("Expected no error when failIfNoAccounts is unset, got %v", err)
+	}
+	if err := CheckAccounts(true, oneAccount); err != nil {
+		t.Errorf("Expected no error with an authenticated account, got %v", err)
+	}
+}
+
+func TestCollectInitialMetrics(t *testing.T) {
+	client := &stallingClient{fastFetches: 1}
+	tokens := []string{"token1"}
+	preFetchedSites := map[string]AccountSiteData{
+		"token1": {AccountID: "account1", Sites: map[string]pantheon.SiteListEntry{
+			"site-1": {Name: "site1"},
+			"site-2": {Name: "site2"},
+		}},
+	}
+	c := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "site1", SiteID: "site-1", Account: "account1", MetricsData: map[string]pantheon.MetricData{}},
+		{SiteName: "site2", SiteID: "site-2", Account: "account1", MetricsData: map[string]pantheon.MetricData{}},
+	})
+
+	start := time.Now()
+	result := CollectInitialMetrics(context.Background(), client, tokens, testEnvLive, "", InitialMetricsDuration, preFetchedSites, 0, c, 50*time.Millisecond, nil)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected collection to stop at the timeout, took %v", elapsed)
+	}
+
+	if len(result) != 1 {
+		t.Fatalf("Expected 1 site collected before the timeout, got %d", len(result))
+	}
+	if !c.HasAnyMetrics() {
+		t.Error("Expected the collector to be updated with fetched metrics")
+	}
+
+	// The site whose fetch was cut off is recorded as failed
+	failed := 0
+	for _, site := range []string{"site1", "site2"} {
+		if c.GetSiteStatus("account1", site).LastFailed {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("Expected 1 site recorded as failed, got %d", failed)
+	}
+}
+
+// accountsClient is a pantheon.ClientInterface that authenticates tokens to fixed emails
+type accountsClient struct {
+	pantheon.ClientInterface
+	emails map[string]string // token -> email
+	auths  int
+}
+
+func (c *accountsClient) Authenticate(_ context.Context, token string) (string, error) {
+	c.auths++
+	email, ok := c.emails[token]
+	if !ok {
+		return "", pantheon.ErrAuthFailed
+	}
+	return email, nil
+}
+
+func (c *accountsClient) GetEmail(ctx context.Context, token string) (string, error) {
+	return c.Authenticate(ctx, token)
+}
+
+func TestAccountAllowed(t *testing.T) {
+	client := &accountsClient{emails: map[string]string{
+		"token1": "allowed@example.com",
+		"token2": "other@example.com",
+	}}
+	siteFilter := filter.Sites{Accounts: []string{"allowed@example.com"}}
+
+	if !accountAllowed(context.Background(), client, siteFilter, "token1", "allowed@example.com") {
+		t.Error("Expected the allowed account to be kept")
+	}
+	if accountAllowed(context.Background(), client, siteFilter, "token2", "other@example.com") {
+		t.Error("Expected the disallowed account to be dropped")
+	}
+	if accountAllowed(context.Background(), client, siteFilter, "token3", "unknown") {
+		t.Error("Expected an account whose email can't be found to be dropped")
+	}
+
+	client.auths = 0
+	if !accountAllowed(context.Background(), client, filter.Sites{}, "token2", "other@example.com") {
+		t.Error("Expected every account to be kept without an allowlist")
+	}
+	if client.auths != 0 {
+		t.Errorf("Expected no email lookup without an allowlist, got %d", client.auths)
+	}
+}
+
+func TestSelectAccount(t *testing.T) {
+	tokenA := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa11111111"
+	tokenB := "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb22222222"
+	tokenC := "cccccccccccccccccccccccccccccccc33333333"
+	tokens := []string{tokenA, tokenB, tokenC}
+
+	tests := []struct {
+		name      string
+		account   string
+		expected  []string
+		wantAuths bool
+	}{
+		{name: "account ID", account: "22222222", expected: []string{tokenB}},
+		{name: "account label", account: "c@example.com", expected: []string{tokenC}, wantAuths: true},
+		{name: "account label is case-insensitive", account: "A@Example.com", expected: []string{tokenA}, wantAuths: true},
+		{name: "no match", account: "nobody@example.com", expected: nil, wantAuths: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &accountsClient{emails: map[string]string{
+				tokenA: "a@example.com",
+				// tokenB fails to authenticate
+				tokenC: "c@example.com",
+			}}
+
+			selected := SelectAccount(context.Background(), client, tokens, tt.account)
+			if len(selected) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, selected)
+			}
+			for i := range selected {
+				if selected[i] != tt.expected[i] {
+					t.Errorf("Expected %v, got %v", tt.expected, selected)
+				}
+			}
+			if (client.auths > 0) != tt.wantAuths {
+				t.Errorf("Expected authentication only when matching by email, got %d calls", client.auths)
+			}
+		})
+	}
+}
+
+func TestLimitAccounts(t *testing.T) {
+	tokens := []string{"token1", "token2", "token3"}
+
+	tests := []struct {
+		limit    int
+		expected int
+	}{
+		{limit: 0, expected: 3},
+		{limit: 1, expected: 1},
+		{limit: 2, expected: 2},
+		{limit: 3, expected: 3},
+		{limit: 10, expected: 3},
+	}
+
+	for _, tt := range tests {
+		limited := LimitAccounts(tokens, tt.limit)
+		if len(limited) != tt.expected {
+			t.Errorf("Limit %d: expected %d tokens, got %v", tt.limit, tt.expected, limited)
+		}
+		for i := range limited {
+			if limited[i] != tokens[i] {
+				t.Errorf("Limit %d: expected the first tokens in order, got %v", tt.limit, limited)
+			}
+		}
+	}
+}
+
+func TestCollectAllMetricsAccountLimit(t *testing.T) {
+	// None of the tokens authenticate, so each processed account costs one call
+	client := &accountsClient{}
+	tokens := []string{"token1", "token2", "token3", "token4"}
+
+	CollectAllMetrics(context.Background(), client, LimitAccounts(tokens, 2), testEnvLive, "", 0, "", nil)
+	if client.auths != 2 {
+		t.Errorf("Expected only 2 accounts to be processed, got %d", client.auths)
+	}
+}
+
+// staticAccountStatuses is an AccountStatusSource returning fixed statuses
+type staticAccountStatuses []refresh.AccountStatus
+
+func (s staticAccountStatuses) AccountStatuses() []refresh.AccountStatus {
+	return s
+}
+
+func TestCreateAccountsHandler(t *testing.T) {
+	refreshed := time.Date(2025, 11, 10, 12, 0, 0, 0, time.UTC)
+	source := staticAccountStatuses{
+		{Account: "a@example.com", Authenticated: true, LastSiteListRefresh: refreshed},
+		{Account: "22222222", Authenticated: false, LastError: "authentication failed"},
+	}
+	c := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "site1", Account: "a@example.com"},
+		{SiteName: "site2", Account: "a@example.com"},
+	})
+
+	req := httptest.NewRequest("GET", "/api/accounts", nil)
+	w := httptest.NewRecorder()
+	createAccountsHandler(source, c)(w, req)
+
+	if contentType := w.Result().Header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %s", contentType)
+	}
+
+	var accounts []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &accounts); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(accounts) != 2 {
+		t.Fatalf("Expected 2 accounts, got %d", len(accounts))
+	}
+
+	healthy := accounts[0]
+	if healthy["account"] != "a@example.com" || healthy["authenticated"] != true || healthy["sites"] != float64(2) {
+		t.Errorf("Unexpected healthy account entry: %v", healthy)
+	}
+	if healthy["last_site_list_refresh"] != "2025-11-10T12:00:00Z" {
+		t.Errorf("Expected last site list refresh timestamp, got %v", healthy["last_site_list_refresh"])
+	}
+	if _, ok := healthy["error"]; ok {
+		t.Errorf("Expected no error field for a healthy account, got %v", healthy["error"])
+	}
+
+	failing := accounts[1]
+	if failing["account"] != "22222222" || failing["authenticated"] != false || failing["sites"] != float64(0) {
+		t.Errorf("Unexpected failing account entry: %v", failing)
+	}
+	if failing["last_site_list_refresh"] != nil {
+		t.Errorf("Expected null last site list refresh, got %v", failing["last_site_list_refresh"])
+	}
+	if failing["error"] != "authentication failed" {
+		t.Errorf("Expected error message, got %v", failing["error"])
+	}
+}
+
+// TestCreateMetricsHandlerGzip tests that scrapes accepting gzip get a compressed response
+func TestCreateMetricsHandlerGzip(t *testing.T) {
+	c := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{
+			SiteName:    "testsite1",
+			Label:       "testsite1",
+			PlanName:    "Basic",
+			Account:     "account1",
+			MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 100, CacheHitRatio: "10%"}},
+		},
+	})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	for _, waitForFirstCollection := range []bool{false, true} {
+		handler := createMetricsHandler(registry, c, waitForFirstCollection)
+
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if encoding := w.Result().Header.Get("Content-Encoding"); encoding != "gzip" {
+			t.Fatalf("wait=%v: expected gzip Content-Encoding, got %q", waitForFirstCollection, encoding)
+		}
+		reader, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("wait=%v: expected a gzip body: %v", waitForFirstCollection, err)
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("wait=%v: failed to decompress body: %v", waitForFirstCollection, err)
+		}
+		if !strings.Contains(string(body), "pantheon_visits_total") {
+			t.Errorf("wait=%v: expected metrics in decompressed body", waitForFirstCollection)
+		}
+	}
+
+	// Clients that don't ask for compression get plain text
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	createMetricsHandler(registry, c, false).ServeHTTP(w, req)
+	if encoding := w.Result().Header.Get("Content-Encoding"); encoding != "" {
+		t.Errorf("Expected no Content-Encoding without Accept-Encoding, got %q", encoding)
+	}
+}
+
+// stubSiteRefresher is a SiteRefresher that updates known sites in a collector
+type stubSiteRefresher struct {
+	c         *collector.PantheonCollector
+	err       error
+	refreshed []string
+}
+
+func (s *stubSiteRefresher) RefreshSite(accountID, siteName string) error {
+	s.refreshed = append(s.refreshed, accountID+"."+siteName)
+	if s.err != nil {
+		return s.err
+	}
+	if _, ok := s.c.GetSite(accountID, siteName); !ok {
+		return refresh.ErrUnknownSite
+	}
+	s.c.UpdateSiteMetrics(accountID, siteName, map[string]pantheon.MetricData{"1762732800": {Visits: 250}})
+	return nil
+}
+
+func TestSiteRefreshHandler(t *testing.T) {
+	c := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "testsite1", Account: "account1", MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 100}}},
+	})
+	refresher := &stubSiteRefresher{c: c}
+	mux := http.NewServeMux()
+	SetupSiteRefreshHandler(mux, refresher, c, "secret")
+
+	tests := []struct {
+		name   string
+		path   string
+		auth   string
+		status int
+	}{
+		{"no token", "/api/site/account1/testsite1/refresh", "", http.StatusUnauthorized},
+		{"wrong token", "/api/site/account1/testsite1/refresh", "Bearer wrong", http.StatusUnauthorized},
+		{"not found", "/api/site/account1/missing/refresh", "Bearer secret", http.StatusNotFound},
+		{"found", "/api/site/account1/testsite1/refresh", "Bearer secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.path, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+
+	if len(refresher.refreshed) != 2 {
+		t.Errorf("Expected only authorized requests to refresh, got %v", refresher.refreshed)
+	}
+
+	req := httptest.NewRequest("POST", "/api/site/account1/testsite1/refresh", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	var result map[string]pantheon.MetricData
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result["1762732800"].Visits != 250 {
+		t.Errorf("Expected the refreshed metrics in the response, got %v", result)
+	}
+}
+
+func TestSiteRefreshHandlerError(t *testing.T) {
+	c := collector.NewPantheonCollector([]pantheon.SiteMetrics{{SiteName: "testsite1", Account: "account1"}})
+	refresher := &stubSiteRefresher{c: c, err: errors.New("api unavailable")}
+	mux := http.NewServeMux()
+	SetupSiteRefreshHandler(mux, refresher, c, "secret")
+
+	req := httptest.NewRequest("POST", "/api/site/account1/testsite1/refresh", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("Expected status 502, got %d", w.Code)
+	}
+}
+
+func TestSetupSiteRefreshHandlerWithoutToken(t *testing.T) {
+	c := collector.NewPantheonCollector([]pantheon.SiteMetrics{{SiteName: "testsite1", Account: "account1"}})
+	refresher := &stubSiteRefresher{c: c}
+	mux := http.NewServeMux()
+	SetupSiteRefreshHandler(mux, refresher, c, "")
+
+	req := httptest.NewRequest("POST", "/api/site/account1/testsite1/refresh", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound || len(refresher.refreshed) != 0 {
+		t.Errorf("Expected the route to be disabled without an admin token, got status %d", w.Code)
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	c := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "site1", Label: "Site One", PlanName: "Basic", Account: "account1",
+			MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 100, PagesServed: 250, CacheHitRatio: "50%"}}},
+		{SiteName: "site2", Label: "Site Two", PlanName: "Elite", Account: "account2",
+			MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 7, CacheHitRatio: "--"}}},
+	})
+	c.SetNoTimestamps(true)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	text, err := RenderText(registry)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, line := range []string{
+		"# TYPE pantheon_visits_total gauge",
+		`pantheon_visits_total{account="account1",plan="Basic",plan_slug="basic",site_id="site1",site_name="Site One"} 100`,
+		`pantheon_visits_total{account="account2",plan="Elite",plan_slug="elite",site_id="site2",site_name="Site Two"} 7`,
+		`pantheon_pages_served_total{account="account1",plan="Basic",plan_slug="basic",site_id="site1",site_name="Site One"} 250`,
+		`pantheon_cache_hit_ratio{account="account1",plan="Basic",plan_slug="basic",site_id="site1",site_name="Site One"} 0.5`,
+	} {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("Expected rendered text to contain %q, got:\n%s", line, text)
+		}
+	}
+}
+
+func TestCollectAllMetricsWithSitesLimitStable(t *testing.T) {
+	preFetchedSites := map[string]AccountSiteData{
+		"token1": {AccountID: "account1", Sites: map[string]pantheon.SiteListEntry{}},
+	}
+	for _, name := range []string{"echo", "alpha", "delta", "charlie", "bravo", "foxtrot"} {
+		preFetchedSites["token1"].Sites["uuid-"+name] = pantheon.SiteListEntry{Name: name, ID: "uuid-" + name}
+	}
+
+	for run := 0; run < 20; run++ {
+		client := &stallingClient{fastFetches: 100}
+		result := CollectAllMetricsWithSites(context.Background(), client, []string{"token1"}, testEnvLive, "", InitialMetricsDuration, preFetchedSites, 3, nil)
+
+		var names []string
+		for _, site := range result {
+			names = append(names, site.SiteName)
+		}
+		if strings.Join(names, ",") != "alpha,bravo,charlie" {
+			t.Fatalf("Run %d: expected the site limit to keep alpha, bravo, and charlie, got %v", run, names)
+		}
+	}
+}
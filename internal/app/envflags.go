@@ -0,0 +1,57 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// EnvPrefix is prepended to the environment variable name of every flag
+const EnvPrefix = "PANTHEON_EXPORTER_"
+
+// EnvVarName returns the environment variable that sets a flag, e.g.
+// "refreshInterval" becomes "PANTHEON_EXPORTER_REFRESH_INTERVAL" and
+// "orgCacheTTL" becomes "PANTHEON_EXPORTER_ORG_CACHE_TTL".
+func EnvVarName(flagName string) string {
+	runes := []rune(flagName)
+	var b strings.Builder
+	b.WriteString(EnvPrefix)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// ApplyEnvFlags sets each flag that wasn't given on the command line from its
+// environment variable, if set. It must be called after fs is parsed, so
+// command-line flags take precedence.
+func ApplyEnvFlags(fs *flag.FlagSet, lookupEnv func(string) (string, bool)) error {
+	setOnCommandLine := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		setOnCommandLine[f.Name] = true
+	})
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || setOnCommandLine[f.Name] {
+			return
+		}
+		name := EnvVarName(f.Name)
+		value, ok := lookupEnv(name)
+		if !ok {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid value %q for %s: %w", value, name, setErr)
+		}
+	})
+	return err
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestEnvVarName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "env", expected: "PANTHEON_EXPORTER_ENV"},
+		{input: "port", expected: "PANTHEON_EXPORTER_PORT"},
+		{input: "refreshInterval", expected: "PANTHEON_EXPORTER_REFRESH_INTERVAL"},
+		{input: "orgID", expected: "PANTHEON_EXPORTER_ORG_ID"},
+		{input: "orgCacheTTL", expected: "PANTHEON_EXPORTER_ORG_CACHE_TTL"},
+		{input: "httpProxy", expected: "PANTHEON_EXPORTER_HTTP_PROXY"},
+	}
+
+	for _, tt := range tests {
+		if got := EnvVarName(tt.input); got != tt.expected {
+			t.Errorf("EnvVarName(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// newTestFlagSet returns a flag set with an env and port flag
+func newTestFlagSet() (*flag.FlagSet, *string, *int) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	env := fs.String("env", "live", "")
+	port := fs.Int("port", 8080, "")
+	return fs, env, port
+}
+
+func TestApplyEnvFlagsEnvOnly(t *testing.T) {
+	fs, env, port := newTestFlagSet()
+	if err := fs.Parse(nil); err != nil {
+		t.Fatalf("Unexpected parse error: %v", err)
+	}
+
+	vars := map[string]string{"PANTHEON_EXPORTER_PORT": "9090"}
+	lookup := func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+	if err := ApplyEnvFlags(fs, lookup); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if *port != 9090 {
+		t.Errorf("Expected port from environment 9090, got %d", *port)
+	}
+	if *env != "live" {
+		t.Errorf("Expected default env to be kept, got %q", *env)
+	}
+}
+
+func TestApplyEnvFlagsCommandLineTakesPrecedence(t *testing.T) {
+	fs, env, port := newTestFlagSet()
+	if err := fs.Parse([]string{"-env", "dev"}); err != nil {
+		t.Fatalf("Unexpected parse error: %v", err)
+	}
+
+	vars := map[string]string{
+		"PANTHEON_EXPORTER_ENV":  "test",
+		"PANTHEON_EXPORTER_PORT": "9090",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+	if err := ApplyEnvFlags(fs, lookup); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if *env != "dev" {
+		t.Errorf("Expected command-line env to win, got %q", *env)
+	}
+	if *port != 9090 {
+		t.Errorf("Expected port from environment 9090, got %d", *port)
+	}
+}
+
+func TestApplyEnvFlagsInvalidValue(t *testing.T) {
+	fs, _, _ := newTestFlagSet()
+	if err := fs.Parse(nil); err != nil {
+		t.Fatalf("Unexpected parse error: %v", err)
+	}
+
+	lookup := func(name string) (string, bool) {
+		if name == "PANTHEON_EXPORTER_PORT" {
+			return "not-a-number", true
+		}
+		return "", false
+	}
+	if err := ApplyEnvFlags(fs, lookup); err == nil {
+		t.Error("Expected error for invalid environment value")
+	}
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"log"
+	"sync"
+)
+
+// DefaultProgressLogInterval is how many sites the initial metrics collection
+// processes between progress log lines.
+const DefaultProgressLogInterval = 50
+
+// DefaultInitialFailureThreshold is the fraction of sites whose initial
+// metrics fetch may fail before the initial collection is unhealthy.
+const DefaultInitialFailureThreshold = 0.5
+
+// CollectionProgress tracks how many sites the initial metrics collection has
+// processed, so operators can follow a long collection (thread-safe).
+type CollectionProgress struct {
+	mu               sync.Mutex
+	done             int
+	failed           int
+	total            int
+	started          bool
+	finished         bool
+	healthy          bool
+	logEvery         int     // Sites between progress log lines (0 = never log)
+	failureThreshold float64 // Fraction of processed sites that may fail while healthy
+}
+
+// NewCollectionProgress creates a progress tracker logging every logEvery sites
+func NewCollectionProgress(logEvery int) *CollectionProgress {
+	return &CollectionProgress{logEvery: logEvery, failureThreshold: DefaultInitialFailureThreshold}
+}
+
+// SetFailureThreshold sets the fraction (0-1) of sites whose metrics fetch may
+// fail before the initial collection is considered unhealthy.
+func (p *CollectionProgress) SetFailureThreshold(threshold float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failureThreshold = threshold
+}
+
+// start begins tracking a collection of total sites
+func (p *CollectionProgress) start(total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done = 0
+	p.failed = 0
+	p.total = total
+	p.started = true
+	p.finished = false
+}
+
+// siteDone records that a site's metrics fetch has finished, successfully or not
+func (p *CollectionProgress) siteDone(failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	if failed {
+		p.failed++
+	}
+	if p.logEvery > 0 && p.done%p.logEvery == 0 {
+		log.Printf("Initial metrics collection progress: %d/%d sites", p.done, p.total)
+	}
+}
+
+// finish records the end of the collection, logging an error if more sites
+// failed than the failure threshold allows.
+func (p *CollectionProgress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finished = true
+	p.healthy = collectionHealthy(p.done-p.failed, p.failed, p.failureThreshold)
+	if !p.healthy {
+		log.Printf("Error: Initial metrics collection failed for %d of %d sites, more than the failure threshold of %.0f%%; check the environment and machine tokens", p.failed, p.done, p.failureThreshold*100)
+	}
+}
+
+// InitialCollectionHealthy reports whether the initial metrics collection
+// stayed within the failure threshold, and whether it has finished at all.
+func (p *CollectionProgress) InitialCollectionHealthy() (healthy, finished bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.healthy, p.finished
+}
+
+// collectionHealthy reports whether no more than threshold of the processed
+// sites failed. A collection that processed no sites is healthy.
+func collectionHealthy(succeeded, failed int, threshold float64) bool {
+	processed := succeeded + failed
+	if processed == 0 {
+		return true
+	}
+	return float64(failed)/float64(processed) <= threshold
+}
+
+// InitialCollectionProgress returns the fraction of sites the initial metrics
+// collection has processed, from 0 to 1. It is 0 until the collection starts,
+// and 1 once a collection of no sites has started.
+func (p *CollectionProgress) InitialCollectionProgress() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.started {
+		return 0
+	}
+	if p.total == 0 {
+		return 1
+	}
+	return float64(p.done) / float64(p.total)
+}
+
+// countSites returns how many sites in preFetchedSites belong to tokens,
+// capped at siteLimit when it is positive
+func countSites(tokens []string, preFetchedSites map[string]AccountSiteData, siteLimit int) int {
+	total := 0
+	for _, token := range tokens {
+		total += len(preFetchedSites[token].Sites)
+	}
+	if siteLimit > 0 && total > siteLimit {
+		total = siteLimit
+	}
+	return total
+}
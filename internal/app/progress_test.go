@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/collector"
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+)
+
+// progressClient is a pantheon.ClientInterface recording the collection
+// progress each time a site's metrics are fetched
+type progressClient struct {
+	stallingClient
+	progress *CollectionProgress
+	seen     []float64
+}
+
+func (c *progressClient) FetchMetricsData(_ context.Context, _, _, _, _ string) (map[string]pantheon.MetricData, error) {
+	c.seen = append(c.seen, c.progress.InitialCollectionProgress())
+	return map[string]pantheon.MetricData{"1762732800": {Visits: 1}}, nil
+}
+
+func TestCollectInitialMetricsProgress(t *testing.T) {
+	progress := NewCollectionProgress(DefaultProgressLogInterval)
+	if got := progress.InitialCollectionProgress(); got != 0 {
+		t.Errorf("Expected no progress before the collection starts, got %v", got)
+	}
+
+	client := &progressClient{progress: progress}
+	preFetchedSites := map[string]AccountSiteData{
+		"token1": {AccountID: "account1", Sites: map[string]pantheon.SiteListEntry{
+			"site-1": {Name: "site1"},
+			"site-2": {Name: "site2"},
+		}},
+		"token2": {AccountID: "account2", Sites: map[string]pantheon.SiteListEntry{
+			"site-3": {Name: "site3"},
+			"site-4": {Name: "site4"},
+		}},
+	}
+	c := collector.NewPantheonCollector(nil)
+
+	CollectInitialMetrics(context.Background(), client, []string{"token1", "token2"}, testEnvLive, "", InitialMetricsDuration, preFetchedSites, 0, c, 0, progress)
+
+	expected := []float64{0, 0.25, 0.5, 0.75}
+	if len(client.seen) != len(expected) {
+		t.Fatalf("Expected %d metrics fetches, got %v", len(expected), client.seen)
+	}
+	for i, got := range client.seen {
+		if got != expected[i] {
+			t.Errorf("Expected progress %v before fetch %d, got %v", expected[i], i+1, got)
+		}
+	}
+	if got := progress.InitialCollectionProgress(); got != 1 {
+		t.Errorf("Expected progress 1 after the collection, got %v", got)
+	}
+}
+
+func TestCountSites(t *testing.T) {
+	preFetchedSites := map[string]AccountSiteData{
+		"token1": {Sites: map[string]pantheon.SiteListEntry{"a": {}, "b": {}, "c": {}}},
+		"token2": {Sites: map[string]pantheon.SiteListEntry{"d": {}}},
+	}
+	if got := countSites([]string{"token1", "token2", "token3"}, preFetchedSites, 0); got != 4 {
+		t.Errorf("Expected 4 sites, got %d", got)
+	}
+	if got := countSites([]string{"token1", "token2"}, preFetchedSites, 2); got != 2 {
+		t.Errorf("Expected the site limit to cap the total at 2, got %d", got)
+	}
+
+	empty := NewCollectionProgress(0)
+	empty.start(0)
+	if got := empty.InitialCollectionProgress(); got != 1 {
+		t.Errorf("Expected a collection of no sites to be complete, got %v", got)
+	}
+}
+
+func TestCollectionHealthy(t *testing.T) {
+	tests := []struct {
+		succeeded, failed int
+		threshold         float64
+		expected          bool
+	}{
+		{0, 0, 0.5, true},
+		{10, 0, 0.5, true},
+		{5, 5, 0.5, true},
+		{4, 6, 0.5, false},
+		{0, 10, 0.5, false},
+		{9, 1, 0, false},
+		{0, 10, 1, true},
+	}
+	for _, tt := range tests {
+		if got := collectionHealthy(tt.succeeded, tt.failed, tt.threshold); got != tt.expected {
+			t.Errorf("collectionHealthy(%d, %d, %v) = %v, expected %v", tt.succeeded, tt.failed, tt.threshold, got, tt.expected)
+		}
+	}
+}
+
+func TestCollectionProgressHealthy(t *testing.T) {
+	progress := NewCollectionProgress(0)
+	if _, finished := progress.InitialCollectionHealthy(); finished {
+		t.Errorf("Expected the collection not to be finished before it starts")
+	}
+
+	progress.SetFailureThreshold(0.25)
+	progress.start(4)
+	progress.siteDone(false)
+	progress.siteDone(false)
+	progress.siteDone(true)
+	progress.siteDone(true)
+	progress.finish()
+	if healthy, finished := progress.InitialCollectionHealthy(); healthy || !finished {
+		t.Errorf("Expected 2 of 4 failed sites to exceed a threshold of 0.25, got healthy=%v finished=%v", healthy, finished)
+	}
+
+	progress.SetFailureThreshold(0.5)
+	progress.start(4)
+	progress.siteDone(false)
+	progress.siteDone(true)
+	progress.finish()
+	if healthy, finished := progress.InitialCollectionHealthy(); !healthy || !finished {
+		t.Errorf("Expected 1 of 2 failed sites to be within a threshold of 0.5, got healthy=%v finished=%v", healthy, finished)
+	}
+}
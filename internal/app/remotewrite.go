@@ -0,0 +1,177 @@
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// remoteWriteTimeout bounds each remote-write request, so a slow receiver
+// can't hold up the refresh loop
+const remoteWriteTimeout = 30 * time.Second
+
+// remoteWriteLabel is a label of a remote-write time series
+type remoteWriteLabel struct {
+	name, value string
+}
+
+// remoteWriteSeries is a remote-write time series with a single sample
+type remoteWriteSeries struct {
+	labels []remoteWriteLabel
+	value  float64
+}
+
+// RemoteWriteMetrics sends the latest sample of each series in the registry
+// to a Prometheus remote-write endpoint, timestamped now. Remote-write
+// receivers reject samples far in the past, so the daily timestamps of the
+// collected history are not kept.
+func RemoteWriteMetrics(url string, registry prometheus.Gatherer, now time.Time) error {
+	families, err := latestOnlyGatherer{gatherer: registry}.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+	body := snappyEncode(encodeWriteRequest(remoteWriteSeriesFor(families), now.UnixMilli()))
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create remote-write request: %w", err)
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	req.Header.Set("User-Agent", "pantheon-metrics-exporter")
+
+	client := &http.Client{Timeout: remoteWriteTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to remote-write metrics to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to remote-write metrics to %s: %s: %s", url, resp.Status, bytes.TrimSpace(message))
+	}
+	return nil
+}
+
+// remoteWriteSeriesFor flattens metric families into remote-write series,
+// expanding histograms and summaries into their _bucket, _sum and _count series
+func remoteWriteSeriesFor(families []*dto.MetricFamily) []remoteWriteSeries {
+	var series []remoteWriteSeries
+	for _, mf := range families {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			add := func(name string, value float64, extra ...remoteWriteLabel) {
+				labels := []remoteWriteLabel{{"__name__", name}}
+				for _, lp := range m.GetLabel() {
+					labels = append(labels, remoteWriteLabel{lp.GetName(), lp.GetValue()})
+				}
+				labels = append(labels, extra...)
+				sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+				series = append(series, remoteWriteSeries{labels: labels, value: value})
+			}
+
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add(name, m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				add(name, m.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				for _, q := range s.GetQuantile() {
+					add(name, q.GetValue(), remoteWriteLabel{"quantile", formatFloat(q.GetQuantile())})
+				}
+				add(name+"_sum", s.GetSampleSum())
+				add(name+"_count", float64(s.GetSampleCount()))
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				infSeen := false
+				for _, b := range h.GetBucket() {
+					if math.IsInf(b.GetUpperBound(), 1) {
+						infSeen = true
+					}
+					add(name+"_bucket", float64(b.GetCumulativeCount()), remoteWriteLabel{"le", formatFloat(b.GetUpperBound())})
+				}
+				if !infSeen {
+					add(name+"_bucket", float64(h.GetSampleCount()), remoteWriteLabel{"le", "+Inf"})
+				}
+				add(name+"_sum", h.GetSampleSum())
+				add(name+"_count", float64(h.GetSampleCount()))
+			}
+		}
+	}
+	return series
+}
+
+// formatFloat formats a quantile or bucket bound as Prometheus does in labels
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// encodeWriteRequest encodes series as a remote-write prometheus.WriteRequest
+// protobuf message, each series holding one sample at timestampMs
+func encodeWriteRequest(series []remoteWriteSeries, timestampMs int64) []byte {
+	var out []byte
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.labels {
+			var label []byte
+			label = protowire.AppendTag(label, 1, protowire.BytesType)
+			label = protowire.AppendString(label, l.name)
+			label = protowire.AppendTag(label, 2, protowire.BytesType)
+			label = protowire.AppendString(label, l.value)
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, label)
+		}
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(timestampMs))
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, sample)
+
+		out = protowire.AppendTag(out, 1, protowire.BytesType)
+		out = protowire.AppendBytes(out, ts)
+	}
+	return out
+}
+
+// snappyMaxLiteral is the longest literal written by snappyEncode
+const snappyMaxLiteral = 1 << 16
+
+// snappyEncode encodes src in the snappy block format required by remote
+// write. It only writes literals, trading compression for not needing a
+// snappy dependency; any snappy decoder reads the result.
+func snappyEncode(src []byte) []byte {
+	dst := binary.AppendUvarint(nil, uint64(len(src)))
+	for len(src) > 0 {
+		n := min(len(src), snappyMaxLiteral)
+		switch length := n - 1; {
+		case length < 60:
+			dst = append(dst, byte(length)<<2)
+		case length < 1<<8:
+			dst = append(dst, 60<<2, byte(length))
+		default:
+			dst = append(dst, 61<<2, byte(length), byte(length>>8))
+		}
+		dst = append(dst, src[:n]...)
+		src = src[n:]
+	}
+	return dst
+}
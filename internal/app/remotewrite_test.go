@@ -0,0 +1,256 @@
+package app
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/collector"
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// snappyDecode decodes a snappy block made up of literals, as written by snappyEncode
+func snappyDecode(src []byte) ([]byte, error) {
+	length, n := binary.Uvarint(src)
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid snappy length")
+	}
+	src = src[n:]
+	var dst []byte
+	for len(src) > 0 {
+		tag := src[0]
+		if tag&3 != 0 {
+			return nil, fmt.Errorf("unexpected snappy copy tag %#x", tag)
+		}
+		size := int(tag >> 2)
+		src = src[1:]
+		switch size {
+		case 60:
+			size = int(src[0])
+			src = src[1:]
+		case 61:
+			size = int(src[0]) | int(src[1])<<8
+			src = src[2:]
+		}
+		size++
+		if size > len(src) {
+			return nil, fmt.Errorf("snappy literal overruns input")
+		}
+		dst = append(dst, src[:size]...)
+		src = src[size:]
+	}
+	if uint64(len(dst)) != length {
+		return nil, fmt.Errorf("expected %d decoded bytes, got %d", length, len(dst))
+	}
+	return dst, nil
+}
+
+// decodedSample is a remote-write series decoded by decodeWriteRequest
+type decodedSample struct {
+	labels    string // Sorted name=value pairs, comma-separated
+	value     float64
+	timestamp int64
+}
+
+// protoFields calls fn with the number and raw value of each field of a protobuf message
+func protoFields(t *testing.T, b []byte, fn func(num protowire.Number, typ protowire.Type, value []byte, varint uint64)) {
+	t.Helper()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("Invalid protobuf tag: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+		switch typ {
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				t.Fatalf("Invalid protobuf bytes: %v", protowire.ParseError(n))
+			}
+			fn(num, typ, v, 0)
+			b = b[n:]
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				t.Fatalf("Invalid protobuf varint: %v", protowire.ParseError(n))
+			}
+			fn(num, typ, nil, v)
+			b = b[n:]
+		case protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				t.Fatalf("Invalid protobuf fixed64: %v", protowire.ParseError(n))
+			}
+			fn(num, typ, nil, v)
+			b = b[n:]
+		default:
+			t.Fatalf("Unexpected protobuf wire type %v", typ)
+		}
+	}
+}
+
+// decodeWriteRequest decodes a remote-write WriteRequest, keyed by metric name
+func decodeWriteRequest(t *testing.T, b []byte) map[string][]decodedSample {
+	t.Helper()
+	series := make(map[string][]decodedSample)
+	protoFields(t, b, func(num protowire.Number, _ protowire.Type, ts []byte, _ uint64) {
+		if num != 1 {
+			t.Fatalf("Unexpected WriteRequest field %d", num)
+		}
+		var name string
+		var labels []string
+		var sample decodedSample
+		protoFields(t, ts, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) {
+			switch num {
+			case 1:
+				var labelName, labelValue string
+				protoFields(t, v, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) {
+					if num == 1 {
+						labelName = string(v)
+					} else {
+						labelValue = string(v)
+					}
+				})
+				if labelName == "__name__" {
+					name = labelValue
+				}
+				labels = append(labels, labelName+"="+labelValue)
+			case 2:
+				protoFields(t, v, func(num protowire.Number, _ protowire.Type, _ []byte, x uint64) {
+					if num == 1 {
+						sample.value = math.Float64frombits(x)
+					} else {
+						sample.timestamp = int64(x)
+					}
+				})
+			}
+		})
+		if !sort.StringsAreSorted(labels) {
+			t.Errorf("Expected labels sorted by name, got %v", labels)
+		}
+		sample.labels = strings.Join(labels, ",")
+		series[name] = append(series[name], sample)
+	})
+	return series
+}
+
+func TestRemoteWriteMetrics(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName: "site1",
+			Label:    "Site 1",
+			PlanName: "Basic",
+			Account:  "account1",
+			MetricsData: map[string]pantheon.MetricData{
+				"1762646400": {Visits: 100, PagesServed: 400, CacheHitRatio: "10%"},
+				"1762732800": {Visits: 200, PagesServed: 800, CacheHitRatio: "20%"},
+			},
+		},
+	}
+	durations := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "test_duration_seconds",
+		Help:    "Test histogram",
+		Buckets: []float64{1},
+	})
+	durations.Observe(0.5)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector.NewPantheonCollector(sites), durations)
+
+	var headers http.Header
+	var series map[string][]decodedSample
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("Failed to read body: %v", err)
+		}
+		decoded, err := snappyDecode(body)
+		if err != nil {
+			t.Errorf("Failed to decode snappy body: %v", err)
+		}
+		series = decodeWriteRequest(t, decoded)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	now := time.Date(2025, 11, 10, 12, 0, 0, 0, time.UTC)
+	if err := RemoteWriteMetrics(server.URL, registry, now); err != nil {
+		t.Fatalf("Expected remote write to succeed, got %v", err)
+	}
+
+	for header, want := range map[string]string{
+		"Content-Encoding":                  "snappy",
+		"Content-Type":                      "application/x-protobuf",
+		"X-Prometheus-Remote-Write-Version": "0.1.0",
+	} {
+		if got := headers.Get(header); got != want {
+			t.Errorf("Expected %s %q, got %q", header, want, got)
+		}
+	}
+
+	visits := series["pantheon_visits_total"]
+	if len(visits) != 1 {
+		t.Fatalf("Expected only the latest pantheon_visits_total sample, got %v", visits)
+	}
+	if visits[0].value != 200 {
+		t.Errorf("Expected the latest visits value 200, got %v", visits[0].value)
+	}
+	if visits[0].timestamp != now.UnixMilli() {
+		t.Errorf("Expected samples timestamped %d, got %d", now.UnixMilli(), visits[0].timestamp)
+	}
+	if !strings.Contains(visits[0].labels, "site_id=site1") {
+		t.Errorf("Expected the site_id label, got %s", visits[0].labels)
+	}
+
+	buckets := make(map[string]float64)
+	for _, s := range series["test_duration_seconds_bucket"] {
+		buckets[s.labels] = s.value
+	}
+	if got := buckets["__name__=test_duration_seconds_bucket,le=1"]; got != 1 {
+		t.Errorf("Expected 1 observation in the le=1 bucket, got %v (%v)", got, buckets)
+	}
+	if got := buckets["__name__=test_duration_seconds_bucket,le=+Inf"]; got != 1 {
+		t.Errorf("Expected 1 observation in the le=+Inf bucket, got %v (%v)", got, buckets)
+	}
+	if got := series["test_duration_seconds_count"]; len(got) != 1 || got[0].value != 1 {
+		t.Errorf("Expected a test_duration_seconds_count of 1, got %v", got)
+	}
+}
+
+func TestRemoteWriteMetricsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := RemoteWriteMetrics(server.URL, prometheus.NewRegistry(), time.Now())
+	if err == nil || !strings.Contains(err.Error(), "out of order sample") {
+		t.Errorf("Expected the receiver's error message, got %v", err)
+	}
+}
+
+func TestSnappyEncodeLongInput(t *testing.T) {
+	for _, size := range []int{0, 1, 60, 61, 256, 257, 70000} {
+		src := make([]byte, size)
+		for i := range src {
+			src[i] = byte(i)
+		}
+		decoded, err := snappyDecode(snappyEncode(src))
+		if err != nil {
+			t.Errorf("Failed to decode %d bytes: %v", size, err)
+			continue
+		}
+		if string(decoded) != string(src) {
+			t.Errorf("Expected %d bytes to round trip", size)
+		}
+	}
+}
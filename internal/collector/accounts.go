@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// AccountStatsProvider exposes how many configured accounts have
+// authenticated, and whether each account is healthy.
+type AccountStatsProvider interface {
+	AccountCounts() (authenticated, total int)
+	AccountsUp() map[string]bool
+}
+
+// AccountsCollector collects account counts and per-account health
+type AccountsCollector struct {
+	source AccountStatsProvider
+
+	authenticated *prometheus.Desc
+	total         *prometheus.Desc
+	up            *prometheus.Desc
+}
+
+// NewAccountsCollector creates a new account count metrics collector
+func NewAccountsCollector(source AccountStatsProvider) *AccountsCollector {
+	return &AccountsCollector{
+		source: source,
+		authenticated: prometheus.NewDesc(
+			"pantheon_accounts_authenticated",
+			"Number of accounts whose machine token has authenticated",
+			nil,
+			nil,
+		),
+		total: prometheus.NewDesc(
+			"pantheon_accounts_total",
+			"Number of configured machine tokens",
+			nil,
+			nil,
+		),
+		up: prometheus.NewDesc(
+			"pantheon_account_up",
+			"Whether an account's last site list refresh and at least one metrics fetch succeeded (1 = up, 0 = down)",
+			[]string{"account"},
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *AccountsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.authenticated
+	ch <- c.total
+	ch <- c.up
+}
+
+// Collect implements prometheus.Collector
+func (c *AccountsCollector) Collect(ch chan<- prometheus.Metric) {
+	authenticated, total := c.source.AccountCounts()
+	ch <- prometheus.MustNewConstMetric(c.authenticated, prometheus.GaugeValue, float64(authenticated))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(total))
+
+	for account, up := range c.source.AccountsUp() {
+		value := 0.0
+		if up {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.up,
+			prometheus.GaugeValue,
+			value,
+			account,
+		)
+	}
+}
@@ -0,0 +1,63 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stubAccountStats is an AccountStatsProvider with fixed values
+type stubAccountStats struct {
+	authenticated, total int
+	up                   map[string]bool
+}
+
+func (s stubAccountStats) AccountCounts() (int, int) {
+	return s.authenticated, s.total
+}
+
+func (s stubAccountStats) AccountsUp() map[string]bool {
+	return s.up
+}
+
+func TestAccountsCollector(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewAccountsCollector(stubAccountStats{authenticated: 2, total: 3}))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	values := map[string]float64{}
+	for _, mf := range families {
+		values[mf.GetName()] = mf.GetMetric()[0].GetGauge().GetValue()
+	}
+	if len(values) != 2 || values["pantheon_accounts_authenticated"] != 2 || values["pantheon_accounts_total"] != 3 {
+		t.Errorf("Expected 2 authenticated of 3 accounts, got %v", values)
+	}
+}
+
+func TestAccountsCollectorUp(t *testing.T) {
+	source := stubAccountStats{up: map[string]bool{"a@example.com": true, "b@example.com": false}}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewAccountsCollector(source))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	values := map[string]float64{}
+	for _, mf := range families {
+		if mf.GetName() != "pantheon_account_up" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			values[m.GetLabel()[0].GetValue()] = m.GetGauge().GetValue()
+		}
+	}
+	if len(values) != 2 || values["a@example.com"] != 1 || values["b@example.com"] != 0 {
+		t.Errorf("Expected a@example.com=1 and b@example.com=0, got %v", values)
+	}
+}
@@ -0,0 +1,51 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CircuitStateProvider exposes the circuit breaker state of each account.
+type CircuitStateProvider interface {
+	AccountCircuitsOpen() map[string]bool
+}
+
+// CircuitBreakerCollector collects per-account circuit breaker state
+type CircuitBreakerCollector struct {
+	source CircuitStateProvider
+
+	circuitOpen *prometheus.Desc
+}
+
+// NewCircuitBreakerCollector creates a new circuit breaker metrics collector
+func NewCircuitBreakerCollector(source CircuitStateProvider) *CircuitBreakerCollector {
+	return &CircuitBreakerCollector{
+		source: source,
+		circuitOpen: prometheus.NewDesc(
+			"pantheon_account_circuit_open",
+			"Whether metrics refreshes for an account are paused after repeated failures (1 = paused)",
+			[]string{"account"},
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *CircuitBreakerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.circuitOpen
+}
+
+// Collect implements prometheus.Collector
+func (c *CircuitBreakerCollector) Collect(ch chan<- prometheus.Metric) {
+	for account, open := range c.source.AccountCircuitsOpen() {
+		value := 0.0
+		if open {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.circuitOpen,
+			prometheus.GaugeValue,
+			value,
+			account,
+		)
+	}
+}
@@ -0,0 +1,38 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stubCircuitState is a CircuitStateProvider with fixed values
+type stubCircuitState map[string]bool
+
+func (s stubCircuitState) AccountCircuitsOpen() map[string]bool {
+	return s
+}
+
+func TestCircuitBreakerCollector(t *testing.T) {
+	source := stubCircuitState{"a@example.com": true, "b@example.com": false}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewCircuitBreakerCollector(source))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	if len(families) != 1 || families[0].GetName() != "pantheon_account_circuit_open" {
+		t.Fatalf("Expected pantheon_account_circuit_open metric, got %v", families)
+	}
+
+	values := map[string]float64{}
+	for _, m := range families[0].GetMetric() {
+		values[m.GetLabel()[0].GetValue()] = m.GetGauge().GetValue()
+	}
+	if values["a@example.com"] != 1 || values["b@example.com"] != 0 {
+		t.Errorf("Expected a@example.com=1 and b@example.com=0, got %v", values)
+	}
+}
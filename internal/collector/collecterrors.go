@@ -0,0 +1,64 @@
+package collector
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Reasons a sample could not be collected as-is.
+const (
+	CollectErrorParseTimestamp = "parse_timestamp" // Sample dropped because its timestamp isn't a Unix time
+	CollectErrorParseRatio     = "parse_ratio"     // Cache hit ratio exported as 0 because it isn't a number
+)
+
+// CollectErrorProvider exposes counts of data-quality errors found while collecting.
+type CollectErrorProvider interface {
+	CollectErrorCounts() map[string]int64
+}
+
+// CollectErrorCollector collects counts of samples that could not be exported as-is
+type CollectErrorCollector struct {
+	source CollectErrorProvider
+
+	collectErrors *prometheus.Desc
+}
+
+// NewCollectErrorCollector creates a new collect error metrics collector
+func NewCollectErrorCollector(source CollectErrorProvider) *CollectErrorCollector {
+	return &CollectErrorCollector{
+		source: source,
+		collectErrors: prometheus.NewDesc(
+			"pantheon_exporter_collect_errors_total",
+			"Number of metrics samples that could not be parsed while collecting, by reason",
+			[]string{"reason"},
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *CollectErrorCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.collectErrors
+}
+
+// Collect implements prometheus.Collector
+func (c *CollectErrorCollector) Collect(ch chan<- prometheus.Metric) {
+	for reason, count := range c.source.CollectErrorCounts() {
+		ch <- prometheus.MustNewConstMetric(
+			c.collectErrors,
+			prometheus.CounterValue,
+			float64(count),
+			reason,
+		)
+	}
+}
+
+// CollectErrorCounts returns how many samples failed to parse during all
+// collections so far, by reason (thread-safe). Every reason is included.
+func (c *PantheonCollector) CollectErrorCounts() map[string]int64 {
+	return map[string]int64{
+		CollectErrorParseTimestamp: atomic.LoadInt64(&c.timestampErrors),
+		CollectErrorParseRatio:     atomic.LoadInt64(&c.ratioErrors),
+	}
+}
@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectErrorCounts gathers pantheon_exporter_collect_errors_total by reason
+func collectErrorCounts(t *testing.T, source CollectErrorProvider) map[string]float64 {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewCollectErrorCollector(source))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	counts := map[string]float64{}
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			counts[m.GetLabel()[0].GetValue()] = m.GetCounter().GetValue()
+		}
+	}
+	return counts
+}
+
+// drainCollect runs one collection of c, discarding the metrics
+func drainCollect(c prometheus.Collector) {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+	for range ch {
+	}
+}
+
+func TestCollectErrorCounts(t *testing.T) {
+	c := NewPantheonCollector([]pantheon.SiteMetrics{
+		{
+			SiteName: "site1",
+			Account:  "account1",
+			MetricsData: map[string]pantheon.MetricData{
+				"not-a-timestamp": {Visits: 1, CacheHitRatio: "10%"},
+				"1762732800":      {Visits: 2, CacheHitRatio: "ratio%"},
+			},
+		},
+	})
+
+	counts := collectErrorCounts(t, c)
+	if counts[CollectErrorParseTimestamp] != 0 || counts[CollectErrorParseRatio] != 0 {
+		t.Errorf("Expected zeroed counters before collecting, got %v", counts)
+	}
+
+	drainCollect(c)
+	counts = collectErrorCounts(t, c)
+	if counts[CollectErrorParseTimestamp] != 1 {
+		t.Errorf("Expected 1 timestamp error, got %v", counts[CollectErrorParseTimestamp])
+	}
+	if counts[CollectErrorParseRatio] != 1 {
+		t.Errorf("Expected 1 ratio error, got %v", counts[CollectErrorParseRatio])
+	}
+
+	// Counters keep advancing with every scrape of the bad data
+	drainCollect(c)
+	counts = collectErrorCounts(t, c)
+	if counts[CollectErrorParseTimestamp] != 2 || counts[CollectErrorParseRatio] != 2 {
+		t.Errorf("Expected both counters at 2 after a second collection, got %v", counts)
+	}
+}
+
+func TestCollectErrorCountsValidData(t *testing.T) {
+	c := NewPantheonCollector([]pantheon.SiteMetrics{
+		{
+			SiteName:    "site1",
+			Account:     "account1",
+			MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 2, CacheHitRatio: "--"}},
+		},
+	})
+
+	drainCollect(c)
+	counts := collectErrorCounts(t, c)
+	if counts[CollectErrorParseTimestamp] != 0 || counts[CollectErrorParseRatio] != 0 {
+		t.Errorf("Expected no errors for valid data, got %v", counts)
+	}
+}
@@ -2,195 +2,583 @@
 package collector
 
 import (
+	"fmt"
 	"log"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// SiteStatus holds the outcome of the most recent metrics refresh for a site
+type SiteStatus struct {
+	LastSuccess time.Time // Time of the last successful refresh (zero if never refreshed)
+	LastFailed  bool      // Whether the most recent refresh attempt failed
+	LastError   string    // Why the most recent refresh attempt failed ("" unless LastFailed)
+}
+
 // PantheonCollector collects Pantheon metrics for multiple sites
 type PantheonCollector struct {
-	sites []pantheon.SiteMetrics
-	mu    sync.RWMutex
+	sites  []pantheon.SiteMetrics
+	status map[string]SiteStatus // Refresh status keyed by account:site
+	mu     sync.RWMutex
+
+	minVisits    int           // Sites whose latest sample has fewer visits are not emitted (0 = emit all)
+	maxLabelLen  int           // Longer per-site label values are truncated (0 = no limit)
+	tagKeys      []string      // Site tags exported as extra labels, in label order
+	defaultEnv   string        // Environment label value for sites without a recorded environment ("" = no label)
+	noDataNaN    bool          // Export a "--" cache hit ratio as NaN instead of 0
+	noTimestamps bool          // Emit only the latest sample of each site, without a timestamp
+	emitSince    time.Duration // Historical samples older than this are not emitted (0 = all)
+	staleAfter   time.Duration // Sites whose latest sample is older are stale (0 = never stale)
+	dropStale    bool          // Don't emit the sample-based metrics of stale sites
+
+	dailyDeltas    bool                  // Whether day-over-day deltas are emitted
+	enabledMetrics map[string]bool       // Selected metric families (nil = all)
+	labelNames     []string              // Label names of the per-site descriptors
+	constLabels    prometheus.Labels     // Fixed labels added to every per-site metric
+	planLimits     map[string]PlanLimits // Configured limits keyed by plan slug (nil = no quota metrics)
+
+	visits         *prometheus.Desc
+	pagesServed    *prometheus.Desc
+	cacheHits      *prometheus.Desc
+	cacheMisses    *prometheus.Desc
+	cacheHitRatio  *prometheus.Desc
+	cacheMissRatio *prometheus.Desc
+	cacheRequests  *prometheus.Desc
+	siteAge        *prometheus.Desc
+	siteInfo       *prometheus.Desc
+	siteSamples    *prometheus.Desc
+	siteStale      *prometheus.Desc
+
+	visitsDaily      *prometheus.Desc
+	pagesServedDaily *prometheus.Desc
+	cacheHitsDaily   *prometheus.Desc
+	cacheMissesDaily *prometheus.Desc
+
+	quotaVisits      *prometheus.Desc
+	quotaPagesServed *prometheus.Desc
 
-	visits        *prometheus.Desc
-	pagesServed   *prometheus.Desc
-	cacheHits     *prometheus.Desc
-	cacheMisses   *prometheus.Desc
-	cacheHitRatio *prometheus.Desc
+	lastRefresh *prometheus.Desc
+	refreshSkew *prometheus.Desc
+	now         func() time.Time
+
+	timestampErrors int64 // Samples dropped for an unparseable timestamp; updated atomically
+	ratioErrors     int64 // Cache hit ratios that failed to parse; updated atomically
 }
 
 // NewPantheonCollector creates a new Pantheon metrics collector
 func NewPantheonCollector(sites []pantheon.SiteMetrics) *PantheonCollector {
-	return &PantheonCollector{
-		sites: sites,
-		visits: prometheus.NewDesc(
-			"pantheon_visits_total",
-			"Total number of visits to a Pantheon site",
-			[]string{"site_id", "site_name", "plan", "account"},
-			nil,
-		),
-		pagesServed: prometheus.NewDesc(
-			"pantheon_pages_served_total",
-			"Total number of pages served by a Pantheon site",
-			[]string{"site_id", "site_name", "plan", "account"},
-			nil,
-		),
-		cacheHits: prometheus.NewDesc(
-			"pantheon_cache_hits_total",
-			"Total number of cache hits for a Pantheon site",
-			[]string{"site_id", "site_name", "plan", "account"},
-			nil,
-		),
-		cacheMisses: prometheus.NewDesc(
-			"pantheon_cache_misses_total",
-			"Total number of cache misses for a Pantheon site",
-			[]string{"site_id", "site_name", "plan", "account"},
-			nil,
-		),
-		cacheHitRatio: prometheus.NewDesc(
-			"pantheon_cache_hit_ratio",
-			"Cache hit ratio for a Pantheon site (0-1)",
-			[]string{"site_id", "site_name", "plan", "account"},
-			nil,
-		),
+	c := &PantheonCollector{
+		sites:  sites,
+		status: make(map[string]SiteStatus),
+		now:    time.Now,
 	}
+	c.setDescs(siteLabelNames)
+	return c
+}
+
+// setDescs creates the per-site metric descriptors with the given label names,
+// leaving unselected metric families nil
+func (c *PantheonCollector) setDescs(labelNames []string) {
+	c.labelNames = labelNames
+	c.visits = prometheus.NewDesc(
+		"pantheon_visits_total",
+		"Total number of visits to a Pantheon site",
+		labelNames,
+		c.constLabels,
+	)
+	c.pagesServed = prometheus.NewDesc(
+		"pantheon_pages_served_total",
+		"Total number of pages served by a Pantheon site",
+		labelNames,
+		c.constLabels,
+	)
+	c.cacheHits = prometheus.NewDesc(
+		"pantheon_cache_hits_total",
+		"Total number of cache hits for a Pantheon site",
+		labelNames,
+		c.constLabels,
+	)
+	c.cacheMisses = prometheus.NewDesc(
+		"pantheon_cache_misses_total",
+		"Total number of cache misses for a Pantheon site",
+		labelNames,
+		c.constLabels,
+	)
+	c.cacheHitRatio = prometheus.NewDesc(
+		"pantheon_cache_hit_ratio",
+		"Cache hit ratio for a Pantheon site (0-1)",
+		labelNames,
+		c.constLabels,
+	)
+	c.cacheMissRatio = prometheus.NewDesc(
+		"pantheon_cache_miss_ratio",
+		"Cache miss ratio for a Pantheon site (0-1), 1 minus the cache hit ratio",
+		labelNames,
+		c.constLabels,
+	)
+	c.cacheRequests = prometheus.NewDesc(
+		"pantheon_cache_total_requests",
+		"Cache hits plus cache misses in the latest sample for a Pantheon site",
+		labelNames,
+		c.constLabels,
+	)
+	c.siteAge = prometheus.NewDesc(
+		"pantheon_site_age_days",
+		"Number of days since a Pantheon site was created",
+		labelNames,
+		c.constLabels,
+	)
+	c.siteInfo = prometheus.NewDesc(
+		"pantheon_site_info",
+		"Information about a Pantheon site, always 1",
+		append(append([]string{}, labelNames...), siteInfoLabels...),
+		c.constLabels,
+	)
+	c.siteSamples = prometheus.NewDesc(
+		"pantheon_site_samples",
+		"Number of metrics samples retained for a Pantheon site",
+		labelNames,
+		c.constLabels,
+	)
+	c.siteStale = prometheus.NewDesc(
+		"pantheon_site_stale",
+		"Whether a Pantheon site's latest metrics sample is older than -staleAfter (1 = stale, 0 = fresh)",
+		labelNames,
+		c.constLabels,
+	)
+	c.visitsDaily = prometheus.NewDesc(
+		"pantheon_visits_daily",
+		"Day-over-day change in visits to a Pantheon site",
+		labelNames,
+		c.constLabels,
+	)
+	c.pagesServedDaily = prometheus.NewDesc(
+		"pantheon_pages_served_daily",
+		"Day-over-day change in pages served by a Pantheon site",
+		labelNames,
+		c.constLabels,
+	)
+	c.cacheHitsDaily = prometheus.NewDesc(
+		"pantheon_cache_hits_daily",
+		"Day-over-day change in cache hits for a Pantheon site",
+		labelNames,
+		c.constLabels,
+	)
+	c.cacheMissesDaily = prometheus.NewDesc(
+		"pantheon_cache_misses_daily",
+		"Day-over-day change in cache misses for a Pantheon site",
+		labelNames,
+		c.constLabels,
+	)
+	c.quotaVisits = prometheus.NewDesc(
+		"pantheon_site_quota_visits",
+		"Configured visits limit of a Pantheon site's plan",
+		labelNames,
+		c.constLabels,
+	)
+	c.quotaPagesServed = prometheus.NewDesc(
+		"pantheon_site_quota_pages_served",
+		"Configured pages served limit of a Pantheon site's plan",
+		labelNames,
+		c.constLabels,
+	)
+	c.lastRefresh = prometheus.NewDesc(
+		"pantheon_site_last_refresh_timestamp_seconds",
+		"Unix time of the last successful metrics refresh for a Pantheon site",
+		labelNames,
+		c.constLabels,
+	)
+	c.refreshSkew = prometheus.NewDesc(
+		"pantheon_exporter_refresh_skew_seconds",
+		"Seconds between the least and most recently refreshed sites; a large skew means the refresh queue is starving some sites",
+		nil,
+		c.constLabels,
+	)
+	c.disableUnselectedMetrics()
+}
+
+// SetTagLabels adds a label to every per-site metric for each of the given site
+// tag keys, named tag_<key>. Keys that map to the same label name, such as
+// "cost-center" and "cost_center", are rejected. It must be called before the
+// collector is registered.
+func (c *PantheonCollector) SetTagLabels(keys []string) error {
+	seen := make(map[string]string, len(keys))
+	for _, key := range keys {
+		name := tagLabelName(key)
+		if other, ok := seen[name]; ok {
+			return fmt.Errorf("tag keys %q and %q both map to label %q", other, key, name)
+		}
+		seen[name] = key
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tagKeys = keys
+	c.setDescs(extendedLabelNames(c.defaultEnv != "", c.tagKeys))
+	return nil
+}
+
+// SetEnvironmentLabel adds an environment label to every per-site metric, holding
+// the environment each site's metrics were fetched from, or defaultEnv if not
+// recorded. It must be called before the collector is registered.
+func (c *PantheonCollector) SetEnvironmentLabel(defaultEnv string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.defaultEnv = defaultEnv
+	c.setDescs(extendedLabelNames(c.defaultEnv != "", c.tagKeys))
+}
+
+// SetCacheHitRatioNaN makes samples without a cache hit ratio, which Pantheon
+// reports as "--" when no pages were served, export NaN instead of 0 so they
+// can't be mistaken for a 0% hit ratio.
+func (c *PantheonCollector) SetCacheHitRatioNaN(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.noDataNaN = enabled
+}
+
+// SetNoTimestamps makes each site's metrics carry only the latest sample,
+// without an explicit timestamp, so Prometheus stamps them with the scrape
+// time. Historical samples and all but the latest daily delta are dropped.
+// This suits remote-write and recording rule setups that mishandle
+// explicitly timestamped or backfilled samples.
+func (c *PantheonCollector) SetNoTimestamps(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.noTimestamps = enabled
+}
+
+// SetMinVisits sets the minimum number of visits in a site's latest sample
+// required for its metrics to be emitted. Filtered sites are still refreshed.
+func (c *PantheonCollector) SetMinVisits(minVisits int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.minVisits = minVisits
+}
+
+// SetMaxLabelLength truncates per-site label values longer than maxLength
+// characters, ending them with "..." so the cut is visible. This bounds the
+// exposition size for sites with pathologically long names. 0 means no limit.
+func (c *PantheonCollector) SetMaxLabelLength(maxLength int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxLabelLen = maxLength
 }
 
 // Describe implements prometheus.Collector
 func (c *PantheonCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- c.visits
-	ch <- c.pagesServed
-	ch <- c.cacheHits
-	ch <- c.cacheMisses
-	ch <- c.cacheHitRatio
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	descs := []*prometheus.Desc{c.visits, c.pagesServed, c.cacheHits, c.cacheMisses, c.cacheHitRatio, c.cacheMissRatio, c.cacheRequests, c.siteAge, c.siteInfo, c.siteSamples, c.lastRefresh, c.refreshSkew}
+	if c.dailyDeltas {
+		descs = append(descs, c.visitsDaily, c.pagesServedDaily, c.cacheHitsDaily, c.cacheMissesDaily)
+	}
+	if len(c.planLimits) > 0 {
+		descs = append(descs, c.quotaVisits, c.quotaPagesServed)
+	}
+	if c.staleAfter > 0 {
+		descs = append(descs, c.siteStale)
+	}
+	for _, desc := range descs {
+		// Unselected metric families have no descriptor
+		if desc != nil {
+			ch <- desc
+		}
+	}
 }
 
-// Collect implements prometheus.Collector
-func (c *PantheonCollector) Collect(ch chan<- prometheus.Metric) {
+// collectState is the collector state read while emitting metrics, copied
+// under a brief read lock so a slow scrape doesn't block writers. Sites are
+// copied shallowly: their metrics data maps are shared, which is safe because
+// writers only ever replace a site's map, never modify it in place.
+// Descriptors are not copied, since they only change before registration.
+type collectState struct {
+	now         time.Time // When the state was copied, used as the current time throughout a collect
+	sites       []pantheon.SiteMetrics
+	status      []SiteStatus // Refresh status of each site in sites
+	skew        float64
+	hasSkew     bool
+	minVisits   int
+	samples     sampleOptions
+	dailyDeltas bool
+	planLimits  map[string]PlanLimits
+	staleBefore time.Time // Sites whose latest sample is older are stale (zero = never stale)
+	dropStale   bool
+	maxLabelLen int // Longer label values are truncated (0 = no limit)
+}
+
+// sampleOptions controls how collectSamples emits a site's samples
+type sampleOptions struct {
+	noDataNaN    bool      // Passed on to parseCacheHitRatio
+	noTimestamps bool      // Emit only the latest sample, without a timestamp
+	now          time.Time // Timestamp of the latest sample, unless noTimestamps is set
+	since        time.Time // Historical samples before this are not emitted (zero = all)
+}
+
+// snapshotCollectState copies the state needed to emit metrics (thread-safe)
+func (c *PantheonCollector) snapshotCollectState() collectState {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	for _, site := range c.sites {
-		// First pass: find the most recent timestamp
-		var latestTimestamp int64
-		var latestTimestampStr string
-		var latestData pantheon.MetricData
-		hasData := false
-
-		for timestampStr, data := range site.MetricsData {
-			timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
-			if err != nil {
-				continue
-			}
-			if !hasData || timestamp > latestTimestamp {
-				latestTimestamp = timestamp
-				latestTimestampStr = timestampStr
-				latestData = data
-				hasData = true
-			}
+	now := c.now()
+	state := collectState{
+		now:         now,
+		sites:       make([]pantheon.SiteMetrics, len(c.sites)),
+		status:      make([]SiteStatus, len(c.sites)),
+		minVisits:   c.minVisits,
+		samples:     sampleOptions{noDataNaN: c.noDataNaN, noTimestamps: c.noTimestamps, now: now},
+		dailyDeltas: c.dailyDeltas,
+		planLimits:  c.planLimits,
+		dropStale:   c.dropStale,
+		maxLabelLen: c.maxLabelLen,
+	}
+	if c.emitSince > 0 {
+		state.samples.since = now.Add(-c.emitSince)
+	}
+	if c.staleAfter > 0 {
+		state.staleBefore = now.Add(-c.staleAfter)
+	}
+	copy(state.sites, c.sites)
+	for i, site := range c.sites {
+		state.status[i] = c.status[site.Account+":"+site.SiteName]
+	}
+	state.skew, state.hasSkew = c.refreshSkewSeconds()
+	return state
+}
+
+// Collect implements prometheus.Collector. The collector is only locked
+// while its state is copied, so sites can be updated during a scrape.
+// promhttp gathers every metric before writing the response, so a client
+// reading /metrics slowly never blocks the sends on ch; only a slow Gather can.
+func (c *PantheonCollector) Collect(ch chan<- prometheus.Metric) {
+	state := c.snapshotCollectState()
+
+	descs := siteDescs{
+		visits:         c.visits,
+		pagesServed:    c.pagesServed,
+		cacheHits:      c.cacheHits,
+		cacheMisses:    c.cacheMisses,
+		cacheHitRatio:  c.cacheHitRatio,
+		cacheMissRatio: c.cacheMissRatio,
+	}
+
+	// Skew covers every refreshed site, including those filtered by -minVisits
+	if state.hasSkew {
+		ch <- prometheus.MustNewConstMetric(c.refreshSkew, prometheus.GaugeValue, state.skew)
+	}
+
+	labelNames := extendedLabelNames(c.defaultEnv != "", c.tagKeys)
+	for i, site := range state.sites {
+		// Skip idle sites when a traffic threshold is configured
+		if belowMinVisits(site, state.minVisits) {
+			continue
 		}
 
-		// Second pass: emit all historical metrics EXCEPT the latest one
-		// (the latest will be emitted without a timestamp at the end)
-		for timestampStr, data := range site.MetricsData {
-			// Skip the latest timestamp - it will be emitted without timestamp below
-			if timestampStr == latestTimestampStr {
-				continue
-			}
+		labelValues := truncateLabelValues(labelNames, siteLabelValues(site, c.defaultEnv, c.tagKeys), state.maxLabelLen)
 
-			timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
-			if err != nil {
-				log.Printf("Error parsing timestamp %s: %v", timestampStr, err)
-				continue
-			}
-			ts := time.Unix(timestamp, 0)
+		ch <- prometheus.MustNewConstMetric(
+			c.siteInfo,
+			prometheus.GaugeValue,
+			1,
+			append(labelValues, truncateLabelValues(siteInfoLabels, []string{sanitizeLabelValue(site.Owner), sanitizeLabelValue(site.Framework), site.Source}, state.maxLabelLen)...)...,
+		)
 
-			cacheHitRatioVal := c.parseCacheHitRatio(data.CacheHitRatio)
+		// A sudden drop in retained samples means history was lost when merging refreshes
+		ch <- prometheus.MustNewConstMetric(
+			c.siteSamples,
+			prometheus.GaugeValue,
+			float64(len(site.MetricsData)),
+			labelValues...,
+		)
 
-			// Create metrics with labels and timestamps
-			ch <- prometheus.NewMetricWithTimestamp(ts, prometheus.MustNewConstMetric(
-				c.visits,
+		if status := state.status[i]; !status.LastSuccess.IsZero() {
+			ch <- prometheus.MustNewConstMetric(
+				c.lastRefresh,
 				prometheus.GaugeValue,
-				float64(data.Visits),
-				site.SiteName, site.Label, site.PlanName, site.Account,
-			))
+				float64(status.LastSuccess.Unix()),
+				labelValues...,
+			)
+		}
 
-			ch <- prometheus.NewMetricWithTimestamp(ts, prometheus.MustNewConstMetric(
-				c.pagesServed,
+		// Site age doesn't depend on metrics data, so it is always emitted when known
+		if site.Created > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				c.siteAge,
 				prometheus.GaugeValue,
-				float64(data.PagesServed),
-				site.SiteName, site.Label, site.PlanName, site.Account,
-			))
+				siteAgeDays(site.Created, state.now),
+				labelValues...,
+			)
+		}
 
-			ch <- prometheus.NewMetricWithTimestamp(ts, prometheus.MustNewConstMetric(
-				c.cacheHits,
-				prometheus.GaugeValue,
-				float64(data.CacheHits),
-				site.SiteName, site.Label, site.PlanName, site.Account,
-			))
+		c.collectQuotas(ch, state.planLimits, site, labelValues...)
 
-			ch <- prometheus.NewMetricWithTimestamp(ts, prometheus.MustNewConstMetric(
-				c.cacheMisses,
-				prometheus.GaugeValue,
-				float64(data.CacheMisses),
-				site.SiteName, site.Label, site.PlanName, site.Account,
-			))
+		if c.collectStale(ch, state, site, labelValues...) {
+			continue
+		}
+
+		c.collectSamples(ch, descs, state.samples, site, labelValues...)
 
-			ch <- prometheus.NewMetricWithTimestamp(ts, prometheus.MustNewConstMetric(
-				c.cacheHitRatio,
+		// Total cache requests from the latest sample, so alerts don't have to sum two series
+		if _, latestData, hasData := latestSample(site); hasData {
+			ch <- prometheus.MustNewConstMetric(
+				c.cacheRequests,
 				prometheus.GaugeValue,
-				cacheHitRatioVal,
-				site.SiteName, site.Label, site.PlanName, site.Account,
-			))
+				float64(latestData.CacheHits+latestData.CacheMisses),
+				labelValues...,
+			)
 		}
 
-		// Emit the most recent metric with the current request time so consumers
-		// can pull current data without gaps in their time series
-		if hasData {
-			now := time.Now()
-			cacheHitRatioVal := c.parseCacheHitRatio(latestData.CacheHitRatio)
+		if state.dailyDeltas {
+			c.collectDailyDeltas(ch, state.samples, site, labelValues...)
+		}
+	}
+}
 
-			ch <- prometheus.NewMetricWithTimestamp(now, prometheus.MustNewConstMetric(
-				c.visits,
-				prometheus.GaugeValue,
-				float64(latestData.Visits),
-				site.SiteName, site.Label, site.PlanName, site.Account,
-			))
+// siteDescs holds the descriptors for the per-sample site metrics of one naming schema.
+// Metrics with a nil descriptor are not emitted.
+type siteDescs struct {
+	visits         *prometheus.Desc
+	pagesServed    *prometheus.Desc
+	cacheHits      *prometheus.Desc
+	cacheMisses    *prometheus.Desc
+	cacheHitRatio  *prometheus.Desc
+	cacheMissRatio *prometheus.Desc
+}
 
-			ch <- prometheus.NewMetricWithTimestamp(now, prometheus.MustNewConstMetric(
-				c.pagesServed,
-				prometheus.GaugeValue,
-				float64(latestData.PagesServed),
-				site.SiteName, site.Label, site.PlanName, site.Account,
-			))
+// sampleValue pairs a metric descriptor with the value to emit for it
+type sampleValue struct {
+	desc  *prometheus.Desc
+	value float64
+}
 
-			ch <- prometheus.NewMetricWithTimestamp(now, prometheus.MustNewConstMetric(
-				c.cacheHits,
-				prometheus.GaugeValue,
-				float64(latestData.CacheHits),
-				site.SiteName, site.Label, site.PlanName, site.Account,
-			))
+// latestSample returns the most recent metrics sample for a site, and whether it has any
+func latestSample(site pantheon.SiteMetrics) (string, pantheon.MetricData, bool) {
+	var latestTimestamp int64
+	var latestTimestampStr string
+	var latestData pantheon.MetricData
+	hasData := false
 
-			ch <- prometheus.NewMetricWithTimestamp(now, prometheus.MustNewConstMetric(
-				c.cacheMisses,
-				prometheus.GaugeValue,
-				float64(latestData.CacheMisses),
-				site.SiteName, site.Label, site.PlanName, site.Account,
-			))
+	for timestampStr, data := range site.MetricsData {
+		timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		if !hasData || timestamp > latestTimestamp {
+			latestTimestamp = timestamp
+			latestTimestampStr = timestampStr
+			latestData = data
+			hasData = true
+		}
+	}
+	return latestTimestampStr, latestData, hasData
+}
+
+// belowMinVisits reports whether a site's latest sample is under the traffic
+// threshold. A threshold of 0 or less filters nothing.
+func belowMinVisits(site pantheon.SiteMetrics, minVisits int) bool {
+	if minVisits <= 0 {
+		return false
+	}
+	_, latestData, hasData := latestSample(site)
+	return !hasData || latestData.Visits < minVisits
+}
 
-			ch <- prometheus.NewMetricWithTimestamp(now, prometheus.MustNewConstMetric(
-				c.cacheHitRatio,
+// collectSamples emits every metrics sample for a site using the given descriptors.
+// Historical samples carry their own timestamps, and the latest sample is stamped with
+// the current time so consumers can pull current data without gaps in their time series.
+// With opts.noTimestamps, only the latest sample is emitted, without a timestamp.
+// Otherwise, historical samples before opts.since are skipped.
+func (c *PantheonCollector) collectSamples(ch chan<- prometheus.Metric, d siteDescs, opts sampleOptions, site pantheon.SiteMetrics, labelValues ...string) {
+	latestTimestampStr, latestData, hasData := latestSample(site)
+
+	// A zero ts emits the sample without a timestamp
+	emit := func(ts time.Time, data pantheon.MetricData) {
+		cacheHitRatioVal, ratioOK := 0.0, true
+		if d.cacheHitRatio != nil || d.cacheMissRatio != nil {
+			cacheHitRatioVal, ratioOK = c.parseCacheHitRatio(data.CacheHitRatio, opts.noDataNaN)
+		}
+		// A miss ratio derived from an unparseable hit ratio would report every
+		// request as a miss, so it is left out of the sample instead
+		missRatioDesc := d.cacheMissRatio
+		if !ratioOK {
+			missRatioDesc = nil
+		}
+
+		for _, v := range []sampleValue{
+			{d.visits, float64(data.Visits)},
+			{d.pagesServed, float64(data.PagesServed)},
+			{d.cacheHits, float64(data.CacheHits)},
+			{d.cacheMisses, float64(data.CacheMisses)},
+			{d.cacheHitRatio, cacheHitRatioVal},
+			{missRatioDesc, cacheMissRatio(data.CacheHitRatio, cacheHitRatioVal)},
+		} {
+			if v.desc == nil {
+				continue
+			}
+			metric := prometheus.MustNewConstMetric(
+				v.desc,
 				prometheus.GaugeValue,
-				cacheHitRatioVal,
-				site.SiteName, site.Label, site.PlanName, site.Account,
-			))
+				v.value,
+				labelValues...,
+			)
+			if !ts.IsZero() {
+				metric = prometheus.NewMetricWithTimestamp(ts, metric)
+			}
+			ch <- metric
+		}
+	}
+
+	if opts.noTimestamps {
+		if hasData {
+			emit(time.Time{}, latestData)
+		}
+		return
+	}
+
+	// Emit all historical metrics EXCEPT the latest one
+	// (the latest will be emitted without its own timestamp at the end)
+	for timestampStr, data := range site.MetricsData {
+		if timestampStr == latestTimestampStr {
+			continue
+		}
+
+		timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
+		if err != nil {
+			log.Printf("Error parsing timestamp %s: %v", timestampStr, err)
+			atomic.AddInt64(&c.timestampErrors, 1)
+			continue
+		}
+		if ts := time.Unix(timestamp, 0); !ts.Before(opts.since) {
+			emit(ts, data)
 		}
 	}
+
+	if hasData {
+		emit(opts.now, latestData)
+	}
+}
+
+// siteAgeDays returns the number of days between a site's creation timestamp and now
+func siteAgeDays(created int64, now time.Time) float64 {
+	return now.Sub(time.Unix(created, 0)).Hours() / 24
+}
+
+// cacheMissRatio returns the cache miss ratio (0-1) of a sample from its parsed
+// hit ratio. Samples with no data ("--") keep the hit ratio's no-data value.
+func cacheMissRatio(ratio string, hitRatio float64) float64 {
+	if ratio == "--" {
+		return hitRatio
+	}
+	return 1 - hitRatio
 }
 
 // parseCacheHitRatio parses cache hit ratio string to float64 ratio (0-1).
@@ -198,25 +586,119 @@ func (c *PantheonCollector) Collect(ch chan<- prometheus.Metric) {
 // (Pantheon API doesn't return cache_hit_ratio; it's calculated by the library,
 // which uses "--" when pages_served is 0, matching Terminus CLI behavior).
 // Input is expected as percentage string (e.g., "50%" or "50"), output is ratio (0-1).
-func (c *PantheonCollector) parseCacheHitRatio(ratio string) float64 {
+// "--" is returned as 0, or NaN if noDataNaN is set (see SetCacheHitRatioNaN).
+// A ratio that fails to parse is logged and returned as 0, with ok set to false.
+func (c *PantheonCollector) parseCacheHitRatio(ratio string, noDataNaN bool) (value float64, ok bool) {
 	if ratio == "--" {
-		return 0
+		if noDataNaN {
+			return math.NaN(), true
+		}
+		return 0, true
 	}
 	cacheHitRatioStr := strings.TrimSuffix(ratio, "%")
 	cacheHitRatioVal, err := strconv.ParseFloat(cacheHitRatioStr, 64)
 	if err != nil {
 		log.Printf("Error parsing cache hit ratio %s: %v", ratio, err)
-		return 0
+		atomic.AddInt64(&c.ratioErrors, 1)
+		return 0, false
 	}
 	// Convert percentage (0-100) to ratio (0-1) per Prometheus naming conventions
-	return cacheHitRatioVal / 100
+	return cacheHitRatioVal / 100, true
 }
 
-// UpdateSites updates the sites in the collector (thread-safe)
+// siteLess orders sites by account, then site name
+func siteLess(a, b pantheon.SiteMetrics) bool {
+	if a.Account != b.Account {
+		return a.Account < b.Account
+	}
+	return a.SiteName < b.SiteName
+}
+
+// sortSites sorts sites by account, then site name. Site lists are built by
+// iterating maps, so sorting keeps GetSites, the refresh queue and the root
+// page in the same order from run to run.
+func sortSites(sites []pantheon.SiteMetrics) {
+	sort.SliceStable(sites, func(i, j int) bool { return siteLess(sites[i], sites[j]) })
+}
+
+// UpdateSites updates the sites in the collector, sorted by account and then
+// site name (thread-safe). The caller's slice is not modified.
 func (c *PantheonCollector) UpdateSites(sites []pantheon.SiteMetrics) {
+	sorted := make([]pantheon.SiteMetrics, len(sites))
+	copy(sorted, sites)
+	sortSites(sorted)
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.sites = sites
+	c.sites = sorted
+	c.pruneStatus()
+}
+
+// MergeSites replaces the site list with sites in a single atomic update
+// (thread-safe). Sites already in the collector keep their current metrics
+// data and environment, so metrics written concurrently by UpdateSiteMetrics
+// are never lost; new sites are added and sites missing from sites are removed.
+// Sites are sorted by account and then site name.
+func (c *PantheonCollector) MergeSites(sites []pantheon.SiteMetrics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	existing := make(map[string]pantheon.SiteMetrics, len(c.sites))
+	for _, site := range c.sites {
+		existing[site.Account+":"+site.SiteName] = site
+	}
+
+	merged := make([]pantheon.SiteMetrics, 0, len(sites))
+	for _, site := range sites {
+		if current, ok := existing[site.Account+":"+site.SiteName]; ok {
+			site.MetricsData = current.MetricsData
+			site.Environment = current.Environment
+		}
+		if site.MetricsData == nil {
+			site.MetricsData = make(map[string]pantheon.MetricData)
+		}
+		merged = append(merged, site)
+	}
+	sortSites(merged)
+	c.sites = merged
+	c.pruneStatus()
+}
+
+// pruneStatus removes the refresh status of sites no longer in the collector,
+// so a removed site's failures don't outlive it. The caller must hold c.mu.
+func (c *PantheonCollector) pruneStatus() {
+	current := make(map[string]bool, len(c.sites))
+	for _, site := range c.sites {
+		current[site.Account+":"+site.SiteName] = true
+	}
+	for key := range c.status {
+		if !current[key] {
+			delete(c.status, key)
+		}
+	}
+}
+
+// UpsertSite replaces the site with the same account and name as site, or
+// inserts it in account and site name order if there is none (thread-safe). If
+// site has no metrics data, the existing site's metrics data is kept.
+func (c *PantheonCollector) UpsertSite(site pantheon.SiteMetrics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range c.sites {
+		if c.sites[i].Account == site.Account && c.sites[i].SiteName == site.SiteName {
+			if len(site.MetricsData) == 0 {
+				site.MetricsData = c.sites[i].MetricsData
+			}
+			c.sites[i] = site
+			return
+		}
+	}
+	i := sort.Search(len(c.sites), func(i int) bool { return siteLess(site, c.sites[i]) })
+	sites := make([]pantheon.SiteMetrics, 0, len(c.sites)+1)
+	sites = append(sites, c.sites[:i]...)
+	sites = append(sites, site)
+	c.sites = append(sites, c.sites[i:]...)
 }
 
 // GetSites returns a copy of the current sites (thread-safe)
@@ -228,6 +710,43 @@ func (c *PantheonCollector) GetSites() []pantheon.SiteMetrics {
 	return sitesCopy
 }
 
+// ForEachSite calls fn for each current site while holding the read lock, avoiding
+// the slice copy made by GetSites. fn must not call methods that modify the collector.
+func (c *PantheonCollector) ForEachSite(fn func(site pantheon.SiteMetrics)) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, site := range c.sites {
+		fn(site)
+	}
+}
+
+// HasAnyMetrics reports whether metrics have been loaded for at least one site (thread-safe)
+func (c *PantheonCollector) HasAnyMetrics() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, site := range c.sites {
+		if len(site.MetricsData) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSite returns a copy of a specific site, and whether it was found (thread-safe)
+func (c *PantheonCollector) GetSite(accountID, siteName string) (pantheon.SiteMetrics, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, site := range c.sites {
+		if site.Account == accountID && site.SiteName == siteName {
+			return site, true
+		}
+	}
+	return pantheon.SiteMetrics{}, false
+}
+
 // UpdateSiteMetrics updates metrics for a specific site (thread-safe)
 func (c *PantheonCollector) UpdateSiteMetrics(accountID, siteName string, metricsData map[string]pantheon.MetricData) {
 	c.mu.Lock()
@@ -236,7 +755,82 @@ func (c *PantheonCollector) UpdateSiteMetrics(accountID, siteName string, metric
 	for i := range c.sites {
 		if c.sites[i].Account == accountID && c.sites[i].SiteName == siteName {
 			c.sites[i].MetricsData = metricsData
+			c.status[accountID+":"+siteName] = SiteStatus{LastSuccess: c.now()}
 			return
 		}
 	}
 }
+
+// SetSiteEnvironment records the environment a site's metrics were fetched from (thread-safe)
+func (c *PantheonCollector) SetSiteEnvironment(accountID, siteName, environment string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range c.sites {
+		if c.sites[i].Account == accountID && c.sites[i].SiteName == siteName {
+			c.sites[i].Environment = environment
+			return
+		}
+	}
+}
+
+// refreshSkewSeconds returns the time between the least and most recent
+// successful refreshes across current sites, and whether any site has been
+// refreshed. Callers must hold c.mu.
+func (c *PantheonCollector) refreshSkewSeconds() (float64, bool) {
+	var oldest, newest time.Time
+	for _, site := range c.sites {
+		last := c.status[site.Account+":"+site.SiteName].LastSuccess
+		if last.IsZero() {
+			continue
+		}
+		if oldest.IsZero() || last.Before(oldest) {
+			oldest = last
+		}
+		if last.After(newest) {
+			newest = last
+		}
+	}
+	if oldest.IsZero() {
+		return 0, false
+	}
+	return newest.Sub(oldest).Seconds(), true
+}
+
+// RecordSiteFailure marks the most recent metrics refresh for a site as
+// failed with err, which may be nil if the reason is unknown (thread-safe)
+func (c *PantheonCollector) RecordSiteFailure(accountID, siteName string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := accountID + ":" + siteName
+	status := c.status[key]
+	status.LastFailed = true
+	status.LastError = ""
+	if err != nil {
+		status.LastError = err.Error()
+	}
+	c.status[key] = status
+}
+
+// RefreshedAccounts returns the accounts with at least one current site whose
+// metrics have been refreshed successfully (thread-safe)
+func (c *PantheonCollector) RefreshedAccounts() map[string]bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	refreshed := make(map[string]bool)
+	for _, site := range c.sites {
+		if !c.status[site.Account+":"+site.SiteName].LastSuccess.IsZero() {
+			refreshed[site.Account] = true
+		}
+	}
+	return refreshed
+}
+
+// GetSiteStatus returns the refresh status for a specific site (thread-safe)
+func (c *PantheonCollector) GetSiteStatus(accountID, siteName string) SiteStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.status[accountID+":"+siteName]
+}
@@ -1,7 +1,14 @@
 package collector
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
 	"github.com/prometheus/client_golang/prometheus"
@@ -56,7 +63,7 @@ func TestDescribe(t *testing.T) {
 	sites := []pantheon.SiteMetrics{}
 	collector := NewPantheonCollector(sites)
 
-	ch := make(chan *prometheus.Desc, 5)
+	ch := make(chan *prometheus.Desc, 20)
 	collector.Describe(ch)
 	close(ch)
 
@@ -66,9 +73,9 @@ func TestDescribe(t *testing.T) {
 		count++
 	}
 
-	// Should have 5 metric descriptors (visits, pages_served, cache_hits, cache_misses, cache_hit_ratio)
-	if count != 5 {
-		t.Errorf("Expected 5 metric descriptors, got %d", count)
+	// Should have 12 metric descriptors (visits, pages_served, cache_hits, cache_misses, cache_hit_ratio, cache_miss_ratio, cache_total_requests, site_age, site_info, site_samples, site_last_refresh, refresh_skew)
+	if count != 12 {
+		t.Errorf("Expected 12 metric descriptors, got %d", count)
 	}
 }
 
@@ -115,10 +122,11 @@ func TestCollect(t *testing.T) {
 		count++
 	}
 
-	// Should have 10 metrics (5 metric types × 1 historical timestamp + 5 latest without timestamp)
+	// Should have 15 metrics (6 metric types × 1 historical timestamp + 6 latest without timestamp
+	// + cache_total_requests for the latest sample + site_info + site_samples)
 	// The latest timestamp is NOT emitted with a timestamp, only without one
-	if count != 10 {
-		t.Errorf("Expected 10 metrics, got %d", count)
+	if count != 15 {
+		t.Errorf("Expected 15 metrics, got %d", count)
 	}
 }
 
@@ -175,10 +183,10 @@ func TestCollectWithMultipleSites(t *testing.T) {
 		count++
 	}
 
-	// Should have 10 metrics (5 latest without timestamp × 2 sites)
+	// Should have 18 metrics ((7 latest without timestamp + site_info + site_samples) × 2 sites)
 	// Each site has only 1 timestamp, which is the latest, so no historical metrics are emitted
-	if count != 10 {
-		t.Errorf("Expected 10 metrics, got %d", count)
+	if count != 18 {
+		t.Errorf("Expected 18 metrics, got %d", count)
 	}
 }
 
@@ -211,14 +219,14 @@ func TestCollectWithInvalidTimestamp(t *testing.T) {
 	collector.Collect(ch)
 	close(ch)
 
-	// Should have 0 metrics due to invalid timestamp
+	// Should only have site_info and site_samples due to invalid timestamp
 	count := 0
 	for range ch {
 		count++
 	}
 
-	if count != 0 {
-		t.Errorf("Expected 0 metrics due to invalid timestamp, got %d", count)
+	if count != 2 {
+		t.Errorf("Expected only site_info and site_samples due to invalid timestamp, got %d metrics", count)
 	}
 }
 
@@ -251,15 +259,15 @@ func TestCollectWithInvalidCacheHitRatio(t *testing.T) {
 	collector.Collect(ch)
 	close(ch)
 
-	// Should still collect metrics, but cache hit ratio will be 0
+	// Should still collect metrics, but cache hit ratio will be 0 and the miss ratio is skipped
 	count := 0
 	for range ch {
 		count++
 	}
 
-	// Should have 5 metrics (only the latest without timestamp, no historical)
-	if count != 5 {
-		t.Errorf("Expected 5 metrics, got %d", count)
+	// Should have 8 metrics (only the latest without timestamp, no historical, no miss ratio, plus site_info and site_samples)
+	if count != 8 {
+		t.Errorf("Expected 8 metrics, got %d", count)
 	}
 }
 
@@ -515,14 +523,14 @@ func TestCollectWithEmptyMetricsData(t *testing.T) {
 	collector.Collect(ch)
 	close(ch)
 
-	// Should have 0 metrics since metrics data is empty
+	// Should only have site_info and site_samples since metrics data is empty
 	count := 0
 	for range ch {
 		count++
 	}
 
-	if count != 0 {
-		t.Errorf("Expected 0 metrics with empty metrics data, got %d", count)
+	if count != 2 {
+		t.Errorf("Expected only site_info and site_samples with empty metrics data, got %d metrics", count)
 	}
 }
 
@@ -562,9 +570,115 @@ func TestCollectWithNoDataCacheHitRatio(t *testing.T) {
 		count++
 	}
 
-	// Should have 5 metrics (only the latest without timestamp, no historical)
-	if count != 5 {
-		t.Errorf("Expected 5 metrics, got %d", count)
+	// Should have 9 metrics (only the latest without timestamp, no historical, plus site_info and site_samples)
+	if count != 9 {
+		t.Errorf("Expected 9 metrics, got %d", count)
+	}
+}
+
+func TestCollectNoTimestamps(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName: testCollectorSite1,
+			Label:    "Site 1",
+			PlanName: "Basic",
+			Account:  "account1",
+			MetricsData: map[string]pantheon.MetricData{
+				"1762646400": {Visits: 8, PagesServed: 40, CacheHits: 20, CacheMisses: 20, CacheHitRatio: "50%"},
+				"1762732800": {Visits: 10, PagesServed: 50, CacheHits: 30, CacheMisses: 20, CacheHitRatio: "60%"},
+				"1762819200": {Visits: 12, PagesServed: 60, CacheHits: 45, CacheMisses: 15, CacheHitRatio: "75%"},
+			},
+		},
+	}
+
+	c := NewPantheonCollector(sites)
+	c.SetNoTimestamps(true)
+	c.SetDailyDeltas(true)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c, NewLegacyCollector(c))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	values := map[string]float64{}
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			if m.TimestampMs != nil {
+				t.Errorf("Expected %s to have no timestamp, got %d", mf.GetName(), m.GetTimestampMs())
+			}
+		}
+		switch mf.GetName() {
+		case "pantheon_visits_total", "pantheon_visits", "pantheon_visits_daily", "pantheon_cache_hit_ratio":
+			if len(mf.GetMetric()) != 1 {
+				t.Errorf("Expected only the latest %s sample, got %d", mf.GetName(), len(mf.GetMetric()))
+				continue
+			}
+			values[mf.GetName()] = mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+
+	expected := map[string]float64{
+		"pantheon_visits_total":    12,
+		"pantheon_visits":          12,
+		"pantheon_visits_daily":    2,
+		"pantheon_cache_hit_ratio": 0.75,
+	}
+	for name, want := range expected {
+		if got, ok := values[name]; !ok || got != want {
+			t.Errorf("Expected %s %v, got %v (present %v)", name, want, got, ok)
+		}
+	}
+}
+
+func TestCollectNoDataCacheHitRatioNaN(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName: testCollectorSite1,
+			Account:  "account1",
+			MetricsData: map[string]pantheon.MetricData{
+				"1762732800": {PagesServed: 10, CacheHits: 5, CacheMisses: 5, CacheHitRatio: "50%"},
+				// The latest sample is stamped with the current time
+				"1762819200": {CacheHitRatio: "--"},
+			},
+		},
+	}
+
+	for _, enabled := range []bool{false, true} {
+		c := NewPantheonCollector(sites)
+		c.SetCacheHitRatioNaN(enabled)
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(c)
+
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("Failed to gather metrics: %v", err)
+		}
+
+		var real, noData float64
+		for _, mf := range families {
+			if mf.GetName() != "pantheon_cache_hit_ratio" {
+				continue
+			}
+			for _, m := range mf.GetMetric() {
+				if m.GetTimestampMs() == 1762732800000 {
+					real = m.GetGauge().GetValue()
+				} else {
+					noData = m.GetGauge().GetValue()
+				}
+			}
+		}
+
+		if enabled && !math.IsNaN(noData) {
+			t.Errorf("Expected NaN for a \"--\" ratio when enabled, got %v", noData)
+		}
+		if !enabled && noData != 0 {
+			t.Errorf("Expected 0 for a \"--\" ratio by default, got %v", noData)
+		}
+		if real != 0.5 {
+			t.Errorf("enabled=%v: expected a real ratio of 0.5, got %v", enabled, real)
+		}
 	}
 }
 
@@ -603,9 +717,9 @@ func TestCollectWithNoCacheHitRatioPercentSign(t *testing.T) {
 		count++
 	}
 
-	// Should have 5 metrics (only the latest without timestamp, no historical)
-	if count != 5 {
-		t.Errorf("Expected 5 metrics, got %d", count)
+	// Should have 9 metrics (only the latest without timestamp, no historical, plus site_info and site_samples)
+	if count != 9 {
+		t.Errorf("Expected 9 metrics, got %d", count)
 	}
 }
 
@@ -623,7 +737,7 @@ func TestNewPantheonCollectorWithEmptySites(t *testing.T) {
 	}
 
 	// Verify descriptors are still created
-	ch := make(chan *prometheus.Desc, 5)
+	ch := make(chan *prometheus.Desc, 20)
 	collector.Describe(ch)
 	close(ch)
 
@@ -632,8 +746,8 @@ func TestNewPantheonCollectorWithEmptySites(t *testing.T) {
 		count++
 	}
 
-	if count != 5 {
-		t.Errorf("Expected 5 descriptors even with empty sites, got %d", count)
+	if count != 12 {
+		t.Errorf("Expected 12 descriptors even with empty sites, got %d", count)
 	}
 }
 
@@ -707,9 +821,9 @@ func TestCollectWithZeroValues(t *testing.T) {
 		count++
 	}
 
-	// Should have 5 metrics (only the latest without timestamp, no historical)
-	if count != 5 {
-		t.Errorf("Expected 5 metrics with zero values, got %d", count)
+	// Should have 9 metrics (only the latest without timestamp, no historical, plus site_info and site_samples)
+	if count != 9 {
+		t.Errorf("Expected 9 metrics with zero values, got %d", count)
 	}
 }
 
@@ -747,9 +861,9 @@ func TestCollectWithLargeNumbers(t *testing.T) {
 		count++
 	}
 
-	// Should have 5 metrics (only the latest without timestamp, no historical)
-	if count != 5 {
-		t.Errorf("Expected 5 metrics, got %d", count)
+	// Should have 9 metrics (only the latest without timestamp, no historical, plus site_info and site_samples)
+	if count != 9 {
+		t.Errorf("Expected 9 metrics, got %d", count)
 	}
 }
 
@@ -788,8 +902,952 @@ func TestCollectWithNegativeTimestamp(t *testing.T) {
 		count++
 	}
 
-	// Should have 5 metrics (only the latest without timestamp, no historical)
-	if count != 5 {
-		t.Errorf("Expected 5 metrics, got %d", count)
+	// Should have 9 metrics (only the latest without timestamp, no historical, plus site_info and site_samples)
+	if count != 9 {
+		t.Errorf("Expected 9 metrics, got %d", count)
+	}
+}
+
+func TestSiteStatus(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName:    testCollectorSite1,
+			Label:       "Site 1",
+			PlanName:    "Basic",
+			Account:     "account1",
+			MetricsData: map[string]pantheon.MetricData{},
+		},
+	}
+	collector := NewPantheonCollector(sites)
+
+	// No refresh has happened yet
+	status := collector.GetSiteStatus("account1", testCollectorSite1)
+	if !status.LastSuccess.IsZero() || status.LastFailed {
+		t.Errorf("Expected empty status before any refresh, got %+v", status)
+	}
+
+	// A successful update records the refresh time
+	collector.UpdateSiteMetrics("account1", testCollectorSite1, map[string]pantheon.MetricData{})
+	status = collector.GetSiteStatus("account1", testCollectorSite1)
+	if status.LastSuccess.IsZero() {
+		t.Error("Expected LastSuccess to be set after successful update")
+	}
+	if status.LastFailed {
+		t.Error("Expected LastFailed to be false after successful update")
+	}
+	lastSuccess := status.LastSuccess
+
+	// A failure keeps the last success time
+	collector.RecordSiteFailure("account1", testCollectorSite1, errors.New("rate limited"))
+	status = collector.GetSiteStatus("account1", testCollectorSite1)
+	if !status.LastFailed {
+		t.Error("Expected LastFailed to be true after failure")
+	}
+	if status.LastError != "rate limited" {
+		t.Errorf("Expected the failure's error to be recorded, got %q", status.LastError)
+	}
+	if !status.LastSuccess.Equal(lastSuccess) {
+		t.Error("Expected LastSuccess to be preserved after failure")
+	}
+
+	// Status survives a site list update
+	collector.UpdateSites(sites)
+	if !collector.GetSiteStatus("account1", testCollectorSite1).LastFailed {
+		t.Error("Expected status to be preserved across UpdateSites")
+	}
+}
+
+func TestCollectWithMinVisits(t *testing.T) {
+	// Test that sites below the visit threshold are not emitted
+	highTraffic := map[string]pantheon.MetricData{
+		"1762732800": {Visits: 10, PagesServed: 20, CacheHitRatio: "50%"},
+		"1762819200": {Visits: 500, PagesServed: 1000, CacheHitRatio: "50%"},
+	}
+	lowTraffic := map[string]pantheon.MetricData{
+		"1762732800": {Visits: 900, PagesServed: 1000, CacheHitRatio: "50%"},
+		"1762819200": {Visits: 2, PagesServed: 4, CacheHitRatio: "50%"},
+	}
+
+	sites := []pantheon.SiteMetrics{
+		{SiteName: "busy", Label: "busy", PlanName: "Basic", Account: "account1", MetricsData: highTraffic},
+		{SiteName: "idle", Label: "idle", PlanName: "Basic", Account: "account1", MetricsData: lowTraffic},
+		{SiteName: "empty", Label: "empty", PlanName: "Basic", Account: "account1", MetricsData: map[string]pantheon.MetricData{}},
+	}
+
+	collector := NewPantheonCollector(sites)
+	collector.SetMinVisits(100)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	count := 0
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			count++
+			for _, label := range m.GetLabel() {
+				if label.GetValue() == "idle" || label.GetValue() == "empty" {
+					t.Errorf("Expected low-traffic site to be filtered, got %s in %s", label.GetValue(), mf.GetName())
+				}
+			}
+		}
+	}
+
+	// Only the busy site should be emitted: 6 metric types x 2 timestamps + cache_total_requests + site_info + site_samples
+	if count != 15 {
+		t.Errorf("Expected 15 metrics, got %d", count)
+	}
+}
+
+func TestGetSite(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{SiteName: testCollectorSite1, PlanName: "Basic", Account: "account1"},
+		{SiteName: testCollectorSite1, PlanName: "Performance", Account: "account2"},
+	}
+	collector := NewPantheonCollector(sites)
+
+	site, ok := collector.GetSite("account2", testCollectorSite1)
+	if !ok {
+		t.Fatal("Expected site to be found")
+	}
+	if site.PlanName != "Performance" {
+		t.Errorf("Expected plan Performance, got %s", site.PlanName)
+	}
+
+	if _, ok := collector.GetSite("account3", testCollectorSite1); ok {
+		t.Error("Expected site not to be found for unknown account")
+	}
+}
+
+func TestHasAnyMetrics(t *testing.T) {
+	collector := NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: testCollectorSite1, Account: "account1", MetricsData: map[string]pantheon.MetricData{}},
+	})
+
+	if collector.HasAnyMetrics() {
+		t.Error("Expected no metrics before update")
+	}
+
+	collector.UpdateSiteMetrics("account1", testCollectorSite1, map[string]pantheon.MetricData{
+		"1762732800": {Visits: 1},
+	})
+
+	if !collector.HasAnyMetrics() {
+		t.Error("Expected metrics after update")
+	}
+}
+
+func TestSiteAgeDays(t *testing.T) {
+	// 2025-01-01T00:00:00Z
+	created := int64(1735689600)
+	now := time.Date(2025, 1, 11, 12, 0, 0, 0, time.UTC)
+
+	if age := siteAgeDays(created, now); age != 10.5 {
+		t.Errorf("Expected age of 10.5 days, got %v", age)
+	}
+}
+
+func TestCollectWithSiteAge(t *testing.T) {
+	now := time.Unix(1762732800, 0)
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName:    testCollectorSite1,
+			Label:       "Site 1",
+			PlanName:    "Basic",
+			Account:     "account1",
+			Created:     now.Add(-72 * time.Hour).Unix(),
+			MetricsData: map[string]pantheon.MetricData{},
+		},
+		{
+			SiteName:    "site2",
+			Label:       "Site 2",
+			PlanName:    "Basic",
+			Account:     "account1",
+			MetricsData: map[string]pantheon.MetricData{},
+		},
+	}
+
+	c := NewPantheonCollector(sites)
+	c.now = func() time.Time { return now }
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	found := false
+	for _, mf := range families {
+		if mf.GetName() != "pantheon_site_age_days" {
+			continue
+		}
+		found = true
+		// Only the site with a known creation time is emitted
+		if len(mf.GetMetric()) != 1 {
+			t.Fatalf("Expected 1 site age series, got %d", len(mf.GetMetric()))
+		}
+		if age := mf.GetMetric()[0].GetGauge().GetValue(); age != 3 {
+			t.Errorf("Expected site age of 3 days, got %v", age)
+		}
+	}
+
+	if !found {
+		t.Error("Expected pantheon_site_age_days metric")
+	}
+}
+
+func TestForEachSite(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{SiteName: "site1", Account: "account1"},
+		{SiteName: "site2", Account: "account1"},
+		{SiteName: "site3", Account: "account2"},
+	}
+	collector := NewPantheonCollector(sites)
+
+	var names []string
+	collector.ForEachSite(func(site pantheon.SiteMetrics) {
+		names = append(names, site.SiteName)
+	})
+
+	if len(names) != 3 {
+		t.Fatalf("Expected 3 sites, got %d", len(names))
+	}
+	for i, name := range []string{"site1", "site2", "site3"} {
+		if names[i] != name {
+			t.Errorf("Expected site %d to be %s, got %s", i, name, names[i])
+		}
+	}
+}
+
+// benchmarkSites builds a fleet of sites with a week of metrics each
+func benchmarkSites(count int) []pantheon.SiteMetrics {
+	sites := make([]pantheon.SiteMetrics, count)
+	for i := range sites {
+		metricsData := make(map[string]pantheon.MetricData)
+		for day := 0; day < 7; day++ {
+			timestamp := fmt.Sprintf("%d", 1762732800+day*86400)
+			metricsData[timestamp] = pantheon.MetricData{Visits: 100, PagesServed: 500, CacheHitRatio: "10%"}
+		}
+		sites[i] = pantheon.SiteMetrics{
+			SiteName:    fmt.Sprintf("site%d", i),
+			SiteID:      fmt.Sprintf("site-uuid-%d", i),
+			Account:     "account@example.com",
+			MetricsData: metricsData,
+		}
+	}
+	return sites
+}
+
+func BenchmarkGetSites(b *testing.B) {
+	collector := NewPantheonCollector(benchmarkSites(5000))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		count := 0
+		for _, site := range collector.GetSites() {
+			count += len(site.SiteName)
+		}
+	}
+}
+
+func BenchmarkForEachSite(b *testing.B) {
+	collector := NewPantheonCollector(benchmarkSites(5000))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		count := 0
+		collector.ForEachSite(func(site pantheon.SiteMetrics) {
+			count += len(site.SiteName)
+		})
+	}
+}
+
+func BenchmarkCollect(b *testing.B) {
+	collector := NewPantheonCollector(benchmarkSites(500))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ch := make(chan prometheus.Metric, 1024)
+		go func() {
+			collector.Collect(ch)
+			close(ch)
+		}()
+		for range ch {
+		}
+	}
+}
+
+// BenchmarkUpdateSiteMetricsDuringCollect measures writes while scrapes are
+// running, which only wait for the collector state to be copied
+func BenchmarkUpdateSiteMetricsDuringCollect(b *testing.B) {
+	collector := NewPantheonCollector(benchmarkSites(500))
+	done := make(chan struct{})
+	var scrapes sync.WaitGroup
+	scrapes.Add(1)
+	go func() {
+		defer scrapes.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			ch := make(chan prometheus.Metric, 1024)
+			go func() {
+				collector.Collect(ch)
+				close(ch)
+			}()
+			for range ch {
+			}
+		}
+	}()
+	metricsData := map[string]pantheon.MetricData{"1762732800": {Visits: 1, CacheHitRatio: "10%"}}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		collector.UpdateSiteMetrics("account@example.com", fmt.Sprintf("site%d", i%500), metricsData)
+	}
+
+	b.StopTimer()
+	close(done)
+	scrapes.Wait()
+}
+
+func TestCollectCacheTotalRequests(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName: testCollectorSite1,
+			Label:    "Site 1",
+			PlanName: "Basic",
+			Account:  "account1",
+			MetricsData: map[string]pantheon.MetricData{
+				"1762732800": {Visits: 10, PagesServed: 50, CacheHits: 30, CacheMisses: 20, CacheHitRatio: "60%"},
+				"1762819200": {Visits: 12, PagesServed: 60, CacheHits: 45, CacheMisses: 15, CacheHitRatio: "75%"},
+			},
+		},
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewPantheonCollector(sites))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	found := false
+	for _, mf := range families {
+		if mf.GetName() != "pantheon_cache_total_requests" {
+			continue
+		}
+		found = true
+		if len(mf.GetMetric()) != 1 {
+			t.Fatalf("Expected 1 cache total requests series, got %d", len(mf.GetMetric()))
+		}
+		// Only the latest sample is summed
+		if value := mf.GetMetric()[0].GetGauge().GetValue(); value != 60 {
+			t.Errorf("Expected cache total requests of 60, got %v", value)
+		}
+	}
+
+	if !found {
+		t.Error("Expected pantheon_cache_total_requests metric")
+	}
+}
+
+func TestCollectCacheMissRatio(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName: testCollectorSite1,
+			Account:  "account1",
+			MetricsData: map[string]pantheon.MetricData{
+				"1762732800": {PagesServed: 100, CacheHits: 25, CacheMisses: 75, CacheHitRatio: "25%"},
+			},
+		},
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewPantheonCollector(sites))
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	values := map[string]float64{}
+	for _, mf := range families {
+		if mf.GetName() == "pantheon_cache_hit_ratio" || mf.GetName() == "pantheon_cache_miss_ratio" {
+			values[mf.GetName()] = mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	if got := values["pantheon_cache_miss_ratio"]; math.Abs(got-0.75) > 1e-9 {
+		t.Errorf("Expected miss ratio 0.75, got %v", got)
+	}
+	if sum := values["pantheon_cache_hit_ratio"] + values["pantheon_cache_miss_ratio"]; math.Abs(sum-1) > 1e-9 {
+		t.Errorf("Expected hit and miss ratios to sum to 1, got %v", sum)
+	}
+}
+
+func TestCacheMissRatioNoData(t *testing.T) {
+	if got := cacheMissRatio("--", 0); got != 0 {
+		t.Errorf("Expected a sample with no data to have miss ratio 0, got %v", got)
+	}
+	if got := cacheMissRatio("--", math.NaN()); !math.IsNaN(got) {
+		t.Errorf("Expected a sample with no data to keep a NaN miss ratio, got %v", got)
+	}
+}
+
+func TestCollectCacheMissRatioParseError(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName: testCollectorSite1,
+			Account:  "account1",
+			MetricsData: map[string]pantheon.MetricData{
+				"1762732800": {PagesServed: 100, CacheHitRatio: "invalid"},
+			},
+		},
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewPantheonCollector(sites))
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	for _, mf := range families {
+		if mf.GetName() == "pantheon_cache_miss_ratio" {
+			t.Errorf("Expected no miss ratio for an unparseable hit ratio, got %v", mf.GetMetric())
+		}
+	}
+}
+
+func TestCollectSiteInfo(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName:    testCollectorSite1,
+			Label:       "Site 1",
+			PlanName:    "Basic",
+			Account:     "account1",
+			Owner:       "b3f4c5d6-owner-user-id",
+			Framework:   "drupal8",
+			Source:      pantheon.SiteSourceOrg,
+			MetricsData: map[string]pantheon.MetricData{},
+		},
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewPantheonCollector(sites))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	for _, mf := range families {
+		if mf.GetName() != "pantheon_site_info" {
+			continue
+		}
+		// Emitted even before any metrics data has been fetched
+		if len(mf.GetMetric()) != 1 {
+			t.Fatalf("Expected 1 site info series, got %d", len(mf.GetMetric()))
+		}
+		m := mf.GetMetric()[0]
+		labels := map[string]string{}
+		for _, lp := range m.GetLabel() {
+			labels[lp.GetName()] = lp.GetValue()
+		}
+		if labels["owner"] != "b3f4c5d6-owner-user-id" {
+			t.Errorf("Expected raw owner ID label, got %q", labels["owner"])
+		}
+		if labels["framework"] != "drupal8" {
+			t.Errorf("Expected framework label drupal8, got %q", labels["framework"])
+		}
+		if labels["source"] != "org" {
+			t.Errorf("Expected source label org, got %q", labels["source"])
+		}
+		if labels["site_id"] != testCollectorSite1 {
+			t.Errorf("Expected site_id %q, got %q", testCollectorSite1, labels["site_id"])
+		}
+		if m.GetGauge().GetValue() != 1 {
+			t.Errorf("Expected site info value 1, got %v", m.GetGauge().GetValue())
+		}
+		return
+	}
+	t.Error("Expected pantheon_site_info metric")
+}
+
+func TestCollectSiteSamples(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName: testCollectorSite1,
+			Label:    "Site 1",
+			PlanName: "Basic",
+			Account:  "account1",
+			MetricsData: map[string]pantheon.MetricData{
+				"1762560000": {Visits: 8},
+				"1762646400": {Visits: 9},
+				"1762732800": {Visits: 10},
+			},
+		},
+		{
+			SiteName:    "site2",
+			Label:       "Site 2",
+			PlanName:    "Basic",
+			Account:     "account1",
+			MetricsData: map[string]pantheon.MetricData{},
+		},
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewPantheonCollector(sites))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	expected := map[string]float64{testCollectorSite1: 3, "site2": 0}
+	for _, mf := range families {
+		if mf.GetName() != "pantheon_site_samples" {
+			continue
+		}
+		if len(mf.GetMetric()) != len(expected) {
+			t.Fatalf("Expected %d site samples series, got %d", len(expected), len(mf.GetMetric()))
+		}
+		for _, m := range mf.GetMetric() {
+			var siteID string
+			for _, lp := range m.GetLabel() {
+				if lp.GetName() == "site_id" {
+					siteID = lp.GetValue()
+				}
+			}
+			if got := m.GetGauge().GetValue(); got != expected[siteID] {
+				t.Errorf("Expected %v samples for %s, got %v", expected[siteID], siteID, got)
+			}
+		}
+		return
+	}
+	t.Error("Expected pantheon_site_samples metric")
+}
+
+func TestMergeSites(t *testing.T) {
+	c := NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "kept", Label: "Old Label", Account: "account1", Environment: "dev",
+			MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 10}}},
+		{SiteName: "removed", Account: "account1"},
+	})
+
+	c.MergeSites([]pantheon.SiteMetrics{
+		{SiteName: "kept", Label: "New Label", Account: "account1"},
+		{SiteName: "added", Account: "account1"},
+	})
+
+	sites := c.GetSites()
+	if len(sites) != 2 {
+		t.Fatalf("Expected 2 sites, got %d", len(sites))
+	}
+	kept, ok := c.GetSite("account1", "kept")
+	if !ok {
+		t.Fatal("Expected kept site to remain")
+	}
+	if kept.Label != "New Label" {
+		t.Errorf("Expected metadata to be updated, got label %q", kept.Label)
+	}
+	if kept.MetricsData["1762732800"].Visits != 10 || kept.Environment != "dev" {
+		t.Errorf("Expected metrics data and environment to be preserved, got %+v", kept)
+	}
+	added, ok := c.GetSite("account1", "added")
+	if !ok {
+		t.Fatal("Expected added site to be present")
+	}
+	if added.MetricsData == nil {
+		t.Error("Expected added site to have non-nil metrics data")
+	}
+	if _, ok := c.GetSite("account1", "removed"); ok {
+		t.Error("Expected removed site to be gone")
+	}
+}
+
+func TestRemovedSitesForgetStatus(t *testing.T) {
+	c := NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "kept", Account: "account1"},
+		{SiteName: "removed", Account: "account1"},
+		{SiteName: "replaced", Account: "account1"},
+	})
+	for _, name := range []string{"kept", "removed", "replaced"} {
+		c.RecordSiteFailure("account1", name, errors.New("api unavailable"))
+	}
+
+	c.MergeSites([]pantheon.SiteMetrics{
+		{SiteName: "kept", Account: "account1"},
+		{SiteName: "replaced", Account: "account1"},
+	})
+	if !c.GetSiteStatus("account1", "kept").LastFailed {
+		t.Error("Expected a kept site to keep its status")
+	}
+	if status := c.GetSiteStatus("account1", "removed"); status.LastFailed || status.LastError != "" {
+		t.Errorf("Expected the status of a site removed by MergeSites to be forgotten, got %+v", status)
+	}
+
+	c.UpdateSites([]pantheon.SiteMetrics{{SiteName: "kept", Account: "account1"}})
+	if status := c.GetSiteStatus("account1", "replaced"); status.LastFailed {
+		t.Errorf("Expected the status of a site dropped by UpdateSites to be forgotten, got %+v", status)
+	}
+	if len(c.status) != 1 {
+		t.Errorf("Expected only the kept site's status, got %v", c.status)
+	}
+
+	// A site that returns starts without its old failure
+	c.MergeSites([]pantheon.SiteMetrics{{SiteName: "kept", Account: "account1"}, {SiteName: "removed", Account: "account1"}})
+	if c.GetSiteStatus("account1", "removed").LastFailed {
+		t.Error("Expected a returning site to start with a clean status")
+	}
+}
+
+func TestMergeSitesConcurrentUpdates(t *testing.T) {
+	const siteCount = 50
+	sites := make([]pantheon.SiteMetrics, siteCount)
+	for i := range sites {
+		sites[i] = pantheon.SiteMetrics{SiteName: fmt.Sprintf("site%d", i), Account: "account1"}
+	}
+	c := NewPantheonCollector(sites)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	// Keep merging a fresh site list (without metrics) while metrics are written
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				fresh := make([]pantheon.SiteMetrics, siteCount)
+				for i := range fresh {
+					fresh[i] = pantheon.SiteMetrics{SiteName: fmt.Sprintf("site%d", i), Account: "account1"}
+				}
+				c.MergeSites(fresh)
+			}
+		}
+	}()
+
+	var writers sync.WaitGroup
+	for i := 0; i < siteCount; i++ {
+		writers.Add(1)
+		go func(i int) {
+			defer writers.Done()
+			c.UpdateSiteMetrics("account1", fmt.Sprintf("site%d", i), map[string]pantheon.MetricData{
+				"1762732800": {Visits: i + 1},
+			})
+		}(i)
+	}
+	writers.Wait()
+	close(done)
+	wg.Wait()
+
+	// A final merge must not discard anything written above
+	c.MergeSites(sites)
+
+	for i := 0; i < siteCount; i++ {
+		site, ok := c.GetSite("account1", fmt.Sprintf("site%d", i))
+		if !ok {
+			t.Fatalf("Expected site%d to be present", i)
+		}
+		if site.MetricsData["1762732800"].Visits != i+1 {
+			t.Errorf("Expected metrics update for site%d to survive merges, got %+v", i, site.MetricsData)
+		}
+	}
+}
+
+func TestCollectRefreshSkew(t *testing.T) {
+	c := NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "site1", Label: "site1", Account: "account1"},
+		{SiteName: "site2", Label: "site2", Account: "account1"},
+		{SiteName: "never", Label: "never", Account: "account1"},
+	})
+
+	// No site has been refreshed yet, so there is no skew
+	if _, ok := c.refreshSkewSeconds(); ok {
+		t.Error("Expected no skew before any refresh")
+	}
+
+	base := time.Unix(1762732800, 0)
+	c.now = func() time.Time { return base }
+	c.UpdateSiteMetrics("account1", "site1", map[string]pantheon.MetricData{"1762732800": {Visits: 1}})
+	c.now = func() time.Time { return base.Add(90 * time.Minute) }
+	c.UpdateSiteMetrics("account1", "site2", map[string]pantheon.MetricData{"1762732800": {Visits: 1}})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	var skew float64
+	lastRefresh := map[string]float64{}
+	for _, mf := range families {
+		switch mf.GetName() {
+		case "pantheon_exporter_refresh_skew_seconds":
+			skew = mf.GetMetric()[0].GetGauge().GetValue()
+		case "pantheon_site_last_refresh_timestamp_seconds":
+			for _, m := range mf.GetMetric() {
+				for _, lp := range m.GetLabel() {
+					if lp.GetName() == "site_id" {
+						lastRefresh[lp.GetValue()] = m.GetGauge().GetValue()
+					}
+				}
+			}
+		}
+	}
+
+	// Sites that were never refreshed are ignored
+	if skew != 5400 {
+		t.Errorf("Expected skew of 5400 seconds, got %v", skew)
+	}
+	if lastRefresh["site1"] != float64(base.Unix()) || lastRefresh["site2"] != float64(base.Add(90*time.Minute).Unix()) {
+		t.Errorf("Expected last refresh timestamps for both sites, got %v", lastRefresh)
+	}
+	if _, ok := lastRefresh["never"]; ok {
+		t.Error("Expected no last refresh timestamp for a site never refreshed")
+	}
+}
+
+func TestUpsertSite(t *testing.T) {
+	metricsData := map[string]pantheon.MetricData{"1762732800": {Visits: 10}}
+
+	tests := []struct {
+		name           string
+		site           pantheon.SiteMetrics
+		expectedSites  int
+		expectedPlan   string
+		expectedVisits int
+	}{
+		{
+			name:           "update replaces metadata and metrics",
+			site:           pantheon.SiteMetrics{SiteName: "site1", Account: "account1", PlanName: "Performance Small", MetricsData: map[string]pantheon.MetricData{"1762819200": {Visits: 20}, "1762732800": {Visits: 15}}},
+			expectedSites:  2,
+			expectedPlan:   "Performance Small",
+			expectedVisits: 15,
+		},
+		{
+			name:           "empty metrics preserve existing data",
+			site:           pantheon.SiteMetrics{SiteName: "site1", Account: "account1", PlanName: "Performance Small"},
+			expectedSites:  2,
+			expectedPlan:   "Performance Small",
+			expectedVisits: 10,
+		},
+		{
+			name:          "new site is inserted",
+			site:          pantheon.SiteMetrics{SiteName: "site1", Account: "account2", PlanName: "Basic"},
+			expectedSites: 3,
+			expectedPlan:  "Basic",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewPantheonCollector([]pantheon.SiteMetrics{
+				{SiteName: "site1", Account: "account1", PlanName: "Basic", MetricsData: metricsData},
+				{SiteName: "site2", Account: "account1", PlanName: "Basic"},
+			})
+
+			c.UpsertSite(tt.site)
+
+			if sites := c.GetSites(); len(sites) != tt.expectedSites {
+				t.Fatalf("Expected %d sites, got %d", tt.expectedSites, len(sites))
+			}
+			site, ok := c.GetSite(tt.site.Account, tt.site.SiteName)
+			if !ok {
+				t.Fatal("Expected upserted site to be present")
+			}
+			if site.PlanName != tt.expectedPlan {
+				t.Errorf("Expected plan %q, got %q", tt.expectedPlan, site.PlanName)
+			}
+			if site.MetricsData["1762732800"].Visits != tt.expectedVisits {
+				t.Errorf("Expected %d visits, got %d", tt.expectedVisits, site.MetricsData["1762732800"].Visits)
+			}
+			if other, _ := c.GetSite("account1", "site2"); other.PlanName != "Basic" {
+				t.Errorf("Expected other sites to be untouched, got %+v", other)
+			}
+		})
+	}
+}
+
+func TestCollectDoesNotBlockWriters(t *testing.T) {
+	c := NewPantheonCollector(benchmarkSites(10))
+
+	// An unbuffered channel stalls Collect part way through emitting, like a slow scrape
+	ch := make(chan prometheus.Metric)
+	collected := make(chan struct{})
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+	<-ch
+
+	updated := make(chan struct{})
+	go func() {
+		c.UpdateSiteMetrics("account@example.com", "site0", map[string]pantheon.MetricData{"1762732800": {Visits: 42}})
+		c.MergeSites(benchmarkSites(10))
+		close(updated)
+	}()
+	select {
+	case <-updated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected writers not to be blocked by a scrape in progress")
+	}
+
+	go func() {
+		for range ch {
+		}
+		close(collected)
+	}()
+	<-collected
+
+	// The next scrape sees the update
+	site, _ := c.GetSite("account@example.com", "site0")
+	if site.MetricsData["1762732800"].Visits != 42 {
+		t.Errorf("Expected the update made during the scrape to be kept, got %+v", site.MetricsData)
 	}
 }
+
+func TestCollectSlowConsumer(t *testing.T) {
+	c := NewPantheonCollector(benchmarkSites(10))
+
+	// Read metrics slowly, like a registry stuck behind a slow scraper
+	ch := make(chan prometheus.Metric)
+	var scrapeDone int32
+	go func() {
+		c.Collect(ch)
+		atomic.StoreInt32(&scrapeDone, 1)
+		close(ch)
+	}()
+	<-ch // The scrape has taken its snapshot
+	consumed := make(chan int)
+	go func() {
+		count := 1
+		for range ch {
+			count++
+			time.Sleep(time.Millisecond)
+		}
+		consumed <- count
+	}()
+
+	for i := 0; i < 10; i++ {
+		c.UpdateSiteMetrics("account@example.com", fmt.Sprintf("site%d", i), map[string]pantheon.MetricData{"1762732800": {Visits: i, CacheHitRatio: "10%"}})
+		c.SetSiteEnvironment("account@example.com", fmt.Sprintf("site%d", i), "live")
+	}
+	if atomic.LoadInt32(&scrapeDone) != 0 {
+		t.Error("Expected writes to finish while the slow scrape was still running")
+	}
+
+	// The scrape emits the sites as they were when it started
+	if count := <-consumed; count < 10*7*5 {
+		t.Errorf("Expected every sample from the scrape's snapshot, got %d metrics", count)
+	}
+}
+
+func TestCollectConcurrentWithUpdates(t *testing.T) {
+	c := NewPantheonCollector(benchmarkSites(50))
+	c.SetDailyDeltas(true)
+	c.SetMinVisits(1)
+
+	done := make(chan struct{})
+	var writers sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		writers.Add(1)
+		go func(w int) {
+			defer writers.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				name := fmt.Sprintf("site%d", (w*13+i)%50)
+				c.UpdateSiteMetrics("account@example.com", name, map[string]pantheon.MetricData{
+					"1762732800": {Visits: i, PagesServed: i, CacheHitRatio: "50%"},
+					"1762819200": {Visits: i + 1, PagesServed: i + 1, CacheHitRatio: "--"},
+				})
+				c.SetSiteEnvironment("account@example.com", name, "live")
+				if i%10 == 0 {
+					c.MergeSites(benchmarkSites(50))
+				}
+			}
+		}(w)
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	for i := 0; i < 20; i++ {
+		if _, err := registry.Gather(); err != nil {
+			t.Fatalf("Failed to gather metrics during updates: %v", err)
+		}
+	}
+	close(done)
+	writers.Wait()
+}
+
+func TestSitesSorted(t *testing.T) {
+	var expected []string
+	var sites []pantheon.SiteMetrics
+	for _, account := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		for i := 0; i < 5; i++ {
+			name := fmt.Sprintf("site%d", i)
+			expected = append(expected, account+"/"+name)
+			sites = append(sites, pantheon.SiteMetrics{SiteName: name, Account: account})
+		}
+	}
+	shuffled := func() []pantheon.SiteMetrics {
+		s := append([]pantheon.SiteMetrics(nil), sites...)
+		rand.New(rand.NewSource(1)).Shuffle(len(s), func(i, j int) { s[i], s[j] = s[j], s[i] })
+		return s
+	}
+	assertSorted := func(t *testing.T, c *PantheonCollector, expected []string) {
+		t.Helper()
+		var got []string
+		for _, site := range c.GetSites() {
+			got = append(got, site.Account+"/"+site.SiteName)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Errorf("Expected sites in order %v, got %v", expected, got)
+		}
+	}
+
+	t.Run("UpdateSites", func(t *testing.T) {
+		c := NewPantheonCollector(nil)
+		input := shuffled()
+		first := input[0].Account + "/" + input[0].SiteName
+		c.UpdateSites(input)
+		assertSorted(t, c, expected)
+		if input[0].Account+"/"+input[0].SiteName != first || first == expected[0] {
+			t.Error("Expected the caller's slice to be left unsorted")
+		}
+	})
+
+	t.Run("MergeSites", func(t *testing.T) {
+		c := NewPantheonCollector(nil)
+		c.MergeSites(shuffled())
+		assertSorted(t, c, expected)
+	})
+
+	t.Run("UpsertSite", func(t *testing.T) {
+		c := NewPantheonCollector(nil)
+		c.UpdateSites(shuffled())
+		c.UpsertSite(pantheon.SiteMetrics{SiteName: "site5", Account: "b@example.com"})
+		withNew := append(append(append([]string(nil), expected[:10]...), "b@example.com/site5"), expected[10:]...)
+		assertSorted(t, c, withNew)
+	})
+}
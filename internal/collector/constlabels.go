@@ -0,0 +1,59 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// labelNamePattern matches valid Prometheus label names
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ParseConstLabels parses a comma-separated list of key=value pairs into
+// constant labels. Keys must be valid Prometheus label names that don't start
+// with "__", values must be non-empty, and each key may appear only once.
+func ParseConstLabels(value string) (prometheus.Labels, error) {
+	labels := prometheus.Labels{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, labelValue, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		labelValue = strings.TrimSpace(labelValue)
+		if !ok || labelValue == "" {
+			return nil, fmt.Errorf("invalid label %q: expected key=value", pair)
+		}
+		if !labelNamePattern.MatchString(key) || strings.HasPrefix(key, "__") {
+			return nil, fmt.Errorf("invalid label name %q", key)
+		}
+		if _, exists := labels[key]; exists {
+			return nil, fmt.Errorf("duplicate label %q", key)
+		}
+		labels[key] = labelValue
+	}
+	return labels, nil
+}
+
+// SetConstLabels adds the given labels, with fixed values, to every per-site
+// metric, including the legacy metrics. Labels may not reuse the name of a
+// per-site or legacy label. It must be called after SetTagLabels and
+// SetEnvironmentLabel, and before the collector is registered.
+func (c *PantheonCollector) SetConstLabels(labels prometheus.Labels) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reserved := append(append([]string{}, c.labelNames...), siteInfoLabels...)
+	for _, name := range append(reserved, legacyLabels...) {
+		if _, ok := labels[name]; ok {
+			return fmt.Errorf("label %q is already used by per-site metrics", name)
+		}
+	}
+	c.constLabels = labels
+	c.setDescs(c.labelNames)
+	return nil
+}
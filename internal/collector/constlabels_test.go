@@ -0,0 +1,87 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestParseConstLabels(t *testing.T) {
+	labels, err := ParseConstLabels("region=us, cluster=prod")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(labels) != 2 || labels["region"] != "us" || labels["cluster"] != "prod" {
+		t.Errorf("Expected region and cluster labels, got %v", labels)
+	}
+
+	labels, err = ParseConstLabels("")
+	if err != nil || len(labels) != 0 {
+		t.Errorf("Expected no labels for an empty value, got %v (err %v)", labels, err)
+	}
+
+	for _, value := range []string{"region", "region=", "=us", "1region=us", "__name__=x", "re-gion=us", "region=us,region=eu"} {
+		if _, err := ParseConstLabels(value); err == nil {
+			t.Errorf("Expected %q to be invalid", value)
+		}
+	}
+}
+
+func TestSetConstLabelsRejectsSiteLabels(t *testing.T) {
+	c := NewPantheonCollector(nil)
+	c.SetTagLabels([]string{"team"})
+	for _, name := range []string{"account", "owner", "framework", "tag_team", "name"} {
+		if err := c.SetConstLabels(prometheus.Labels{name: "x"}); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestCollectConstLabels(t *testing.T) {
+	c := NewPantheonCollector([]pantheon.SiteMetrics{
+		{
+			SiteName:    "site1",
+			Label:       "site1",
+			PlanName:    "Basic",
+			Account:     "account1",
+			MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 10, CacheHitRatio: "50%"}},
+		},
+	})
+	if err := c.SetConstLabels(prometheus.Labels{"region": "us", "cluster": "prod"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// A refreshed site makes the collector export its refresh skew too
+	c.UpdateSiteMetrics("account1", "site1", map[string]pantheon.MetricData{"1762732800": {Visits: 10, CacheHitRatio: "50%"}})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	registry.MustRegister(NewLegacyCollector(c))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	if len(families) == 0 {
+		t.Fatal("Expected metrics to be gathered")
+	}
+
+	skewFound := false
+	for _, mf := range families {
+		if mf.GetName() == "pantheon_exporter_refresh_skew_seconds" {
+			skewFound = true
+		}
+		for _, m := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, lp := range m.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+			if labels["region"] != "us" || labels["cluster"] != "prod" {
+				t.Errorf("Expected constant labels on %s, got %v", mf.GetName(), labels)
+			}
+		}
+	}
+	if !skewFound {
+		t.Error("Expected pantheon_exporter_refresh_skew_seconds to be checked for constant labels")
+	}
+}
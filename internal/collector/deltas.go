@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// secondsPerDay is the spacing required between two samples to compute a daily delta
+const secondsPerDay = 24 * 60 * 60
+
+// dailyDelta holds the change in each counter between two consecutive daily samples
+type dailyDelta struct {
+	timestamp   int64 // Timestamp of the later sample
+	visits      int
+	pagesServed int
+	cacheHits   int
+	cacheMisses int
+}
+
+// dailyDeltas returns the day-over-day change between each pair of samples exactly
+// one day apart, ordered by timestamp. Pairs separated by a missing day are skipped.
+func dailyDeltas(site pantheon.SiteMetrics) []dailyDelta {
+	timestamps := make([]int64, 0, len(site.MetricsData))
+	byTimestamp := make(map[int64]pantheon.MetricData, len(site.MetricsData))
+	for timestampStr, data := range site.MetricsData {
+		timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		timestamps = append(timestamps, timestamp)
+		byTimestamp[timestamp] = data
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+
+	var deltas []dailyDelta
+	for i := 1; i < len(timestamps); i++ {
+		if timestamps[i]-timestamps[i-1] != secondsPerDay {
+			continue
+		}
+		prev, cur := byTimestamp[timestamps[i-1]], byTimestamp[timestamps[i]]
+		deltas = append(deltas, dailyDelta{
+			timestamp:   timestamps[i],
+			visits:      cur.Visits - prev.Visits,
+			pagesServed: cur.PagesServed - prev.PagesServed,
+			cacheHits:   cur.CacheHits - prev.CacheHits,
+			cacheMisses: cur.CacheMisses - prev.CacheMisses,
+		})
+	}
+	return deltas
+}
+
+// SetDailyDeltas enables exporting the day-over-day change in each counter,
+// computed from consecutive daily samples. It must be called before the
+// collector is registered.
+func (c *PantheonCollector) SetDailyDeltas(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dailyDeltas = enabled
+}
+
+// collectDailyDeltas emits each daily delta for a site at the later sample's timestamp.
+// With opts.noTimestamps, only the latest delta is emitted, without a timestamp;
+// otherwise deltas before opts.since are skipped. Negative deltas are dropped,
+// since a counter decreasing means the samples aren't comparable.
+func (c *PantheonCollector) collectDailyDeltas(ch chan<- prometheus.Metric, opts sampleOptions, site pantheon.SiteMetrics, labelValues ...string) {
+	deltas := dailyDeltas(site)
+	if opts.noTimestamps && len(deltas) > 1 {
+		// Several deltas without timestamps would be duplicate series
+		deltas = deltas[len(deltas)-1:]
+	}
+	for _, delta := range deltas {
+		ts := time.Unix(delta.timestamp, 0)
+		if !opts.noTimestamps && ts.Before(opts.since) {
+			continue
+		}
+		for _, v := range []sampleValue{
+			{c.visitsDaily, float64(delta.visits)},
+			{c.pagesServedDaily, float64(delta.pagesServed)},
+			{c.cacheHitsDaily, float64(delta.cacheHits)},
+			{c.cacheMissesDaily, float64(delta.cacheMisses)},
+		} {
+			if v.desc == nil || v.value < 0 {
+				continue
+			}
+			metric := prometheus.MustNewConstMetric(
+				v.desc,
+				prometheus.GaugeValue,
+				v.value,
+				labelValues...,
+			)
+			if !opts.noTimestamps {
+				metric = prometheus.NewMetricWithTimestamp(ts, metric)
+			}
+			ch <- metric
+		}
+	}
+}
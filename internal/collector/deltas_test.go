@@ -0,0 +1,130 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestDailyDeltas(t *testing.T) {
+	site := pantheon.SiteMetrics{
+		MetricsData: map[string]pantheon.MetricData{
+			"1762732800": {Visits: 100, PagesServed: 500, CacheHits: 300, CacheMisses: 200},
+			"1762819200": {Visits: 150, PagesServed: 650, CacheHits: 400, CacheMisses: 250},
+			"1762905600": {Visits: 170, PagesServed: 700, CacheHits: 420, CacheMisses: 280},
+		},
+	}
+
+	deltas := dailyDeltas(site)
+	if len(deltas) != 2 {
+		t.Fatalf("Expected 2 deltas for a three-day series, got %d", len(deltas))
+	}
+
+	expected := []dailyDelta{
+		{timestamp: 1762819200, visits: 50, pagesServed: 150, cacheHits: 100, cacheMisses: 50},
+		{timestamp: 1762905600, visits: 20, pagesServed: 50, cacheHits: 20, cacheMisses: 30},
+	}
+	for i, want := range expected {
+		if deltas[i] != want {
+			t.Errorf("Delta %d: expected %+v, got %+v", i, want, deltas[i])
+		}
+	}
+}
+
+func TestDailyDeltasSkipsMissingDays(t *testing.T) {
+	site := pantheon.SiteMetrics{
+		MetricsData: map[string]pantheon.MetricData{
+			"1762732800": {Visits: 100},
+			// 1762819200 is missing
+			"1762905600": {Visits: 170},
+			"1762992000": {Visits: 200},
+			"invalid":    {Visits: 999},
+		},
+	}
+
+	deltas := dailyDeltas(site)
+	if len(deltas) != 1 {
+		t.Fatalf("Expected only the delta between adjacent days, got %d", len(deltas))
+	}
+	if deltas[0].timestamp != 1762992000 || deltas[0].visits != 30 {
+		t.Errorf("Expected 30 visits at 1762992000, got %+v", deltas[0])
+	}
+}
+
+func TestCollectDailyDeltas(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName: "site1",
+			Label:    "site1",
+			PlanName: "Basic",
+			Account:  "account1",
+			MetricsData: map[string]pantheon.MetricData{
+				"1762732800": {Visits: 100, PagesServed: 500, CacheHits: 300, CacheMisses: 200},
+				"1762819200": {Visits: 150, PagesServed: 650, CacheHits: 400, CacheMisses: 250},
+				// Visits decreased, so only the other counters get a delta
+				"1762905600": {Visits: 120, PagesServed: 700, CacheHits: 420, CacheMisses: 280},
+			},
+		},
+	}
+
+	c := NewPantheonCollector(sites)
+	c.SetDailyDeltas(true)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	counts := map[string]int{}
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			counts[mf.GetName()]++
+			if mf.GetName() == "pantheon_visits_daily" {
+				if m.GetTimestampMs() != 1762819200000 {
+					t.Errorf("Expected visits delta at the later sample's timestamp, got %d", m.GetTimestampMs())
+				}
+				if m.GetGauge().GetValue() != 50 {
+					t.Errorf("Expected visits delta of 50, got %v", m.GetGauge().GetValue())
+				}
+			}
+		}
+	}
+
+	if counts["pantheon_visits_daily"] != 1 {
+		t.Errorf("Expected the negative visits delta to be dropped, got %d series", counts["pantheon_visits_daily"])
+	}
+	for _, name := range []string{"pantheon_pages_served_daily", "pantheon_cache_hits_daily", "pantheon_cache_misses_daily"} {
+		if counts[name] != 2 {
+			t.Errorf("Expected 2 %s samples, got %d", name, counts[name])
+		}
+	}
+}
+
+func TestCollectDailyDeltasDisabled(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName: "site1",
+			Account:  "account1",
+			MetricsData: map[string]pantheon.MetricData{
+				"1762732800": {Visits: 100},
+				"1762819200": {Visits: 150},
+			},
+		},
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewPantheonCollector(sites))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	for _, mf := range families {
+		if mf.GetName() == "pantheon_visits_daily" {
+			t.Error("Expected no daily deltas unless enabled")
+		}
+	}
+}
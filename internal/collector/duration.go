@@ -0,0 +1,29 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDaysOrDuration parses a length of time given as a number of days such
+// as "7d" or a Go duration such as "36h", as used by -emitSince and
+// -staleAfter. An empty value is 0, which those flags treat as disabled.
+func ParseDaysOrDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid duration %q: expected a positive number of days such as 7d", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid duration %q: expected a number of days such as 7d or a duration such as 36h", value)
+	}
+	return duration, nil
+}
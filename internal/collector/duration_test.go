@@ -0,0 +1,35 @@
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDaysOrDuration(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"7d", 7 * 24 * time.Hour},
+		{"1d", 24 * time.Hour},
+		{"36h", 36 * time.Hour},
+		{"90m", 90 * time.Minute},
+	}
+	for _, tt := range tests {
+		got, err := ParseDaysOrDuration(tt.value)
+		if err != nil {
+			t.Errorf("ParseDaysOrDuration(%q) returned error: %v", tt.value, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("ParseDaysOrDuration(%q) = %v, expected %v", tt.value, got, tt.expected)
+		}
+	}
+
+	for _, value := range []string{"d", "0d", "-3d", "seven", "7days", "0s", "-1h"} {
+		if _, err := ParseDaysOrDuration(value); err == nil {
+			t.Errorf("Expected %q to be invalid", value)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// EnvironmentUp is the outcome of the last metrics fetch from one environment of a site
+type EnvironmentUp struct {
+	Account     string
+	Site        string
+	Environment string
+	Up          bool
+}
+
+// EnvironmentStatusProvider exposes whether the last metrics fetch from each
+// environment of each site succeeded.
+type EnvironmentStatusProvider interface {
+	EnvironmentsUp() []EnvironmentUp
+}
+
+// EnvironmentCollector collects whether each site environment's metrics can be fetched
+type EnvironmentCollector struct {
+	source EnvironmentStatusProvider
+
+	up *prometheus.Desc
+}
+
+// NewEnvironmentCollector creates a new environment status collector
+func NewEnvironmentCollector(source EnvironmentStatusProvider) *EnvironmentCollector {
+	return &EnvironmentCollector{
+		source: source,
+		up: prometheus.NewDesc(
+			"pantheon_environment_up",
+			"Whether the last metrics fetch from a site's environment succeeded (1 = up, 0 = down)",
+			[]string{"site_id", "account", "environment"},
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *EnvironmentCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.up
+}
+
+// Collect implements prometheus.Collector
+func (c *EnvironmentCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, env := range c.source.EnvironmentsUp() {
+		value := 0.0
+		if env.Up {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.up,
+			prometheus.GaugeValue,
+			value,
+			env.Site,
+			env.Account,
+			env.Environment,
+		)
+	}
+}
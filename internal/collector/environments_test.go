@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stubEnvironments is an EnvironmentStatusProvider with fixed values
+type stubEnvironments []EnvironmentUp
+
+func (s stubEnvironments) EnvironmentsUp() []EnvironmentUp {
+	return s
+}
+
+func TestEnvironmentCollector(t *testing.T) {
+	source := stubEnvironments{
+		{Account: "a@example.com", Site: "site1", Environment: "live", Up: true},
+		{Account: "a@example.com", Site: "site1", Environment: "test", Up: false},
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewEnvironmentCollector(source))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	if len(families) != 1 || families[0].GetName() != "pantheon_environment_up" {
+		t.Fatalf("Expected pantheon_environment_up metric, got %v", families)
+	}
+
+	up := map[string]float64{}
+	for _, m := range families[0].GetMetric() {
+		labels := map[string]string{}
+		for _, label := range m.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		up[labels["account"]+"/"+labels["site_id"]+"/"+labels["environment"]] = m.GetGauge().GetValue()
+	}
+	if len(up) != 2 || up["a@example.com/site1/live"] != 1 || up["a@example.com/site1/test"] != 0 {
+		t.Errorf("Expected live up and test down for site1, got %v", up)
+	}
+}
@@ -0,0 +1,78 @@
+package collector
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Metric family names accepted by SetMetrics
+const (
+	MetricVisits         = "visits"
+	MetricPagesServed    = "pages_served"
+	MetricCacheHits      = "cache_hits"
+	MetricCacheMisses    = "cache_misses"
+	MetricCacheHitRatio  = "cache_hit_ratio"
+	MetricCacheMissRatio = "cache_miss_ratio"
+)
+
+// metricFamilies lists the per-sample metric families that can be selected
+var metricFamilies = []string{MetricVisits, MetricPagesServed, MetricCacheHits, MetricCacheMisses, MetricCacheHitRatio, MetricCacheMissRatio}
+
+// SetMetrics limits the per-sample metric families that are described and
+// collected to the given names. An empty list enables every family. Daily
+// deltas follow the family they are computed from. It must be called before
+// the collector is registered.
+func (c *PantheonCollector) SetMetrics(names []string) error {
+	enabled := make(map[string]bool, len(names))
+	for _, name := range names {
+		if !isMetricFamily(name) {
+			return fmt.Errorf("unknown metric %q, must be one of: %s", name, strings.Join(metricFamilies, ", "))
+		}
+		enabled[name] = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.enabledMetrics = nil
+	if len(enabled) > 0 {
+		c.enabledMetrics = enabled
+	}
+	c.setDescs(c.labelNames)
+	return nil
+}
+
+// isMetricFamily reports whether name is a selectable metric family
+func isMetricFamily(name string) bool {
+	for _, family := range metricFamilies {
+		if name == family {
+			return true
+		}
+	}
+	return false
+}
+
+// disableUnselectedMetrics clears the descriptors of metric families that
+// weren't selected, so they are neither described nor collected.
+func (c *PantheonCollector) disableUnselectedMetrics() {
+	if c.enabledMetrics == nil {
+		return
+	}
+	if !c.enabledMetrics[MetricVisits] {
+		c.visits, c.visitsDaily = nil, nil
+	}
+	if !c.enabledMetrics[MetricPagesServed] {
+		c.pagesServed, c.pagesServedDaily = nil, nil
+	}
+	if !c.enabledMetrics[MetricCacheHits] {
+		c.cacheHits, c.cacheHitsDaily = nil, nil
+	}
+	if !c.enabledMetrics[MetricCacheMisses] {
+		c.cacheMisses, c.cacheMissesDaily = nil, nil
+	}
+	if !c.enabledMetrics[MetricCacheHitRatio] {
+		c.cacheHitRatio = nil
+	}
+	if !c.enabledMetrics[MetricCacheMissRatio] {
+		c.cacheMissRatio = nil
+	}
+}
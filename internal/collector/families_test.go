@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// describedNames returns the fully-qualified names of the descriptors a collector describes
+func describedNames(c prometheus.Collector) map[string]bool {
+	ch := make(chan *prometheus.Desc, 20)
+	c.Describe(ch)
+	close(ch)
+
+	// Desc has no name accessor, so keep its string form
+	names := map[string]bool{}
+	for desc := range ch {
+		names[desc.String()] = true
+	}
+	return names
+}
+
+func TestSetMetricsDescribe(t *testing.T) {
+	c := NewPantheonCollector(nil)
+	if err := c.SetMetrics([]string{MetricVisits, MetricCacheHitRatio}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	described := describedNames(c)
+	expected := []string{"pantheon_visits_total", "pantheon_cache_hit_ratio", "pantheon_cache_total_requests", "pantheon_site_age_days", "pantheon_site_info", "pantheon_site_samples", "pantheon_site_last_refresh_timestamp_seconds", "pantheon_exporter_refresh_skew_seconds"}
+	if len(described) != len(expected) {
+		t.Errorf("Expected %d descriptors, got %d", len(expected), len(described))
+	}
+	for _, name := range expected {
+		if !containsDesc(described, name) {
+			t.Errorf("Expected %s to be described", name)
+		}
+	}
+	for _, name := range []string{"pantheon_pages_served_total", "pantheon_cache_hits_total", "pantheon_cache_misses_total"} {
+		if containsDesc(described, name) {
+			t.Errorf("Expected %s not to be described", name)
+		}
+	}
+}
+
+func TestSetMetricsCollect(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName: "site1",
+			Account:  "account1",
+			MetricsData: map[string]pantheon.MetricData{
+				"1762732800": {Visits: 10, PagesServed: 20, CacheHits: 5, CacheMisses: 15, CacheHitRatio: "25%"},
+			},
+		},
+	}
+
+	c := NewPantheonCollector(sites)
+	if err := c.SetMetrics([]string{MetricVisits}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Later descriptor rebuilds keep the selection
+	c.SetTagLabels([]string{"team"})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	for _, mf := range families {
+		switch mf.GetName() {
+		case "pantheon_visits_total", "pantheon_cache_total_requests", "pantheon_site_info", "pantheon_site_samples":
+		default:
+			t.Errorf("Unexpected metric family %s", mf.GetName())
+		}
+	}
+}
+
+func TestSetMetricsInvalid(t *testing.T) {
+	c := NewPantheonCollector(nil)
+	if err := c.SetMetrics([]string{MetricVisits, "bandwidth"}); err == nil {
+		t.Error("Expected error for unknown metric name")
+	}
+}
+
+// containsDesc reports whether any described descriptor has the given metric name
+func containsDesc(described map[string]bool, name string) bool {
+	for desc := range described {
+		if strings.Contains(desc, `fqName: "`+name+`"`) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// FrozenSiteProvider exposes how many monitored sites are frozen per account.
+type FrozenSiteProvider interface {
+	FrozenSiteCounts() map[string]int
+}
+
+// FrozenSiteCollector collects the number of frozen sites per account
+type FrozenSiteCollector struct {
+	source FrozenSiteProvider
+
+	sitesFrozen *prometheus.Desc
+}
+
+// NewFrozenSiteCollector creates a new frozen site metrics collector
+func NewFrozenSiteCollector(source FrozenSiteProvider) *FrozenSiteCollector {
+	return &FrozenSiteCollector{
+		source: source,
+		sitesFrozen: prometheus.NewDesc(
+			"pantheon_sites_frozen_total",
+			"Number of an account's monitored sites that are frozen",
+			[]string{"account"},
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *FrozenSiteCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sitesFrozen
+}
+
+// Collect implements prometheus.Collector
+func (c *FrozenSiteCollector) Collect(ch chan<- prometheus.Metric) {
+	for account, count := range c.source.FrozenSiteCounts() {
+		ch <- prometheus.MustNewConstMetric(
+			c.sitesFrozen,
+			prometheus.GaugeValue,
+			float64(count),
+			account,
+		)
+	}
+}
+
+// FrozenSiteCounts returns the number of frozen sites for each account with
+// monitored sites, including accounts with none frozen (thread-safe). Sites
+// hidden by -minVisits are counted.
+func (c *PantheonCollector) FrozenSiteCounts() map[string]int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, site := range c.sites {
+		if site.Frozen {
+			counts[site.Account]++
+		} else if _, ok := counts[site.Account]; !ok {
+			counts[site.Account] = 0
+		}
+	}
+	return counts
+}
@@ -0,0 +1,52 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestFrozenSiteCollector(t *testing.T) {
+	c := NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "frozen1", Account: "a@example.com", Frozen: true},
+		{SiteName: "frozen2", Account: "a@example.com", Frozen: true},
+		{SiteName: "active1", Account: "a@example.com"},
+		{SiteName: "active2", Account: "b@example.com"},
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewFrozenSiteCollector(c))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	if len(families) != 1 || families[0].GetName() != "pantheon_sites_frozen_total" {
+		t.Fatalf("Expected only pantheon_sites_frozen_total, got %v", families)
+	}
+
+	expected := map[string]float64{"a@example.com": 2, "b@example.com": 0}
+	if len(families[0].GetMetric()) != len(expected) {
+		t.Fatalf("Expected %d series, got %d", len(expected), len(families[0].GetMetric()))
+	}
+	for _, m := range families[0].GetMetric() {
+		account := m.GetLabel()[0].GetValue()
+		if got := m.GetGauge().GetValue(); got != expected[account] {
+			t.Errorf("Expected %v frozen sites for %s, got %v", expected[account], account, got)
+		}
+	}
+}
+
+func TestFrozenSiteCollectorNoSites(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewFrozenSiteCollector(NewPantheonCollector(nil)))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	if len(families) != 0 {
+		t.Errorf("Expected no metrics without sites, got %d families", len(families))
+	}
+}
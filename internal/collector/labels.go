@@ -0,0 +1,123 @@
+package collector
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+)
+
+// siteLabelNames are the labels attached to every per-site metric
+var siteLabelNames = []string{"site_id", "site_name", "plan", "plan_slug", "account"}
+
+// siteInfoLabels are the labels pantheon_site_info carries after the per-site labels
+var siteInfoLabels = []string{"owner", "framework", "source"}
+
+// siteLabelValues returns the values for siteLabelNames, followed by the
+// environment the site's metrics came from when defaultEnv is set, then the
+// site's value for each tag key (empty if the site lacks the tag)
+func siteLabelValues(site pantheon.SiteMetrics, defaultEnv string, tagKeys []string) []string {
+	plan := sanitizeLabelValue(site.PlanName)
+	values := []string{site.SiteName, site.Label, plan, planSlug(plan), site.Account}
+	if defaultEnv != "" {
+		environment := site.Environment
+		if environment == "" {
+			environment = defaultEnv
+		}
+		values = append(values, environment)
+	}
+	for _, key := range tagKeys {
+		values = append(values, sanitizeLabelValue(site.Tags[key]))
+	}
+	return values
+}
+
+// labelTruncationMarker ends label values shortened by truncateLabelValues
+const labelTruncationMarker = "..."
+
+// identifyingLabels are never truncated, as two sites whose identifying labels
+// share a prefix would otherwise export the same series, failing the scrape
+var identifyingLabels = map[string]bool{"site_id": true, "account": true, "name": true}
+
+// truncateLabelValues shortens, in place, each value longer than maxLength
+// characters to maxLength characters ending in labelTruncationMarker, except
+// for the values of identifyingLabels. names holds the label name of each
+// value. Values are cut on character boundaries so they stay valid UTF-8. A
+// maxLength of 0 leaves values unchanged.
+func truncateLabelValues(names, values []string, maxLength int) []string {
+	if maxLength <= 0 {
+		return values
+	}
+	for i, value := range values {
+		if identifyingLabels[names[i]] || utf8.RuneCountInString(value) <= maxLength {
+			continue
+		}
+		keep := max(maxLength-len(labelTruncationMarker), 0)
+		runes := []rune(value)
+		values[i] = string(runes[:keep]) + labelTruncationMarker[:min(len(labelTruncationMarker), maxLength)]
+	}
+	return values
+}
+
+// extendedLabelNames returns siteLabelNames followed by the environment label
+// when withEnvironment is set, then a label for each tag key
+func extendedLabelNames(withEnvironment bool, tagKeys []string) []string {
+	labelNames := append([]string{}, siteLabelNames...)
+	if withEnvironment {
+		labelNames = append(labelNames, "environment")
+	}
+	for _, key := range tagKeys {
+		labelNames = append(labelNames, tagLabelName(key))
+	}
+	return labelNames
+}
+
+// tagLabelName returns the label name for a site tag key, e.g. "cost-center"
+// becomes "tag_cost_center". Characters not allowed in label names are replaced
+// with underscores.
+func tagLabelName(key string) string {
+	var b strings.Builder
+	b.WriteString("tag_")
+	for _, r := range key {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteByte('_')
+	}
+	return b.String()
+}
+
+// sanitizeLabelValue replaces invalid UTF-8 and control characters with spaces,
+// then collapses runs of whitespace and trims the result.
+func sanitizeLabelValue(value string) string {
+	value = strings.ToValidUTF8(value, " ")
+	value = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, value)
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// planSlug returns a stable, lowercase form of a plan name with runs of
+// non-alphanumeric characters replaced by underscores,
+// e.g. "Performance Small" becomes "performance_small".
+func planSlug(plan string) string {
+	var b strings.Builder
+	pendingSeparator := false
+	for _, r := range strings.ToLower(plan) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if pendingSeparator && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			pendingSeparator = false
+			b.WriteRune(r)
+			continue
+		}
+		pendingSeparator = true
+	}
+	return b.String()
+}
@@ -0,0 +1,354 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestSanitizeLabelValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "Performance Small", expected: "Performance Small"},
+		{input: "  Performance   Small ", expected: "Performance Small"},
+		{input: "Elite\tPlan\n", expected: "Elite Plan"},
+		{input: "Basic\x00Plan", expected: "Basic Plan"},
+		{input: "Bad\xffByte", expected: "Bad Byte"},
+		{input: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		if got := sanitizeLabelValue(tt.input); got != tt.expected {
+			t.Errorf("sanitizeLabelValue(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestPlanSlug(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "Performance Small", expected: "performance_small"},
+		{input: "BASIC", expected: "basic"},
+		{input: "Performance XLarge 2X", expected: "performance_xlarge_2x"},
+		{input: "Elite - Plus (Annual)", expected: "elite_plus_annual"},
+		{input: " Sandbox ", expected: "sandbox"},
+		{input: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		if got := planSlug(tt.input); got != tt.expected {
+			t.Errorf("planSlug(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestCollectPlanLabels(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName:    "site1",
+			Label:       "site1",
+			PlanName:    "Performance  Small\n",
+			Account:     "account1",
+			MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 10}},
+		},
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewPantheonCollector(sites))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	for _, mf := range families {
+		if mf.GetName() != "pantheon_visits_total" {
+			continue
+		}
+		labels := map[string]string{}
+		for _, lp := range mf.GetMetric()[0].GetLabel() {
+			labels[lp.GetName()] = lp.GetValue()
+		}
+		if labels["plan"] != "Performance Small" {
+			t.Errorf("Expected sanitized plan 'Performance Small', got %q", labels["plan"])
+		}
+		if labels["plan_slug"] != "performance_small" {
+			t.Errorf("Expected plan_slug 'performance_small', got %q", labels["plan_slug"])
+		}
+		return
+	}
+	t.Error("Expected pantheon_visits_total metric")
+}
+
+func TestTruncateLabelValues(t *testing.T) {
+	tests := []struct {
+		input     string
+		maxLength int
+		expected  string
+	}{
+		{input: "short-site", maxLength: 20, expected: "short-site"},
+		{input: "exactly-ten", maxLength: 11, expected: "exactly-ten"},
+		{input: "a-very-long-site-name", maxLength: 10, expected: "a-very-..."},
+		{input: "a-very-long-site-name", maxLength: 0, expected: "a-very-long-site-name"},
+		{input: "héllo wörld", maxLength: 8, expected: "héllo..."},
+		{input: "abcdef", maxLength: 2, expected: ".."},
+	}
+
+	for _, tt := range tests {
+		got := truncateLabelValues([]string{"site_name"}, []string{tt.input}, tt.maxLength)[0]
+		if got != tt.expected {
+			t.Errorf("truncateLabelValues(%q, %d) = %q, expected %q", tt.input, tt.maxLength, got, tt.expected)
+		}
+	}
+}
+
+func TestCollectMaxLabelLength(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName:    "site1",
+			Label:       "An extremely long site label that goes on and on",
+			PlanName:    "Basic",
+			Account:     "account1",
+			MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 10}},
+		},
+	}
+	c := NewPantheonCollector(sites)
+	c.SetMaxLabelLength(16)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	for _, mf := range families {
+		if mf.GetName() != "pantheon_visits_total" && mf.GetName() != "pantheon_site_info" {
+			continue
+		}
+		labels := map[string]string{}
+		for _, lp := range mf.GetMetric()[0].GetLabel() {
+			labels[lp.GetName()] = lp.GetValue()
+		}
+		if labels["site_name"] != "An extremely ..." {
+			t.Errorf("Expected %s site_name truncated to 'An extremely ...', got %q", mf.GetName(), labels["site_name"])
+		}
+		if labels["site_id"] != "site1" || labels["plan"] != "Basic" {
+			t.Errorf("Expected %s labels under the cap unchanged, got %v", mf.GetName(), labels)
+		}
+	}
+}
+
+func TestCollectMaxLabelLengthKeepsSitesDistinct(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName:    "acme-prod-eu",
+			Label:       "acme-prod-eu",
+			PlanName:    "Basic",
+			Account:     "account-with-a-long-name@example.com",
+			MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 10}},
+		},
+		{
+			SiteName:    "acme-prod-us",
+			Label:       "acme-prod-us",
+			PlanName:    "Basic",
+			Account:     "account-with-a-long-name@example.com",
+			MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 20}},
+		},
+	}
+	c := NewPantheonCollector(sites)
+	c.SetMaxLabelLength(8)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c, NewLegacyCollector(c))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Expected sites sharing a prefix to export distinct series, got %v", err)
+	}
+
+	for _, mf := range families {
+		if mf.GetName() != "pantheon_visits_total" && mf.GetName() != "pantheon_visits" {
+			continue
+		}
+		siteIDs := map[string]bool{}
+		for _, m := range mf.GetMetric() {
+			for _, lp := range m.GetLabel() {
+				switch lp.GetName() {
+				case "site_id", "name":
+					siteIDs[lp.GetValue()] = true
+				case "account":
+					if lp.GetValue() != "account-with-a-long-name@example.com" {
+						t.Errorf("Expected %s account to be left whole, got %q", mf.GetName(), lp.GetValue())
+					}
+				}
+			}
+		}
+		if !siteIDs["acme-prod-eu"] || !siteIDs["acme-prod-us"] {
+			t.Errorf("Expected %s series for both sites, got %v", mf.GetName(), siteIDs)
+		}
+	}
+}
+
+func TestTagLabelName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "team", expected: "tag_team"},
+		{input: "cost-center", expected: "tag_cost_center"},
+		{input: "Cost Center", expected: "tag_Cost_Center"},
+		{input: "env_2", expected: "tag_env_2"},
+	}
+
+	for _, tt := range tests {
+		if got := tagLabelName(tt.input); got != tt.expected {
+			t.Errorf("tagLabelName(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestCollectTagLabels(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName:    "site1",
+			Label:       "site1",
+			PlanName:    "Basic",
+			Account:     "account1",
+			Tags:        map[string]string{"team": "payments", "cost-center": "cc-42"},
+			MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 10}},
+		},
+		{
+			SiteName:    "site2",
+			Label:       "site2",
+			PlanName:    "Basic",
+			Account:     "account1",
+			MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 20}},
+		},
+	}
+
+	c := NewPantheonCollector(sites)
+	c.SetTagLabels([]string{"team", "cost-center"})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	found := 0
+	for _, mf := range families {
+		if mf.GetName() != "pantheon_visits_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, lp := range m.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+			if _, ok := labels["tag_team"]; !ok {
+				t.Errorf("Expected tag_team label on %s", labels["site_id"])
+			}
+			switch labels["site_id"] {
+			case "site1":
+				found++
+				if labels["tag_team"] != "payments" || labels["tag_cost_center"] != "cc-42" {
+					t.Errorf("Expected promoted tag values, got %v", labels)
+				}
+			case "site2":
+				found++
+				// Missing tags become empty strings
+				if labels["tag_team"] != "" || labels["tag_cost_center"] != "" {
+					t.Errorf("Expected empty tag values for untagged site, got %v", labels)
+				}
+			}
+		}
+	}
+	if found != 2 {
+		t.Errorf("Expected visits for 2 sites, got %d", found)
+	}
+}
+
+func TestSetTagLabelsRejectsCollisions(t *testing.T) {
+	for _, keys := range [][]string{
+		{"cost-center", "cost_center"},
+		{"team", "team"},
+	} {
+		c := NewPantheonCollector(nil)
+		if err := c.SetTagLabels(keys); err == nil {
+			t.Errorf("Expected tag keys %v to be rejected", keys)
+		}
+		// The rejected keys aren't applied, so the collector still registers
+		if err := prometheus.NewRegistry().Register(c); err != nil {
+			t.Errorf("Expected the collector to register after rejecting %v, got %v", keys, err)
+		}
+	}
+
+	c := NewPantheonCollector(nil)
+	if err := c.SetTagLabels([]string{"team", "cost-center"}); err != nil {
+		t.Errorf("Expected distinct tag keys to be accepted, got %v", err)
+	}
+}
+
+func TestCollectEnvironmentLabel(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName:    "launched",
+			Label:       "launched",
+			PlanName:    "Basic",
+			Account:     "account1",
+			MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 10}},
+		},
+		{
+			SiteName:    "prelaunch",
+			Label:       "prelaunch",
+			PlanName:    "Basic",
+			Account:     "account1",
+			MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 20}},
+		},
+	}
+
+	c := NewPantheonCollector(sites)
+	c.SetEnvironmentLabel("live")
+	c.SetTagLabels([]string{"team"})
+	c.SetSiteEnvironment("account1", "prelaunch", "dev")
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	environments := map[string]string{}
+	for _, mf := range families {
+		if mf.GetName() != "pantheon_visits_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, lp := range m.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+			if _, ok := labels["tag_team"]; !ok {
+				t.Errorf("Expected tag_team label alongside environment on %s", labels["site_id"])
+			}
+			environments[labels["site_id"]] = labels["environment"]
+		}
+	}
+
+	// Sites without a recorded environment report the default
+	if environments["launched"] != "live" {
+		t.Errorf("Expected launched site in live, got %q", environments["launched"])
+	}
+	if environments["prelaunch"] != "dev" {
+		t.Errorf("Expected prelaunch site in dev, got %q", environments["prelaunch"])
+	}
+}
@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// apiRequestDurationBuckets covers API calls from sub-second to tens of seconds
+var apiRequestDurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// RequestDurationCollector records how long Pantheon API operations take
+type RequestDurationCollector struct {
+	duration *prometheus.HistogramVec
+}
+
+// NewRequestDurationCollector creates a new API request duration collector
+func NewRequestDurationCollector() *RequestDurationCollector {
+	return &RequestDurationCollector{
+		duration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "pantheon_api_request_duration_seconds",
+				Help:    "Duration of Pantheon API operations",
+				Buckets: apiRequestDurationBuckets,
+			},
+			[]string{"operation"},
+		),
+	}
+}
+
+// Observe records the duration of an API operation
+func (c *RequestDurationCollector) Observe(operation string, duration time.Duration) {
+	c.duration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+// Describe implements prometheus.Collector
+func (c *RequestDurationCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.duration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *RequestDurationCollector) Collect(ch chan<- prometheus.Metric) {
+	c.duration.Collect(ch)
+}
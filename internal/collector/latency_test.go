@@ -0,0 +1,42 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRequestDurationCollector(t *testing.T) {
+	c := NewRequestDurationCollector()
+	c.Observe("authenticate", 200*time.Millisecond)
+	c.Observe("fetch_metrics", 2*time.Second)
+	c.Observe("fetch_metrics", 4*time.Second)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	if len(families) != 1 || families[0].GetName() != "pantheon_api_request_duration_seconds" {
+		t.Fatalf("Expected pantheon_api_request_duration_seconds metric, got %v", families)
+	}
+
+	counts := map[string]uint64{}
+	sums := map[string]float64{}
+	for _, m := range families[0].GetMetric() {
+		operation := m.GetLabel()[0].GetValue()
+		counts[operation] = m.GetHistogram().GetSampleCount()
+		sums[operation] = m.GetHistogram().GetSampleSum()
+	}
+
+	if counts["authenticate"] != 1 || counts["fetch_metrics"] != 2 {
+		t.Errorf("Expected 1 authenticate and 2 fetch_metrics observations, got %v", counts)
+	}
+	if sums["fetch_metrics"] != 6 {
+		t.Errorf("Expected fetch_metrics duration sum of 6s, got %v", sums["fetch_metrics"])
+	}
+}
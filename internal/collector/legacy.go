@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// legacyLabels are the label names used by the original metric schema
+var legacyLabels = []string{"name", "label", "plan", "account"}
+
+// LegacyCollector emits site metrics under the original metric names and labels,
+// so dashboards can migrate to the current schema gradually. The cache hit ratio is
+// not included, since its legacy name is still used by the current schema.
+//
+// Deprecated: use the metrics emitted by PantheonCollector instead.
+type LegacyCollector struct {
+	source *PantheonCollector
+	descs  siteDescs
+}
+
+// NewLegacyCollector creates a collector emitting the legacy schema from the same
+// site data and constant labels as source.
+func NewLegacyCollector(source *PantheonCollector) *LegacyCollector {
+	return &LegacyCollector{
+		source: source,
+		descs: siteDescs{
+			visits: prometheus.NewDesc(
+				"pantheon_visits",
+				"DEPRECATED: use pantheon_visits_total. Number of visits",
+				legacyLabels,
+				source.constLabels,
+			),
+			pagesServed: prometheus.NewDesc(
+				"pantheon_pages_served",
+				"DEPRECATED: use pantheon_pages_served_total. Number of pages served",
+				legacyLabels,
+				source.constLabels,
+			),
+			cacheHits: prometheus.NewDesc(
+				"pantheon_cache_hits",
+				"DEPRECATED: use pantheon_cache_hits_total. Number of cache hits",
+				legacyLabels,
+				source.constLabels,
+			),
+			cacheMisses: prometheus.NewDesc(
+				"pantheon_cache_misses",
+				"DEPRECATED: use pantheon_cache_misses_total. Number of cache misses",
+				legacyLabels,
+				source.constLabels,
+			),
+		},
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *LegacyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.descs.visits
+	ch <- c.descs.pagesServed
+	ch <- c.descs.cacheHits
+	ch <- c.descs.cacheMisses
+}
+
+// Collect implements prometheus.Collector
+func (c *LegacyCollector) Collect(ch chan<- prometheus.Metric) {
+	state := c.source.snapshotCollectState()
+	for _, site := range state.sites {
+		if belowMinVisits(site, state.minVisits) {
+			continue
+		}
+		if stale, _ := siteStale(site, state.staleBefore); stale && state.dropStale {
+			continue
+		}
+		labelValues := truncateLabelValues(legacyLabels, []string{site.SiteName, site.Label, sanitizeLabelValue(site.PlanName), site.Account}, state.maxLabelLen)
+		c.source.collectSamples(ch, c.descs, state.samples, site, labelValues...)
+	}
+}
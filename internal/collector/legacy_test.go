@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestLegacyCollectorAlongsideCurrent(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName: "site1",
+			Label:    "Site One",
+			PlanName: "Basic",
+			Account:  "account1",
+			MetricsData: map[string]pantheon.MetricData{
+				"1762646400": {Visits: 100, PagesServed: 400, CacheHits: 40, CacheMisses: 360, CacheHitRatio: "10%"},
+				"1762732800": {Visits: 200, PagesServed: 800, CacheHits: 80, CacheMisses: 720, CacheHitRatio: "10%"},
+			},
+		},
+	}
+
+	current := NewPantheonCollector(sites)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(current)
+	registry.MustRegister(NewLegacyCollector(current))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	found := map[string]int{}
+	for _, mf := range families {
+		found[mf.GetName()] = len(mf.GetMetric())
+
+		if mf.GetName() == "pantheon_visits" {
+			if !strings.HasPrefix(mf.GetHelp(), "DEPRECATED") {
+				t.Errorf("Expected legacy help text to be marked deprecated, got %q", mf.GetHelp())
+			}
+			labels := map[string]string{}
+			for _, lp := range mf.GetMetric()[0].GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+			expected := map[string]string{"name": "site1", "label": "Site One", "plan": "Basic", "account": "account1"}
+			for name, value := range expected {
+				if labels[name] != value {
+					t.Errorf("Expected legacy label %s=%q, got %q", name, value, labels[name])
+				}
+			}
+		}
+	}
+
+	for _, name := range []string{
+		"pantheon_visits_total", "pantheon_pages_served_total", "pantheon_cache_hits_total", "pantheon_cache_misses_total", "pantheon_cache_hit_ratio",
+		"pantheon_visits", "pantheon_pages_served", "pantheon_cache_hits", "pantheon_cache_misses",
+	} {
+		if found[name] != 2 {
+			t.Errorf("Expected 2 samples for %s, got %d", name, found[name])
+		}
+	}
+}
+
+func TestLegacyCollectorHonorsMinVisits(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{
+			SiteName:    "idle",
+			Account:     "account1",
+			MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 1}},
+		},
+	}
+
+	current := NewPantheonCollector(sites)
+	current.SetMinVisits(10)
+
+	ch := make(chan prometheus.Metric, 10)
+	NewLegacyCollector(current).Collect(ch)
+	close(ch)
+
+	if len(ch) != 0 {
+		t.Errorf("Expected idle site to be skipped, got %d metrics", len(ch))
+	}
+}
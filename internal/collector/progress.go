@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// InitialCollectionProgressProvider exposes how far the initial metrics collection has got,
+// and whether it finished with few enough failures.
+type InitialCollectionProgressProvider interface {
+	InitialCollectionProgress() float64
+	InitialCollectionHealthy() (healthy, finished bool)
+}
+
+// InitialCollectionCollector collects the progress of the initial metrics collection
+type InitialCollectionCollector struct {
+	source InitialCollectionProgressProvider
+
+	progress *prometheus.Desc
+	healthy  *prometheus.Desc
+}
+
+// NewInitialCollectionCollector creates a new initial collection progress collector
+func NewInitialCollectionCollector(source InitialCollectionProgressProvider) *InitialCollectionCollector {
+	return &InitialCollectionCollector{
+		source: source,
+		progress: prometheus.NewDesc(
+			"pantheon_exporter_initial_collection_progress",
+			"Fraction of sites the initial metrics collection has processed (0-1)",
+			nil,
+			nil,
+		),
+		healthy: prometheus.NewDesc(
+			"pantheon_exporter_initial_collection_healthy",
+			"Whether the initial metrics collection stayed within the failure threshold (1 = healthy, 0 = too many sites failed)",
+			nil,
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *InitialCollectionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.progress
+	ch <- c.healthy
+}
+
+// Collect implements prometheus.Collector
+func (c *InitialCollectionCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.progress, prometheus.GaugeValue, c.source.InitialCollectionProgress())
+
+	healthy, finished := c.source.InitialCollectionHealthy()
+	if !finished {
+		return
+	}
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.healthy, prometheus.GaugeValue, value)
+}
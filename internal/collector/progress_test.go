@@ -0,0 +1,69 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stubProgress is an InitialCollectionProgressProvider with fixed values
+type stubProgress struct {
+	progress          float64
+	healthy, finished bool
+}
+
+func (s stubProgress) InitialCollectionProgress() float64 {
+	return s.progress
+}
+
+func (s stubProgress) InitialCollectionHealthy() (healthy, finished bool) {
+	return s.healthy, s.finished
+}
+
+func TestInitialCollectionCollector(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewInitialCollectionCollector(stubProgress{progress: 0.25}))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	if len(families) != 1 || families[0].GetName() != "pantheon_exporter_initial_collection_progress" {
+		t.Fatalf("Expected pantheon_exporter_initial_collection_progress metric, got %v", families)
+	}
+	if got := families[0].GetMetric()[0].GetGauge().GetValue(); got != 0.25 {
+		t.Errorf("Expected progress 0.25, got %v", got)
+	}
+}
+
+func TestInitialCollectionCollectorHealthy(t *testing.T) {
+	tests := []struct {
+		healthy  bool
+		expected float64
+	}{
+		{true, 1},
+		{false, 0},
+	}
+	for _, tt := range tests {
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(NewInitialCollectionCollector(stubProgress{progress: 1, healthy: tt.healthy, finished: true}))
+
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("Failed to gather metrics: %v", err)
+		}
+		found := false
+		for _, mf := range families {
+			if mf.GetName() != "pantheon_exporter_initial_collection_healthy" {
+				continue
+			}
+			found = true
+			if got := mf.GetMetric()[0].GetGauge().GetValue(); got != tt.expected {
+				t.Errorf("Expected healthy %v, got %v", tt.expected, got)
+			}
+		}
+		if !found {
+			t.Errorf("Expected pantheon_exporter_initial_collection_healthy once the collection finished")
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PlanLimits holds the expected monthly limits of a Pantheon plan. A zero
+// limit is not configured and isn't exported.
+type PlanLimits struct {
+	Visits      int
+	PagesServed int
+}
+
+// ParsePlanLimits parses a semicolon-separated list of plan limits, each
+// written as plan:visits=N,pages_served=N, e.g.
+// "Basic:visits=25000,pages_served=125000;Performance Small:visits=35000".
+// Plans are keyed by their slug, so "Performance Small" and
+// "performance_small" name the same plan. Limits must be positive integers
+// and each plan may appear only once.
+func ParsePlanLimits(value string) (map[string]PlanLimits, error) {
+	limits := make(map[string]PlanLimits)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		plan, values, ok := strings.Cut(entry, ":")
+		slug := planSlug(strings.TrimSpace(plan))
+		if !ok || slug == "" {
+			return nil, fmt.Errorf("invalid plan limits %q: expected plan:visits=N,pages_served=N", entry)
+		}
+		if _, exists := limits[slug]; exists {
+			return nil, fmt.Errorf("duplicate plan %q", plan)
+		}
+
+		var planLimits PlanLimits
+		for _, pair := range strings.Split(values, ",") {
+			key, number, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid limit %q for plan %q: expected key=N", pair, plan)
+			}
+			limit, err := strconv.Atoi(strings.TrimSpace(number))
+			if err != nil || limit <= 0 {
+				return nil, fmt.Errorf("invalid limit %q for plan %q: must be a positive integer", pair, plan)
+			}
+			switch strings.TrimSpace(key) {
+			case MetricVisits:
+				planLimits.Visits = limit
+			case MetricPagesServed:
+				planLimits.PagesServed = limit
+			default:
+				return nil, fmt.Errorf("unknown limit %q for plan %q: expected visits or pages_served", key, plan)
+			}
+		}
+		limits[slug] = planLimits
+	}
+	return limits, nil
+}
+
+// SetPlanLimits exports the configured limits of each site's plan as
+// pantheon_site_quota_* gauges, so dashboards can divide actual usage by them.
+// Sites whose plan isn't configured have no quota metrics.
+func (c *PantheonCollector) SetPlanLimits(limits map[string]PlanLimits) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.planLimits = limits // Replaced rather than modified, as Collect reads it without the lock
+}
+
+// collectQuotas emits the limits configured in planLimits for a site's plan
+func (c *PantheonCollector) collectQuotas(ch chan<- prometheus.Metric, planLimits map[string]PlanLimits, site pantheon.SiteMetrics, labelValues ...string) {
+	limits, ok := planLimits[planSlug(sanitizeLabelValue(site.PlanName))]
+	if !ok {
+		return
+	}
+	for _, v := range []sampleValue{
+		{c.quotaVisits, float64(limits.Visits)},
+		{c.quotaPagesServed, float64(limits.PagesServed)},
+	} {
+		if v.value == 0 {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			v.desc,
+			prometheus.GaugeValue,
+			v.value,
+			labelValues...,
+		)
+	}
+}
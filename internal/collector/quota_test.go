@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestParsePlanLimits(t *testing.T) {
+	limits, err := ParsePlanLimits("Basic:visits=25000,pages_served=125000; Performance Small:visits=35000")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(limits) != 2 {
+		t.Fatalf("Expected 2 plans, got %v", limits)
+	}
+	if got := limits["basic"]; got.Visits != 25000 || got.PagesServed != 125000 {
+		t.Errorf("Expected basic limits 25000/125000, got %+v", got)
+	}
+	if got := limits["performance_small"]; got.Visits != 35000 || got.PagesServed != 0 {
+		t.Errorf("Expected performance_small limits 35000/0, got %+v", got)
+	}
+
+	limits, err = ParsePlanLimits("")
+	if err != nil || len(limits) != 0 {
+		t.Errorf("Expected no limits for an empty value, got %v (err %v)", limits, err)
+	}
+
+	for _, value := range []string{
+		"Basic",
+		":visits=1",
+		"Basic:visits",
+		"Basic:visits=many",
+		"Basic:visits=0",
+		"Basic:visits=-5",
+		"Basic:cache_hits=10",
+		"Basic:visits=1;basic:visits=2",
+	} {
+		if _, err := ParsePlanLimits(value); err == nil {
+			t.Errorf("Expected %q to be invalid", value)
+		}
+	}
+}
+
+func TestCollectPlanLimits(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{SiteName: "site1", Label: "site1", PlanName: "Basic", Account: "account1"},
+		{SiteName: "site2", Label: "site2", PlanName: "Performance Small", Account: "account1"},
+		{SiteName: "site3", Label: "site3", PlanName: "Elite", Account: "account1"},
+	}
+
+	limits, err := ParsePlanLimits("basic:visits=25000,pages_served=125000;performance_small:visits=35000")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	c := NewPantheonCollector(sites)
+	c.SetPlanLimits(limits)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	quotas := map[string]map[string]float64{}
+	for _, mf := range families {
+		if mf.GetName() != "pantheon_site_quota_visits" && mf.GetName() != "pantheon_site_quota_pages_served" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() != "site_id" {
+					continue
+				}
+				if quotas[label.GetValue()] == nil {
+					quotas[label.GetValue()] = map[string]float64{}
+				}
+				quotas[label.GetValue()][mf.GetName()] = m.GetGauge().GetValue()
+			}
+		}
+	}
+
+	if got := quotas["site1"]; got["pantheon_site_quota_visits"] != 25000 || got["pantheon_site_quota_pages_served"] != 125000 {
+		t.Errorf("Expected site1 quotas 25000/125000, got %v", got)
+	}
+	if got := quotas["site2"]; len(got) != 1 || got["pantheon_site_quota_visits"] != 35000 {
+		t.Errorf("Expected only a visits quota of 35000 for site2, got %v", got)
+	}
+	if _, ok := quotas["site3"]; ok {
+		t.Errorf("Expected no quotas for a site on an unconfigured plan, got %v", quotas["site3"])
+	}
+}
+
+func TestCollectPlanLimitsDisabled(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "site1", PlanName: "Basic", Account: "account1"},
+	}))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	for _, mf := range families {
+		if mf.GetName() == "pantheon_site_quota_visits" || mf.GetName() == "pantheon_site_quota_pages_served" {
+			t.Errorf("Expected no %s without -planLimits", mf.GetName())
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RefreshStatusProvider exposes the state of the periodic refreshes.
+type RefreshStatusProvider interface {
+	LastSiteListRefresh() time.Time
+	RefreshInProgress() bool
+	RefreshGoroutines() int
+}
+
+// RefreshCollector collects metrics about the periodic site list and metrics refreshes
+type RefreshCollector struct {
+	source RefreshStatusProvider
+
+	lastSiteListRefresh *prometheus.Desc
+	inProgress          *prometheus.Desc
+	goroutines          *prometheus.Desc
+}
+
+// NewRefreshCollector creates a new refresh metrics collector
+func NewRefreshCollector(source RefreshStatusProvider) *RefreshCollector {
+	return &RefreshCollector{
+		source: source,
+		lastSiteListRefresh: prometheus.NewDesc(
+			"pantheon_exporter_last_sitelist_refresh_timestamp_seconds",
+			"Unix time of the last site list refresh that succeeded for every account",
+			nil,
+			nil,
+		),
+		inProgress: prometheus.NewDesc(
+			"pantheon_exporter_refresh_in_progress",
+			"Whether a batch of site metrics refreshes is running (1 = running, 0 = idle)",
+			nil,
+			nil,
+		),
+		goroutines: prometheus.NewDesc(
+			"pantheon_exporter_refresh_goroutines",
+			"Number of site metrics refresh goroutines currently running",
+			nil,
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *RefreshCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.lastSiteListRefresh
+	ch <- c.inProgress
+	ch <- c.goroutines
+}
+
+// Collect implements prometheus.Collector
+func (c *RefreshCollector) Collect(ch chan<- prometheus.Metric) {
+	inProgress := 0.0
+	if c.source.RefreshInProgress() {
+		inProgress = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.inProgress, prometheus.GaugeValue, inProgress)
+	ch <- prometheus.MustNewConstMetric(c.goroutines, prometheus.GaugeValue, float64(c.source.RefreshGoroutines()))
+
+	last := c.source.LastSiteListRefresh()
+	if last.IsZero() {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		c.lastSiteListRefresh,
+		prometheus.GaugeValue,
+		float64(last.UnixNano())/1e9,
+	)
+}
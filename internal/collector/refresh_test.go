@@ -0,0 +1,113 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stubSiteListRefresh is a RefreshStatusProvider with fixed values
+type stubSiteListRefresh struct {
+	last       time.Time
+	inProgress bool
+	goroutines int
+}
+
+func (s *stubSiteListRefresh) LastSiteListRefresh() time.Time {
+	return s.last
+}
+
+func (s *stubSiteListRefresh) RefreshInProgress() bool {
+	return s.inProgress
+}
+
+func (s *stubSiteListRefresh) RefreshGoroutines() int {
+	return s.goroutines
+}
+
+func TestRefreshCollector(t *testing.T) {
+	last := time.Unix(1762732800, 0)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewRefreshCollector(&stubSiteListRefresh{last: last}))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	if len(families) != 3 || families[0].GetName() != "pantheon_exporter_last_sitelist_refresh_timestamp_seconds" {
+		t.Fatalf("Expected pantheon_exporter_last_sitelist_refresh_timestamp_seconds metric, got %v", families)
+	}
+	if got := families[0].GetMetric()[0].GetGauge().GetValue(); got != 1762732800 {
+		t.Errorf("Expected timestamp 1762732800, got %v", got)
+	}
+}
+
+func TestRefreshCollectorInProgress(t *testing.T) {
+	tests := []struct {
+		inProgress bool
+		expected   float64
+	}{
+		{inProgress: false, expected: 0},
+		{inProgress: true, expected: 1},
+	}
+
+	for _, tt := range tests {
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(NewRefreshCollector(&stubSiteListRefresh{inProgress: tt.inProgress}))
+
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("Failed to gather metrics: %v", err)
+		}
+
+		found := false
+		for _, family := range families {
+			if family.GetName() != "pantheon_exporter_refresh_in_progress" {
+				continue
+			}
+			found = true
+			if got := family.GetMetric()[0].GetGauge().GetValue(); got != tt.expected {
+				t.Errorf("inProgress=%v: expected %v, got %v", tt.inProgress, tt.expected, got)
+			}
+		}
+		if !found {
+			t.Errorf("inProgress=%v: pantheon_exporter_refresh_in_progress not emitted", tt.inProgress)
+		}
+	}
+}
+
+func TestRefreshCollectorNeverRefreshed(t *testing.T) {
+	collector := NewRefreshCollector(&stubSiteListRefresh{})
+
+	ch := make(chan prometheus.Metric, 3)
+	collector.Collect(ch)
+	close(ch)
+
+	// Only the in-progress and goroutine gauges are emitted before the first refresh.
+	if len(ch) != 2 {
+		t.Errorf("Expected only the in-progress and goroutine metrics before the first refresh, got %d", len(ch))
+	}
+}
+
+func TestRefreshCollectorGoroutines(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewRefreshCollector(&stubSiteListRefresh{goroutines: 7}))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "pantheon_exporter_refresh_goroutines" {
+			continue
+		}
+		if got := family.GetMetric()[0].GetGauge().GetValue(); got != 7 {
+			t.Errorf("Expected 7 refresh goroutines, got %v", got)
+		}
+		return
+	}
+	t.Error("pantheon_exporter_refresh_goroutines not emitted")
+}
@@ -0,0 +1,50 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RetryStatsProvider exposes counts of Pantheon API retries.
+type RetryStatsProvider interface {
+	RetryStats() (retries, exhausted int64)
+}
+
+// RetryCollector collects API retry counters
+type RetryCollector struct {
+	source RetryStatsProvider
+
+	retries   *prometheus.Desc
+	exhausted *prometheus.Desc
+}
+
+// NewRetryCollector creates a new API retry metrics collector
+func NewRetryCollector(source RetryStatsProvider) *RetryCollector {
+	return &RetryCollector{
+		source: source,
+		retries: prometheus.NewDesc(
+			"pantheon_api_retries_total",
+			"Number of Pantheon API requests retried after a failure",
+			nil,
+			nil,
+		),
+		exhausted: prometheus.NewDesc(
+			"pantheon_api_retry_budget_exhausted_total",
+			"Number of Pantheon API retries skipped because the retry budget was used up",
+			nil,
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *RetryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.retries
+	ch <- c.exhausted
+}
+
+// Collect implements prometheus.Collector
+func (c *RetryCollector) Collect(ch chan<- prometheus.Metric) {
+	retries, exhausted := c.source.RetryStats()
+	ch <- prometheus.MustNewConstMetric(c.retries, prometheus.CounterValue, float64(retries))
+	ch <- prometheus.MustNewConstMetric(c.exhausted, prometheus.CounterValue, float64(exhausted))
+}
@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stubRetryStats is a RetryStatsProvider with fixed values
+type stubRetryStats struct {
+	retries, exhausted int64
+}
+
+func (s *stubRetryStats) RetryStats() (retries, exhausted int64) {
+	return s.retries, s.exhausted
+}
+
+func TestRetryCollector(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewRetryCollector(&stubRetryStats{retries: 12, exhausted: 3}))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	expected := map[string]float64{
+		"pantheon_api_retries_total":                12,
+		"pantheon_api_retry_budget_exhausted_total": 3,
+	}
+	if len(families) != len(expected) {
+		t.Fatalf("Expected %d metric families, got %d", len(expected), len(families))
+	}
+	for _, mf := range families {
+		want, ok := expected[mf.GetName()]
+		if !ok {
+			t.Errorf("Unexpected metric %s", mf.GetName())
+			continue
+		}
+		if got := mf.GetMetric()[0].GetCounter().GetValue(); got != want {
+			t.Errorf("Expected %s to be %v, got %v", mf.GetName(), want, got)
+		}
+	}
+}
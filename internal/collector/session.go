@@ -0,0 +1,86 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SessionStatsProvider exposes statistics about authenticated Pantheon sessions.
+type SessionStatsProvider interface {
+	SessionCount() int
+	SessionAges() map[string]time.Duration
+	SessionsDegraded() map[string]bool
+}
+
+// SessionCollector collects session count and age metrics
+type SessionCollector struct {
+	source SessionStatsProvider
+
+	sessionsActive *prometheus.Desc
+	sessionAge     *prometheus.Desc
+	degraded       *prometheus.Desc
+}
+
+// NewSessionCollector creates a new session metrics collector
+func NewSessionCollector(source SessionStatsProvider) *SessionCollector {
+	return &SessionCollector{
+		source: source,
+		sessionsActive: prometheus.NewDesc(
+			"pantheon_sessions_active",
+			"Number of authenticated Pantheon sessions held in memory",
+			nil,
+			nil,
+		),
+		sessionAge: prometheus.NewDesc(
+			"pantheon_session_age_seconds",
+			"Age of the authenticated Pantheon session for an account",
+			[]string{"account"},
+			nil,
+		),
+		degraded: prometheus.NewDesc(
+			"pantheon_session_degraded",
+			"Whether an account logged in but its email lookup failed (1 = degraded, 0 = healthy)",
+			[]string{"account"},
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *SessionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sessionsActive
+	ch <- c.sessionAge
+	ch <- c.degraded
+}
+
+// Collect implements prometheus.Collector
+func (c *SessionCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(
+		c.sessionsActive,
+		prometheus.GaugeValue,
+		float64(c.source.SessionCount()),
+	)
+
+	for account, age := range c.source.SessionAges() {
+		ch <- prometheus.MustNewConstMetric(
+			c.sessionAge,
+			prometheus.GaugeValue,
+			age.Seconds(),
+			account,
+		)
+	}
+
+	for account, degraded := range c.source.SessionsDegraded() {
+		value := 0.0
+		if degraded {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.degraded,
+			prometheus.GaugeValue,
+			value,
+			account,
+		)
+	}
+}
@@ -0,0 +1,136 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stubSessionStats is a SessionStatsProvider with fixed values
+type stubSessionStats struct {
+	ages     map[string]time.Duration
+	degraded map[string]bool
+}
+
+func (s *stubSessionStats) SessionCount() int {
+	return len(s.ages)
+}
+
+func (s *stubSessionStats) SessionAges() map[string]time.Duration {
+	return s.ages
+}
+
+func (s *stubSessionStats) SessionsDegraded() map[string]bool {
+	return s.degraded
+}
+
+func TestSessionCollectorCount(t *testing.T) {
+	source := &stubSessionStats{
+		ages: map[string]time.Duration{
+			"a@example.com": 30 * time.Second,
+			"b@example.com": 2 * time.Minute,
+			"c@example.com": time.Hour,
+		},
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSessionCollector(source))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	found := map[string]bool{}
+	for _, mf := range families {
+		found[mf.GetName()] = true
+		switch mf.GetName() {
+		case "pantheon_sessions_active":
+			if got := mf.GetMetric()[0].GetGauge().GetValue(); got != 3 {
+				t.Errorf("Expected pantheon_sessions_active=3, got %v", got)
+			}
+		case "pantheon_session_age_seconds":
+			if len(mf.GetMetric()) != 3 {
+				t.Errorf("Expected 3 session age series, got %d", len(mf.GetMetric()))
+			}
+			for _, m := range mf.GetMetric() {
+				account := m.GetLabel()[0].GetValue()
+				expected := source.ages[account].Seconds()
+				if got := m.GetGauge().GetValue(); got != expected {
+					t.Errorf("Expected age %v for %s, got %v", expected, account, got)
+				}
+			}
+		}
+	}
+
+	if !found["pantheon_sessions_active"] {
+		t.Error("Expected pantheon_sessions_active metric")
+	}
+	if !found["pantheon_session_age_seconds"] {
+		t.Error("Expected pantheon_session_age_seconds metric")
+	}
+}
+
+func TestSessionCollectorNoSessions(t *testing.T) {
+	collector := NewSessionCollector(&stubSessionStats{})
+
+	ch := make(chan prometheus.Metric, 5)
+	collector.Collect(ch)
+	close(ch)
+
+	count := 0
+	for range ch {
+		count++
+	}
+
+	// Only the active session count should be emitted
+	if count != 1 {
+		t.Errorf("Expected 1 metric, got %d", count)
+	}
+}
+
+func TestSessionCollectorDegraded(t *testing.T) {
+	source := &stubSessionStats{
+		ages: map[string]time.Duration{
+			"a@example.com": time.Minute,
+			"12345678":      time.Minute,
+		},
+		degraded: map[string]bool{
+			"a@example.com": false,
+			"12345678":      true,
+		},
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSessionCollector(source))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	found := false
+	for _, mf := range families {
+		if mf.GetName() != "pantheon_session_degraded" {
+			continue
+		}
+		found = true
+		if len(mf.GetMetric()) != 2 {
+			t.Fatalf("Expected 2 session degraded series, got %d", len(mf.GetMetric()))
+		}
+		for _, m := range mf.GetMetric() {
+			account := m.GetLabel()[0].GetValue()
+			expected := 0.0
+			if source.degraded[account] {
+				expected = 1
+			}
+			if got := m.GetGauge().GetValue(); got != expected {
+				t.Errorf("Expected degraded=%v for %s, got %v", expected, account, got)
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected pantheon_session_degraded metric")
+	}
+}
@@ -0,0 +1,13 @@
+package collector
+
+import "time"
+
+// SetEmitSince limits the historical samples emitted for each site, and the
+// daily deltas, to those within window of the current time. Older samples are
+// still fetched and kept, for the site metrics endpoint and snapshots. The
+// latest sample is always emitted. 0 means no limit.
+func (c *PantheonCollector) SetEmitSince(window time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.emitSince = window
+}
@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCollectEmitSince(t *testing.T) {
+	now := time.Unix(1762732800, 0)
+	metricsData := make(map[string]pantheon.MetricData)
+	for day := 0; day < 10; day++ {
+		timestamp := now.Add(-time.Duration(day) * 24 * time.Hour).Unix()
+		metricsData[strconv.FormatInt(timestamp, 10)] = pantheon.MetricData{Visits: 200 - day, CacheHitRatio: "10%"}
+	}
+
+	c := NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "site1", Label: "site1", Account: "account1", MetricsData: metricsData},
+	})
+	c.now = func() time.Time { return now }
+	c.SetDailyDeltas(true)
+	c.SetEmitSince(3 * 24 * time.Hour)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	cutoff := now.Add(-3 * 24 * time.Hour).UnixMilli()
+	counts := map[string]int{}
+	for _, mf := range families {
+		if mf.GetName() != "pantheon_visits_total" && mf.GetName() != "pantheon_visits_daily" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			counts[mf.GetName()]++
+			if m.GetTimestampMs() < cutoff {
+				t.Errorf("Expected no %s sample before the window, got one at %d", mf.GetName(), m.GetTimestampMs())
+			}
+		}
+	}
+
+	// The latest sample plus the 3 days before it
+	if counts["pantheon_visits_total"] != 4 {
+		t.Errorf("Expected 4 visits samples within 3 days, got %d", counts["pantheon_visits_total"])
+	}
+	// Deltas are stamped with the later sample, so the same 3 days qualify
+	if counts["pantheon_visits_daily"] != 4 {
+		t.Errorf("Expected 4 daily deltas within 3 days, got %d", counts["pantheon_visits_daily"])
+	}
+}
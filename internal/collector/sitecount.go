@@ -0,0 +1,47 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SiteCountProvider exposes how many sites the Pantheon API listed for each account.
+type SiteCountProvider interface {
+	SiteCounts() map[string]int
+}
+
+// SiteCountCollector collects the number of sites listed per account
+type SiteCountCollector struct {
+	source SiteCountProvider
+
+	sitesListed *prometheus.Desc
+}
+
+// NewSiteCountCollector creates a new site count metrics collector
+func NewSiteCountCollector(source SiteCountProvider) *SiteCountCollector {
+	return &SiteCountCollector{
+		source: source,
+		sitesListed: prometheus.NewDesc(
+			"pantheon_account_sites_listed",
+			"Number of sites the Pantheon API listed for an account in its last site list fetch, before filtering",
+			[]string{"account"},
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *SiteCountCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sitesListed
+}
+
+// Collect implements prometheus.Collector
+func (c *SiteCountCollector) Collect(ch chan<- prometheus.Metric) {
+	for account, count := range c.source.SiteCounts() {
+		ch <- prometheus.MustNewConstMetric(
+			c.sitesListed,
+			prometheus.GaugeValue,
+			float64(count),
+			account,
+		)
+	}
+}
@@ -0,0 +1,57 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stubSiteCounts is a SiteCountProvider with fixed values
+type stubSiteCounts struct {
+	counts map[string]int
+}
+
+func (s *stubSiteCounts) SiteCounts() map[string]int {
+	return s.counts
+}
+
+func TestSiteCountCollector(t *testing.T) {
+	source := &stubSiteCounts{counts: map[string]int{
+		"a@example.com": 150,
+		"b@example.com": 3,
+	}}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSiteCountCollector(source))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	if len(families) != 1 || families[0].GetName() != "pantheon_account_sites_listed" {
+		t.Fatalf("Expected only pantheon_account_sites_listed, got %v", families)
+	}
+	if len(families[0].GetMetric()) != 2 {
+		t.Fatalf("Expected 2 series, got %d", len(families[0].GetMetric()))
+	}
+	for _, m := range families[0].GetMetric() {
+		account := m.GetLabel()[0].GetValue()
+		if got := m.GetGauge().GetValue(); got != float64(source.counts[account]) {
+			t.Errorf("Expected %d sites for %s, got %v", source.counts[account], account, got)
+		}
+	}
+}
+
+func TestSiteCountCollectorEmpty(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSiteCountCollector(&stubSiteCounts{}))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	if len(families) != 0 {
+		t.Errorf("Expected no metrics before any site list fetch, got %d families", len(families))
+	}
+}
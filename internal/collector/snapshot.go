@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+)
+
+// snapshot is the on-disk form of the collector's sites
+type snapshot struct {
+	SavedAt time.Time              `json:"saved_at"`
+	Sites   []pantheon.SiteMetrics `json:"sites"`
+}
+
+// SaveSnapshot writes every site, including its metrics data, to path as JSON
+// (thread-safe). The file is written to a temporary file and renamed into
+// place, so a crash while saving never leaves a truncated snapshot behind.
+func (c *PantheonCollector) SaveSnapshot(path string) error {
+	c.mu.RLock()
+	data, err := json.Marshal(snapshot{SavedAt: c.now(), Sites: c.sites})
+	c.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot restores metrics data saved by SaveSnapshot to the sites
+// currently in the collector that have none yet, matching sites by account and
+// name (thread-safe). Sites in the snapshot that are no longer listed are
+// ignored. It returns how many sites were restored. A missing file returns an
+// error wrapping os.ErrNotExist, and a corrupt file leaves the collector
+// unchanged.
+func (c *PantheonCollector) LoadSnapshot(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var saved snapshot
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return 0, fmt.Errorf("corrupt snapshot %s: %w", path, err)
+	}
+
+	savedSites := make(map[string]pantheon.SiteMetrics, len(saved.Sites))
+	for _, site := range saved.Sites {
+		savedSites[site.Account+":"+site.SiteName] = site
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	restored := 0
+	for i := range c.sites {
+		site, ok := savedSites[c.sites[i].Account+":"+c.sites[i].SiteName]
+		if !ok || len(site.MetricsData) == 0 || len(c.sites[i].MetricsData) > 0 {
+			continue
+		}
+		c.sites[i].MetricsData = site.MetricsData
+		c.sites[i].Environment = site.Environment
+		restored++
+	}
+	return restored, nil
+}
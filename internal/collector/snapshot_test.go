@@ -0,0 +1,95 @@
+package collector
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	saved := NewPantheonCollector([]pantheon.SiteMetrics{
+		{
+			SiteName:    "site1",
+			Account:     "account1",
+			Environment: "dev",
+			MetricsData: map[string]pantheon.MetricData{
+				"1762732800": {DateTime: "2025-11-10T00:00:00", Visits: 100, PagesServed: 500, CacheHitRatio: "50%"},
+			},
+		},
+		{SiteName: "removed", Account: "account1", MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 1}}},
+	})
+	if err := saved.SaveSnapshot(path); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+
+	// A fresh start lists site1 and a new site, without metrics yet
+	loaded := NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "site1", Account: "account1"},
+		{SiteName: "site2", Account: "account1"},
+	})
+	restored, err := loaded.LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if restored != 1 {
+		t.Errorf("Expected 1 site restored, got %d", restored)
+	}
+
+	site, _ := loaded.GetSite("account1", "site1")
+	if got := site.MetricsData["1762732800"]; got.Visits != 100 || got.PagesServed != 500 || got.CacheHitRatio != "50%" {
+		t.Errorf("Expected saved metrics for site1, got %+v", got)
+	}
+	if site.Environment != "dev" {
+		t.Errorf("Expected environment dev, got %q", site.Environment)
+	}
+	if _, ok := loaded.GetSite("account1", "removed"); ok {
+		t.Error("Expected sites no longer listed to be ignored")
+	}
+	if len(loaded.GetSites()) != 2 {
+		t.Errorf("Expected the site list to be unchanged, got %d sites", len(loaded.GetSites()))
+	}
+}
+
+func TestLoadSnapshotKeepsFreshMetrics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	saved := NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "site1", Account: "account1", MetricsData: map[string]pantheon.MetricData{"1762732800": {Visits: 1}}},
+	})
+	if err := saved.SaveSnapshot(path); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+
+	loaded := NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "site1", Account: "account1", MetricsData: map[string]pantheon.MetricData{"1762819200": {Visits: 2}}},
+	})
+	if restored, err := loaded.LoadSnapshot(path); err != nil || restored != 0 {
+		t.Errorf("Expected no sites restored over fresh metrics, got %d (err %v)", restored, err)
+	}
+	if site, _ := loaded.GetSite("account1", "site1"); site.MetricsData["1762819200"].Visits != 2 {
+		t.Errorf("Expected fresh metrics to be kept, got %v", site.MetricsData)
+	}
+}
+
+func TestLoadSnapshotMissingOrCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	c := NewPantheonCollector([]pantheon.SiteMetrics{{SiteName: "site1", Account: "account1"}})
+
+	if _, err := c.LoadSnapshot(filepath.Join(dir, "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a not-exist error for a missing snapshot, got %v", err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte(`{"sites": [{"SiteName": "site1"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.LoadSnapshot(corrupt); err == nil {
+		t.Error("Expected an error for a corrupt snapshot")
+	}
+	if c.HasAnyMetrics() {
+		t.Error("Expected the collector to be unchanged after a corrupt snapshot")
+	}
+}
@@ -0,0 +1,59 @@
+package collector
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SetStaleAfter marks sites whose latest sample is older than age as stale,
+// exported as pantheon_site_stale. Pantheon's daily samples are stamped at
+// midnight, so age should be well over a day. If dropStale is set, the
+// sample-based metrics of stale sites aren't exported at all, so dashboards
+// show a gap instead of a misleading flat line. 0 disables staleness.
+func (c *PantheonCollector) SetStaleAfter(age time.Duration, dropStale bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.staleAfter = age
+	c.dropStale = dropStale
+}
+
+// siteStale reports whether a site's latest sample is from before staleBefore,
+// and whether that could be decided. Sites without samples are neither stale
+// nor fresh.
+func siteStale(site pantheon.SiteMetrics, staleBefore time.Time) (stale, known bool) {
+	latestTimestampStr, _, hasData := latestSample(site)
+	if !hasData {
+		return false, false
+	}
+	timestamp, err := strconv.ParseInt(latestTimestampStr, 10, 64)
+	if err != nil {
+		return false, false
+	}
+	return time.Unix(timestamp, 0).Before(staleBefore), true
+}
+
+// collectStale emits whether a site is stale, and returns whether its
+// sample-based metrics should be dropped
+func (c *PantheonCollector) collectStale(ch chan<- prometheus.Metric, state collectState, site pantheon.SiteMetrics, labelValues ...string) bool {
+	if state.staleBefore.IsZero() {
+		return false
+	}
+	stale, known := siteStale(site, state.staleBefore)
+	if !known {
+		return false
+	}
+	value := 0.0
+	if stale {
+		value = 1
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.siteStale,
+		prometheus.GaugeValue,
+		value,
+		labelValues...,
+	)
+	return stale && state.dropStale
+}
@@ -0,0 +1,102 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCollectStale(t *testing.T) {
+	now := time.Unix(1762732800, 0)
+	sample := func(age time.Duration) map[string]pantheon.MetricData {
+		return map[string]pantheon.MetricData{
+			strconv.FormatInt(now.Add(-age).Unix(), 10): {Visits: 100, CacheHitRatio: "10%"},
+		}
+	}
+	sites := []pantheon.SiteMetrics{
+		{SiteName: "fresh", Label: "fresh", Account: "account1", MetricsData: sample(24 * time.Hour)},
+		{SiteName: "stale", Label: "stale", Account: "account1", MetricsData: sample(5 * 24 * time.Hour)},
+		{SiteName: "empty", Label: "empty", Account: "account1"},
+	}
+
+	tests := []struct {
+		name           string
+		staleAfter     time.Duration
+		dropStale      bool
+		expectedStale  map[string]float64
+		expectedVisits map[string]bool
+	}{
+		{
+			name:           "disabled",
+			expectedStale:  map[string]float64{},
+			expectedVisits: map[string]bool{"fresh": true, "stale": true},
+		},
+		{
+			name:           "marked stale",
+			staleAfter:     3 * 24 * time.Hour,
+			expectedStale:  map[string]float64{"fresh": 0, "stale": 1},
+			expectedVisits: map[string]bool{"fresh": true, "stale": true},
+		},
+		{
+			name:           "stale dropped",
+			staleAfter:     3 * 24 * time.Hour,
+			dropStale:      true,
+			expectedStale:  map[string]float64{"fresh": 0, "stale": 1},
+			expectedVisits: map[string]bool{"fresh": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewPantheonCollector(sites)
+			c.now = func() time.Time { return now }
+			c.SetStaleAfter(tt.staleAfter, tt.dropStale)
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(c)
+
+			families, err := registry.Gather()
+			if err != nil {
+				t.Fatalf("Failed to gather metrics: %v", err)
+			}
+
+			stale := map[string]float64{}
+			visits := map[string]bool{}
+			for _, mf := range families {
+				for _, m := range mf.GetMetric() {
+					var site string
+					for _, label := range m.GetLabel() {
+						if label.GetName() == "site_id" {
+							site = label.GetValue()
+						}
+					}
+					switch mf.GetName() {
+					case "pantheon_site_stale":
+						stale[site] = m.GetGauge().GetValue()
+					case "pantheon_visits_total":
+						visits[site] = true
+					}
+				}
+			}
+
+			if len(stale) != len(tt.expectedStale) {
+				t.Errorf("Expected stale gauges %v, got %v", tt.expectedStale, stale)
+			}
+			for site, expected := range tt.expectedStale {
+				if got, ok := stale[site]; !ok || got != expected {
+					t.Errorf("Expected pantheon_site_stale %v for %s, got %v", expected, site, stale)
+				}
+			}
+			if len(visits) != len(tt.expectedVisits) {
+				t.Errorf("Expected visits for %v, got %v", tt.expectedVisits, visits)
+			}
+			for site := range tt.expectedVisits {
+				if !visits[site] {
+					t.Errorf("Expected visits to be exported for %s", site)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// UnauthorizedSitesProvider exposes the sites skipped because reading their
+// metrics was forbidden.
+type UnauthorizedSitesProvider interface {
+	UnauthorizedSites() map[string][]string
+}
+
+// UnauthorizedCollector collects which sites are skipped after a forbidden
+// metrics request
+type UnauthorizedCollector struct {
+	source UnauthorizedSitesProvider
+
+	unauthorized *prometheus.Desc
+}
+
+// NewUnauthorizedCollector creates a new unauthorized sites metrics collector
+func NewUnauthorizedCollector(source UnauthorizedSitesProvider) *UnauthorizedCollector {
+	return &UnauthorizedCollector{
+		source: source,
+		unauthorized: prometheus.NewDesc(
+			"pantheon_site_unauthorized",
+			"Whether a site's metrics are skipped because the account's token isn't allowed to read them (1 = skipped)",
+			[]string{"site_id", "account"},
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *UnauthorizedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.unauthorized
+}
+
+// Collect implements prometheus.Collector
+func (c *UnauthorizedCollector) Collect(ch chan<- prometheus.Metric) {
+	for account, sites := range c.source.UnauthorizedSites() {
+		for _, site := range sites {
+			ch <- prometheus.MustNewConstMetric(
+				c.unauthorized,
+				prometheus.GaugeValue,
+				1,
+				site,
+				account,
+			)
+		}
+	}
+}
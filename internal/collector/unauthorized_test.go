@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stubUnauthorizedSites is an UnauthorizedSitesProvider with fixed values
+type stubUnauthorizedSites map[string][]string
+
+func (s stubUnauthorizedSites) UnauthorizedSites() map[string][]string {
+	return s
+}
+
+func TestUnauthorizedCollector(t *testing.T) {
+	source := stubUnauthorizedSites{"a@example.com": {"site1", "site2"}}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewUnauthorizedCollector(source))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	if len(families) != 1 || families[0].GetName() != "pantheon_site_unauthorized" {
+		t.Fatalf("Expected pantheon_site_unauthorized metric, got %v", families)
+	}
+
+	sites := map[string]float64{}
+	for _, m := range families[0].GetMetric() {
+		labels := map[string]string{}
+		for _, label := range m.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		sites[labels["account"]+"/"+labels["site_id"]] = m.GetGauge().GetValue()
+	}
+	if len(sites) != 2 || sites["a@example.com/site1"] != 1 || sites["a@example.com/site2"] != 1 {
+		t.Errorf("Expected both sites of a@example.com to be reported, got %v", sites)
+	}
+}
@@ -0,0 +1,51 @@
+package filter
+
+import "github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+
+// accountRank returns the position of an account in the preference list, or
+// len(PreferAccounts) if the account isn't listed.
+func (f Sites) accountRank(account string) int {
+	for i, preferred := range f.PreferAccounts {
+		if preferred == account {
+			return i
+		}
+	}
+	return len(f.PreferAccounts)
+}
+
+// prefers reports whether site entry a should be kept for a shared site instead of b.
+// Listed accounts win over unlisted ones, in list order. Between equally
+// preferred accounts, a direct membership wins over an organization's site;
+// remaining ties keep the earlier entry.
+func (f Sites) prefers(a, b pantheon.SiteMetrics) bool {
+	if rankA, rankB := f.accountRank(a.Account), f.accountRank(b.Account); rankA != rankB {
+		return rankA < rankB
+	}
+	return a.Source == pantheon.SiteSourceUser && b.Source != pantheon.SiteSourceUser
+}
+
+// DedupeShared removes duplicate entries for sites visible to several accounts
+// when Dedupe is set, keeping the entry of the preferred account. Sites are
+// identified by site ID. Without a preference, an account that is a direct
+// member of the site wins, then the first entry (in token order).
+func (f Sites) DedupeShared(sites []pantheon.SiteMetrics) []pantheon.SiteMetrics {
+	if !f.Dedupe {
+		return sites
+	}
+
+	owners := make(map[string]int, len(sites)) // site ID -> index of the owning entry
+	for i, site := range sites {
+		owner, ok := owners[site.SiteID]
+		if !ok || f.prefers(site, sites[owner]) {
+			owners[site.SiteID] = i
+		}
+	}
+
+	kept := make([]pantheon.SiteMetrics, 0, len(owners))
+	for i, site := range sites {
+		if owners[site.SiteID] == i {
+			kept = append(kept, site)
+		}
+	}
+	return kept
+}
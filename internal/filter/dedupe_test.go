@@ -0,0 +1,95 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+)
+
+// sharedSites returns a site visible to both accounts plus one site per account
+func sharedSites() []pantheon.SiteMetrics {
+	return []pantheon.SiteMetrics{
+		{SiteID: "shared", SiteName: "client-site", Account: "agency@example.com"},
+		{SiteID: "agency-only", SiteName: "agency-site", Account: "agency@example.com"},
+		{SiteID: "shared", SiteName: "client-site", Account: "client@example.com"},
+		{SiteID: "client-only", SiteName: "other-site", Account: "client@example.com"},
+	}
+}
+
+// owners maps site IDs to the accounts they were kept under
+func owners(sites []pantheon.SiteMetrics) map[string][]string {
+	result := make(map[string][]string)
+	for _, site := range sites {
+		result[site.SiteID] = append(result[site.SiteID], site.Account)
+	}
+	return result
+}
+
+func TestDedupeSharedDisabled(t *testing.T) {
+	sites := sharedSites()
+	if got := (Sites{}).DedupeShared(sites); len(got) != len(sites) {
+		t.Errorf("Expected all %d sites without dedupe, got %d", len(sites), len(got))
+	}
+}
+
+func TestDedupeSharedTokenOrder(t *testing.T) {
+	got := owners(Sites{Dedupe: true}.DedupeShared(sharedSites()))
+
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 unique sites, got %v", got)
+	}
+	if accounts := got["shared"]; len(accounts) != 1 || accounts[0] != "agency@example.com" {
+		t.Errorf("Expected shared site to stay with the first account, got %v", accounts)
+	}
+}
+
+func TestDedupeSharedPreferAccounts(t *testing.T) {
+	f := Sites{Dedupe: true, PreferAccounts: []string{"client@example.com"}}
+
+	// The preference wins regardless of which account listed the site first
+	for i := 0; i < 2; i++ {
+		sites := sharedSites()
+		if i == 1 {
+			sites[0], sites[2] = sites[2], sites[0]
+		}
+
+		got := owners(f.DedupeShared(sites))
+		if accounts := got["shared"]; len(accounts) != 1 || accounts[0] != "client@example.com" {
+			t.Errorf("Expected shared site to be owned by the preferred account, got %v", accounts)
+		}
+		if len(got["agency-only"]) != 1 || len(got["client-only"]) != 1 {
+			t.Errorf("Expected unshared sites to be kept, got %v", got)
+		}
+	}
+}
+
+func TestDedupeSharedPreferenceOrder(t *testing.T) {
+	sites := []pantheon.SiteMetrics{
+		{SiteID: "shared", Account: "a@example.com"},
+		{SiteID: "shared", Account: "b@example.com"},
+		{SiteID: "shared", Account: "c@example.com"},
+	}
+	f := Sites{Dedupe: true, PreferAccounts: []string{"c@example.com", "b@example.com"}}
+
+	got := f.DedupeShared(sites)
+	if len(got) != 1 || got[0].Account != "c@example.com" {
+		t.Errorf("Expected the first preferred account to own the site, got %v", got)
+	}
+}
+
+func TestDedupeSharedPrefersDirectMembership(t *testing.T) {
+	sites := sharedSites()
+	sites[0].Source = pantheon.SiteSourceOrg
+	sites[2].Source = pantheon.SiteSourceUser
+
+	got := owners(Sites{Dedupe: true}.DedupeShared(sites))
+	if accounts := got["shared"]; len(accounts) != 1 || accounts[0] != "client@example.com" {
+		t.Errorf("Expected shared site to stay with the directly member account, got %v", accounts)
+	}
+
+	// An account preference still wins over the source
+	got = owners(Sites{Dedupe: true, PreferAccounts: []string{"agency@example.com"}}.DedupeShared(sites))
+	if accounts := got["shared"]; len(accounts) != 1 || accounts[0] != "agency@example.com" {
+		t.Errorf("Expected shared site to be owned by the preferred account, got %v", accounts)
+	}
+}
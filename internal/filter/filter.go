@@ -0,0 +1,109 @@
+// Package filter selects which sites are monitored.
+package filter
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+)
+
+// ParseList splits a comma-separated flag value into trimmed, non-empty entries.
+func ParseList(value string) []string {
+	var entries []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Sites selects which of an account's sites are monitored.
+type Sites struct {
+	Names          []string // Exact site names to include (empty = all sites)
+	Frameworks     []string // Site frameworks to include, e.g. drupal8 (empty = all frameworks)
+	Accounts       []string // Emails of the accounts to include, whatever tokens are given (empty = all accounts)
+	Dedupe         bool     // Report sites shared by several accounts under only one of them
+	PreferAccounts []string // Accounts that own shared sites, most preferred first
+}
+
+// IsEmpty reports whether the filter selects every site.
+func (f Sites) IsEmpty() bool {
+	return len(f.Names) == 0 && len(f.Frameworks) == 0
+}
+
+// Matches reports whether a site is selected by the filter: its name must be
+// one of Names and its framework one of Frameworks, when each is set.
+// Frameworks are compared case-insensitively.
+func (f Sites) Matches(site pantheon.SiteListEntry) bool {
+	return f.matchesName(site) && f.matchesFramework(site)
+}
+
+// matchesName reports whether a site's name is selected
+func (f Sites) matchesName(site pantheon.SiteListEntry) bool {
+	if len(f.Names) == 0 {
+		return true
+	}
+	for _, name := range f.Names {
+		if site.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesFramework reports whether a site's framework is selected
+func (f Sites) matchesFramework(site pantheon.SiteListEntry) bool {
+	if len(f.Frameworks) == 0 {
+		return true
+	}
+	for _, framework := range f.Frameworks {
+		if strings.EqualFold(site.Framework, framework) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsAccount reports whether an account, identified by the email it
+// authenticated as, is selected by the filter. Emails are compared
+// case-insensitively.
+func (f Sites) AllowsAccount(email string) bool {
+	if len(f.Accounts) == 0 {
+		return true
+	}
+	for _, allowed := range f.Accounts {
+		if strings.EqualFold(email, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns the subset of sites selected by the filter.
+func (f Sites) Apply(sites map[string]pantheon.SiteListEntry) map[string]pantheon.SiteListEntry {
+	if f.IsEmpty() {
+		return sites
+	}
+	selected := make(map[string]pantheon.SiteListEntry)
+	for siteID, site := range sites {
+		if f.Matches(site) {
+			selected[siteID] = site
+		}
+	}
+	return selected
+}
+
+// Missing returns the requested site names that are not in found, sorted.
+func (f Sites) Missing(found map[string]bool) []string {
+	var missing []string
+	for _, name := range f.Names {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
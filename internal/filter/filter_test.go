@@ -0,0 +1,146 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+)
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{name: "empty", value: "", expected: nil},
+		{name: "single", value: "pr-*", expected: []string{"pr-*"}},
+		{name: "multiple with spaces", value: "pr-*, ci-* ,,", expected: []string{"pr-*", "ci-*"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ParseList(tt.value)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParseList(%q) = %v, want %v", tt.value, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSitesApply(t *testing.T) {
+	sites := map[string]pantheon.SiteListEntry{
+		"uuid-a": {Name: "site-a", ID: "uuid-a"},
+		"uuid-b": {Name: "site-b", ID: "uuid-b"},
+		"uuid-c": {Name: "site-c", ID: "uuid-c"},
+	}
+
+	f := Sites{Names: []string{"site-a", "site-c", "site-missing"}}
+	result := f.Apply(sites)
+
+	if len(result) != 2 {
+		t.Fatalf("Expected 2 sites, got %d", len(result))
+	}
+	if _, ok := result["uuid-a"]; !ok {
+		t.Error("Expected site-a to be selected")
+	}
+	if _, ok := result["uuid-c"]; !ok {
+		t.Error("Expected site-c to be selected")
+	}
+}
+
+func TestSitesApplyExactMatch(t *testing.T) {
+	sites := map[string]pantheon.SiteListEntry{
+		"uuid-a": {Name: "site-a", ID: "uuid-a"},
+		"uuid-b": {Name: "site-a-staging", ID: "uuid-b"},
+	}
+
+	result := Sites{Names: []string{"site-a"}}.Apply(sites)
+
+	if len(result) != 1 {
+		t.Fatalf("Expected only the exact match, got %d sites", len(result))
+	}
+}
+
+func TestSitesApplyEmpty(t *testing.T) {
+	sites := map[string]pantheon.SiteListEntry{
+		"uuid-a": {Name: "site-a", ID: "uuid-a"},
+	}
+
+	f := Sites{}
+	if !f.IsEmpty() {
+		t.Error("Expected empty filter")
+	}
+	if result := f.Apply(sites); len(result) != 1 {
+		t.Errorf("Expected all sites with empty filter, got %d", len(result))
+	}
+}
+
+func TestSitesApplyFrameworks(t *testing.T) {
+	sites := map[string]pantheon.SiteListEntry{
+		"uuid-a": {Name: "site-a", ID: "uuid-a", Framework: "drupal8"},
+		"uuid-b": {Name: "site-b", ID: "uuid-b", Framework: "wordpress"},
+		"uuid-c": {Name: "site-c", ID: "uuid-c", Framework: "drupal10"},
+		"uuid-d": {Name: "site-d", ID: "uuid-d", Framework: "Drupal8"},
+	}
+
+	tests := []struct {
+		name     string
+		filter   Sites
+		expected []string
+	}{
+		{name: "single framework", filter: Sites{Frameworks: []string{"wordpress"}}, expected: []string{"uuid-b"}},
+		{name: "multiple frameworks", filter: Sites{Frameworks: []string{"drupal8", "drupal10"}}, expected: []string{"uuid-a", "uuid-c", "uuid-d"}},
+		{name: "with site names", filter: Sites{Names: []string{"site-a", "site-b"}, Frameworks: []string{"drupal8"}}, expected: []string{"uuid-a"}},
+		{name: "no match", filter: Sites{Frameworks: []string{"backdrop"}}, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.filter.IsEmpty() {
+				t.Error("Expected a framework filter not to be empty")
+			}
+			result := tt.filter.Apply(sites)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, result)
+			}
+			for _, id := range tt.expected {
+				if _, ok := result[id]; !ok {
+					t.Errorf("Expected %s to be selected, got %v", id, result)
+				}
+			}
+		})
+	}
+}
+
+func TestSitesAllowsAccount(t *testing.T) {
+	if !(Sites{}).AllowsAccount("anyone@example.com") {
+		t.Error("Expected every account to be allowed without an allowlist")
+	}
+
+	f := Sites{Accounts: []string{"a@example.com", "B@example.com"}}
+	for email, expected := range map[string]bool{
+		"a@example.com": true,
+		"b@example.com": true,
+		"A@EXAMPLE.COM": true,
+		"c@example.com": false,
+		"12345678":      false,
+		"":              false,
+	} {
+		if got := f.AllowsAccount(email); got != expected {
+			t.Errorf("AllowsAccount(%q) = %v, expected %v", email, got, expected)
+		}
+	}
+}
+
+func TestSitesMissing(t *testing.T) {
+	f := Sites{Names: []string{"site-b", "site-typo", "site-a"}}
+	found := map[string]bool{"site-a": true, "site-b": true, "site-other": true}
+
+	missing := f.Missing(found)
+
+	expected := []string{"site-typo"}
+	if !reflect.DeepEqual(missing, expected) {
+		t.Errorf("Expected missing %v, got %v", expected, missing)
+	}
+}
@@ -0,0 +1,53 @@
+package pantheon
+
+import "fmt"
+
+// Values for the account label, choosing what identifies an account in metrics.
+const (
+	AccountLabelEmail       = "email"        // The account email from whoami (default)
+	AccountLabelUserID      = "user_id"      // The Pantheon user ID returned at login
+	AccountLabelTokenSuffix = "token_suffix" // The last 8 characters of the machine token, as returned by GetAccountID
+)
+
+// ValidateAccountLabel returns an error unless label is one of the AccountLabel values.
+func ValidateAccountLabel(label string) error {
+	switch label {
+	case AccountLabelEmail, AccountLabelUserID, AccountLabelTokenSuffix:
+		return nil
+	}
+	return fmt.Errorf("invalid account label %q: expected %s, %s or %s",
+		label, AccountLabelEmail, AccountLabelUserID, AccountLabelTokenSuffix)
+}
+
+// accountFor returns the value identifying session's account for label. An
+// empty label is treated as AccountLabelEmail.
+func accountFor(label string, session *Session) string {
+	switch label {
+	case AccountLabelUserID:
+		return session.UserID
+	case AccountLabelTokenSuffix:
+		return GetAccountID(session.MachineToken)
+	}
+	return session.Email
+}
+
+// SetAccountLabel chooses what Authenticate returns to identify an account,
+// and so the value of the account label on every metric. Email is the
+// default; user IDs and token suffixes are stable if an account's email
+// changes, and keep emails out of the metrics. It applies to sessions
+// authenticated after this call.
+func (c *Client) SetAccountLabel(label string) error {
+	if err := ValidateAccountLabel(label); err != nil {
+		return err
+	}
+	c.sessionManager.setAccountLabel(label)
+	return nil
+}
+
+// SetTokenAliases identifies the accounts of the given machine tokens by an
+// alias instead of the account label, e.g. from ParseAliasedTokens. Tokens
+// without an alias keep the account label. It applies to sessions
+// authenticated after this call.
+func (c *Client) SetTokenAliases(aliases map[string]string) {
+	c.sessionManager.setTokenAliases(aliases)
+}
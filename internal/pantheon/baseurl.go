@@ -0,0 +1,39 @@
+package pantheon
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/deviantintegral/terminus-golang/pkg/api"
+)
+
+// DefaultAPIBaseURL is the public Pantheon API, used unless SetAPIBaseURL overrides it.
+const DefaultAPIBaseURL = api.DefaultBaseURL
+
+// SetBaseURL sets the Pantheon API base URL used by sessions created after
+// this call. An empty baseURL uses the terminus-golang default.
+func (sm *SessionManager) SetBaseURL(baseURL string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.baseURL = baseURL
+}
+
+// SetAPIBaseURL sends all Pantheon API requests to baseURL instead of the
+// public API, e.g. a mock server such as "http://localhost:8081/api". An empty
+// baseURL uses the public API. It must be called before any account is
+// authenticated.
+func (c *Client) SetAPIBaseURL(baseURL string) error {
+	if baseURL != "" {
+		parsed, err := url.Parse(baseURL)
+		if err != nil {
+			return fmt.Errorf("invalid API base URL %q: %w", baseURL, err)
+		}
+		if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid API base URL %q: must be an http or https URL with a host", baseURL)
+		}
+	}
+	// Request paths start with a slash, so a trailing one would be doubled
+	c.sessionManager.SetBaseURL(strings.TrimSuffix(baseURL, "/"))
+	return nil
+}
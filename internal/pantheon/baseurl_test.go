@@ -0,0 +1,40 @@
+package pantheon
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestSetAPIBaseURL(t *testing.T) {
+	server := newAuthServer(t, http.StatusOK)
+	client := NewClient(false)
+	if err := client.SetAPIBaseURL(server.URL + "/"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if client.sessionManager.baseURL != server.URL {
+		t.Errorf("Expected base URL %q without the trailing slash, got %q", server.URL, client.sessionManager.baseURL)
+	}
+
+	email, err := client.Authenticate(context.Background(), "abcdefgh12345678")
+	if err != nil {
+		t.Fatalf("Expected authentication against the configured base URL, got %v", err)
+	}
+	if email != "user@example.com" {
+		t.Errorf("Expected the email served by the stub server, got %q", email)
+	}
+}
+
+func TestSetAPIBaseURLInvalid(t *testing.T) {
+	for _, value := range []string{"localhost:8081", "ftp://example.com/api", "http://", "://bad"} {
+		client := NewClient(false)
+		if err := client.SetAPIBaseURL(value); err == nil {
+			t.Errorf("Expected %q to be invalid", value)
+		}
+	}
+
+	client := NewClient(false)
+	if err := client.SetAPIBaseURL(""); err != nil || client.sessionManager.baseURL != "" {
+		t.Errorf("Expected an empty base URL to use the default, got %q (err %v)", client.sessionManager.baseURL, err)
+	}
+}
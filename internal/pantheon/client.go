@@ -7,23 +7,151 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/deviantintegral/terminus-golang/pkg/api"
+	"github.com/deviantintegral/terminus-golang/pkg/api/models"
 )
 
+// DefaultOrgConcurrency is how many organizations' site lists are fetched at once.
+const DefaultOrgConcurrency = 4
+
 // Client wraps the terminus-golang library for Pantheon API access.
 type Client struct {
 	sessionManager *SessionManager
-	debugEnabled   bool
+
+	granularity string    // Metrics granularity (daily, weekly, monthly)
+	timeRange   TimeRange // Days every metrics fetch covers, overriding its duration (zero = unset)
+
+	orgCacheMu  sync.Mutex
+	orgCache    map[string]orgCacheEntry // key: machineToken
+	orgCacheTTL time.Duration            // 0 disables caching
+	listOrgs    func(ctx context.Context, session *Session) ([]*models.Organization, error)
+
+	orgConcurrency int // Organizations whose sites are listed at once
+	listOrgSites   func(ctx context.Context, session *Session, orgID string) ([]*models.Site, error)
+
+	labelCacheMu  sync.Mutex
+	labelCache    map[string]string // key: site ID
+	fetchLabels   bool              // Look up each site's human-readable label
+	getSiteDetail func(ctx context.Context, session *Session, siteID string) (*models.Site, error)
+
+	ownerCacheMu  sync.Mutex
+	ownerCache    map[string]string // key: owner user ID
+	resolveOwners bool              // Look up each site owner's email
+	lookupUser    func(ctx context.Context, session *Session, userID string) (*models.User, error)
+
+	siteCountsMu sync.Mutex
+	siteCounts   map[string]int // Sites listed in the last successful fetch, keyed by account
+
+	tagKeys     []string // Site tags promoted to labels; tags aren't fetched when empty
+	getSiteTags func(ctx context.Context, session *Session, siteID, orgID string) ([]*models.Tag, error)
+
+	retryBudget *retryBudget // Caps API retries across all sessions
+
+	observeRequest func(operation string, duration time.Duration) // Optional API latency observer
+	now            func() time.Time
+}
+
+// orgCacheEntry holds a cached organization list for one token.
+type orgCacheEntry struct {
+	orgs      []*models.Organization
+	fetchedAt time.Time
 }
 
 // NewClient creates a new Pantheon API client.
 // If debug is true, HTTP requests and responses will be logged to stderr.
 func NewClient(debug bool) *Client {
+	budget := newRetryBudget(DefaultRetryBudget)
+	sessionManager := NewSessionManager(debug)
+	sessionManager.budget = budget
 	return &Client{
-		sessionManager: NewSessionManager(debug),
-		debugEnabled:   debug,
+		sessionManager: sessionManager,
+		retryBudget:    budget,
+		granularity:    GranularityDaily,
+		orgCache:       make(map[string]orgCacheEntry),
+		listOrgs:       listOrganizations,
+		orgConcurrency: DefaultOrgConcurrency,
+		listOrgSites:   listOrganizationSites,
+		labelCache:     make(map[string]string),
+		getSiteDetail:  getSite,
+		getSiteTags:    getTags,
+		ownerCache:     make(map[string]string),
+		lookupUser:     getUser,
+		siteCounts:     make(map[string]int),
+		now:            time.Now,
+	}
+}
+
+// SetGranularity sets the granularity of fetched metrics (daily, weekly, or monthly).
+func (c *Client) SetGranularity(granularity string) error {
+	if err := ValidateGranularity(granularity); err != nil {
+		return err
+	}
+	c.granularity = granularity
+	return nil
+}
+
+// SetOrgCacheTTL sets how long each token's organization list is cached.
+// A TTL of 0 disables caching.
+func (c *Client) SetOrgCacheTTL(ttl time.Duration) {
+	c.orgCacheMu.Lock()
+	defer c.orgCacheMu.Unlock()
+	c.orgCacheTTL = ttl
+}
+
+// SetOrgConcurrency sets how many organizations' site lists are fetched at
+// once when listing all sites. Values below 1 fetch one organization at a time.
+func (c *Client) SetOrgConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	c.orgConcurrency = concurrency
+}
+
+// InvalidateOrgCache discards all cached organization lists, so the next
+// site list fetch for each token lists its organizations again.
+func (c *Client) InvalidateOrgCache() {
+	c.orgCacheMu.Lock()
+	defer c.orgCacheMu.Unlock()
+	c.orgCache = make(map[string]orgCacheEntry)
+}
+
+// listOrganizations lists the organizations the session's user belongs to.
+func listOrganizations(ctx context.Context, session *Session) ([]*models.Organization, error) {
+	orgsService := api.NewOrganizationsService(session.Client)
+	return orgsService.List(ctx, session.UserID)
+}
+
+// listOrganizationSites lists the sites of a single organization.
+func listOrganizationSites(ctx context.Context, session *Session, orgID string) ([]*models.Site, error) {
+	sitesService := api.NewSitesService(session.Client)
+	return sitesService.ListByOrganization(ctx, orgID)
+}
+
+// getOrganizations returns the organizations for a session, using the cache when fresh.
+func (c *Client) getOrganizations(ctx context.Context, session *Session) ([]*models.Organization, error) {
+	c.orgCacheMu.Lock()
+	ttl := c.orgCacheTTL
+	entry, ok := c.orgCache[session.MachineToken]
+	c.orgCacheMu.Unlock()
+
+	if ttl > 0 && ok && c.now().Sub(entry.fetchedAt) < ttl {
+		return entry.orgs, nil
+	}
+
+	orgs, err := c.listOrgs(ctx, session)
+	if err != nil {
+		return nil, err
 	}
+
+	if ttl > 0 {
+		c.orgCacheMu.Lock()
+		c.orgCache[session.MachineToken] = orgCacheEntry{orgs: orgs, fetchedAt: c.now()}
+		c.orgCacheMu.Unlock()
+	}
+	return orgs, nil
 }
 
 // GetAccountID returns an account identifier from a machine token (last 8 chars).
@@ -36,14 +164,16 @@ func GetAccountID(token string) string {
 	return token
 }
 
-// Authenticate authenticates with a machine token and returns the account email.
+// Authenticate authenticates with a machine token and returns the account,
+// identified by email unless SetAccountLabel chose otherwise.
 func (c *Client) Authenticate(ctx context.Context, machineToken string) (string, error) {
+	defer c.observe(OperationAuthenticate, c.now())
 	log.Printf("Authenticating with machine token...")
 	session, err := c.sessionManager.Authenticate(ctx, machineToken)
 	if err != nil {
 		return "", err
 	}
-	return session.Email, nil
+	return session.Account, nil
 }
 
 // GetEmail returns the email for the given machine token (cached from session).
@@ -65,6 +195,8 @@ func getOrgDisplayName(orgID, orgLabel string) string {
 // 1. Sites from direct user memberships
 // 2. Sites from all organizations the user is a member of
 func (c *Client) FetchAllSites(ctx context.Context, machineToken string, orgID string) (map[string]SiteListEntry, error) {
+	defer c.observe(OperationFetchAllSites, c.now())
+
 	if orgID != "" {
 		log.Printf("Fetching sites from organization %s...", orgID)
 	} else {
@@ -81,51 +213,107 @@ func (c *Client) FetchAllSites(ctx context.Context, machineToken string, orgID s
 
 	// If orgID is specified, only fetch sites from that organization
 	if orgID != "" {
-		return c.fetchSitesFromOrg(ctx, sitesService, orgID, siteMap)
+		if _, err := c.fetchSitesFromOrg(ctx, sitesService, orgID, siteMap); err != nil {
+			return nil, err
+		}
+		c.applyLabels(ctx, session, siteMap)
+		c.applyTags(ctx, session, siteMap)
+		c.applyOwners(ctx, session, siteMap)
+		c.recordSiteCount(session.Account, len(siteMap))
+		return siteMap, nil
 	}
 
 	// Fetch sites from direct user memberships
 	userSites, err := sitesService.List(ctx, session.UserID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list user sites: %w", err)
+		return nil, fmt.Errorf("failed to list user sites: %w", classifyError(err))
 	}
 	for _, site := range userSites {
-		siteMap[site.ID] = ConvertSite(site)
+		entry := ConvertSite(site)
+		entry.Source = SiteSourceUser
+		siteMap[site.ID] = entry
 	}
 	log.Printf("Found %d sites from direct user memberships", len(userSites))
 
 	// Fetch sites from user's organizations
-	c.fetchSitesFromAllOrgs(ctx, session, sitesService, siteMap)
+	c.fetchSitesFromAllOrgs(ctx, session, siteMap)
+	c.applyLabels(ctx, session, siteMap)
+	c.applyTags(ctx, session, siteMap)
+	c.applyOwners(ctx, session, siteMap)
 
 	log.Printf("Total unique sites found: %d", len(siteMap))
+	c.recordSiteCount(session.Account, len(siteMap))
 	return siteMap, nil
 }
 
+// recordSiteCount records how many sites were listed for an account.
+func (c *Client) recordSiteCount(account string, count int) {
+	c.siteCountsMu.Lock()
+	defer c.siteCountsMu.Unlock()
+	c.siteCounts[account] = count
+}
+
+// SiteCounts returns the number of sites listed for each account by its last
+// successful site list fetch, before any site filters are applied.
+func (c *Client) SiteCounts() map[string]int {
+	c.siteCountsMu.Lock()
+	defer c.siteCountsMu.Unlock()
+
+	counts := make(map[string]int, len(c.siteCounts))
+	for account, count := range c.siteCounts {
+		counts[account] = count
+	}
+	return counts
+}
+
 // fetchSitesFromOrg fetches sites from a specific organization.
 func (c *Client) fetchSitesFromOrg(ctx context.Context, sitesService *api.SitesService, orgID string, siteMap map[string]SiteListEntry) (map[string]SiteListEntry, error) {
 	orgSites, err := sitesService.ListByOrganization(ctx, orgID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list sites for organization %s: %w", orgID, err)
+		return nil, fmt.Errorf("failed to list sites for organization %s: %w", orgID, classifyError(err))
 	}
 	for _, site := range orgSites {
-		siteMap[site.ID] = ConvertSite(site)
+		siteMap[site.ID] = convertOrgSite(site, orgID)
 	}
 	log.Printf("Found %d sites from organization %s", len(orgSites), orgID)
 	return siteMap, nil
 }
 
-// fetchSitesFromAllOrgs fetches sites from all organizations the user belongs to.
-func (c *Client) fetchSitesFromAllOrgs(ctx context.Context, session *Session, sitesService *api.SitesService, siteMap map[string]SiteListEntry) {
-	orgsService := api.NewOrganizationsService(session.Client)
-	orgs, err := orgsService.List(ctx, session.UserID)
+// fetchSitesFromAllOrgs fetches sites from all organizations the user belongs
+// to, up to orgConcurrency organizations at a time. Results are merged in
+// organization order once every fetch has finished, so sites already in
+// siteMap are kept and a site in several organizations is always attributed to
+// the first one listed.
+func (c *Client) fetchSitesFromAllOrgs(ctx context.Context, session *Session, siteMap map[string]SiteListEntry) {
+	orgs, err := c.getOrganizations(ctx, session)
 	if err != nil {
 		log.Printf("Warning: failed to list user organizations: %v", err)
 		return
 	}
 
 	log.Printf("Found %d organizations", len(orgs))
-	for _, org := range orgs {
-		orgSites, err := sitesService.ListByOrganization(ctx, org.ID)
+
+	type orgResult struct {
+		sites []*models.Site
+		err   error
+	}
+	results := make([]orgResult, len(orgs))
+	slots := make(chan struct{}, c.orgConcurrency)
+	var wg sync.WaitGroup
+	for i, org := range orgs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, orgID string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			sites, err := c.listOrgSites(ctx, session, orgID)
+			results[i] = orgResult{sites: sites, err: err}
+		}(i, org.ID)
+	}
+	wg.Wait()
+
+	for i, org := range orgs {
+		orgSites, err := results[i].sites, results[i].err
 		if err != nil {
 			log.Printf("Warning: failed to list sites for organization %s: %v", getOrgDisplayName(org.ID, org.Label), err)
 			continue
@@ -134,7 +322,7 @@ func (c *Client) fetchSitesFromAllOrgs(ctx context.Context, session *Session, si
 		orgSiteCount := 0
 		for _, site := range orgSites {
 			if _, exists := siteMap[site.ID]; !exists {
-				siteMap[site.ID] = ConvertSite(site)
+				siteMap[site.ID] = convertOrgSite(site, org.ID)
 				orgSiteCount++
 			}
 		}
@@ -146,7 +334,18 @@ func (c *Client) fetchSitesFromAllOrgs(ctx context.Context, session *Session, si
 
 // FetchMetricsData fetches metrics data for a site.
 // duration should be "28d" for initial fetch or "1d" for subsequent refreshes.
+// The duration is translated into whole periods of the client's granularity.
+// If a time range is set, it replaces duration and only samples within it are returned.
 func (c *Client) FetchMetricsData(ctx context.Context, machineToken, siteID, environment, duration string) (map[string]MetricData, error) {
+	if !c.timeRange.IsZero() {
+		duration = c.timeRange.duration(c.now())
+	}
+	duration, err := translateDuration(duration, c.granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.observe(OperationFetchMetrics, c.now())
 	log.Printf("Fetching metrics for site %s.%s (duration: %s)...", siteID, environment, duration)
 
 	session, err := c.sessionManager.GetSession(ctx, machineToken)
@@ -157,17 +356,50 @@ func (c *Client) FetchMetricsData(ctx context.Context, machineToken, siteID, env
 	envsService := api.NewEnvironmentsService(session.Client)
 	metrics, err := envsService.GetMetrics(ctx, siteID, environment, duration)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch metrics: %w", err)
+		return nil, fmt.Errorf("failed to fetch metrics: %w", classifyError(err))
 	}
 
+	if !c.timeRange.IsZero() {
+		return c.timeRange.filter(ConvertMetricsToMap(metrics)), nil
+	}
 	return ConvertMetricsToMap(metrics), nil
 }
 
+// FetchSiteInfo fetches detailed information for a single site.
+func (c *Client) FetchSiteInfo(ctx context.Context, machineToken, siteID string) (*SiteInfo, error) {
+	session, err := c.sessionManager.GetSession(ctx, machineToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	site, err := c.getSiteDetail(ctx, session, siteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch site info: %w", classifyError(err))
+	}
+	return ConvertSiteInfo(site), nil
+}
+
 // InvalidateSession removes a session, forcing re-authentication on next use.
 func (c *Client) InvalidateSession(machineToken string) {
 	c.sessionManager.InvalidateSession(machineToken)
 }
 
+// SessionCount returns the number of authenticated sessions held by the client.
+func (c *Client) SessionCount() int {
+	return c.sessionManager.SessionCount()
+}
+
+// SessionAges returns the age of each authenticated session keyed by account email.
+func (c *Client) SessionAges() map[string]time.Duration {
+	return c.sessionManager.SessionAges()
+}
+
+// SessionsDegraded reports, for each authenticated account, whether its email
+// lookup failed after a successful login.
+func (c *Client) SessionsDegraded() map[string]bool {
+	return c.sessionManager.SessionsDegraded()
+}
+
 // ----- Test helper functions (kept for testing with JSON files) -----
 
 // parseMetricsData parses metrics JSON data
@@ -2,9 +2,18 @@ package pantheon
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/deviantintegral/terminus-golang/pkg/api/models"
 )
 
 const (
@@ -535,3 +544,289 @@ func TestGetOrgDisplayName(t *testing.T) {
 		})
 	}
 }
+
+func TestGetOrganizationsCachesWithinTTL(t *testing.T) {
+	client := NewClient(false)
+	client.SetOrgCacheTTL(time.Hour)
+	now := time.Unix(1762732800, 0)
+	client.now = func() time.Time { return now }
+
+	calls := 0
+	client.listOrgs = func(_ context.Context, _ *Session) ([]*models.Organization, error) {
+		calls++
+		return []*models.Organization{{ID: "org-1", Label: "Org One"}}, nil
+	}
+
+	ctx := context.Background()
+	session := &Session{MachineToken: "token-a", UserID: "user-a"}
+
+	for i := 0; i < 2; i++ {
+		orgs, err := client.getOrganizations(ctx, session)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(orgs) != 1 || orgs[0].ID != "org-1" {
+			t.Errorf("Expected cached org-1, got %v", orgs)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected org list to be fetched once within TTL, got %d calls", calls)
+	}
+
+	// A different token has its own cache entry
+	if _, err := client.getOrganizations(ctx, &Session{MachineToken: "token-b"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected a separate fetch for another token, got %d calls", calls)
+	}
+
+	// An entry older than the TTL is refetched
+	now = now.Add(time.Hour)
+	if _, err := client.getOrganizations(ctx, session); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected refetch after the TTL expired, got %d calls", calls)
+	}
+
+	// Invalidation forces a refetch within the TTL
+	client.InvalidateOrgCache()
+	if _, err := client.getOrganizations(ctx, session); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls != 4 {
+		t.Errorf("Expected refetch after invalidation, got %d calls", calls)
+	}
+}
+
+func TestGetOrganizationsCacheDisabled(t *testing.T) {
+	client := NewClient(false)
+
+	calls := 0
+	client.listOrgs = func(_ context.Context, _ *Session) ([]*models.Organization, error) {
+		calls++
+		return nil, nil
+	}
+
+	ctx := context.Background()
+	session := &Session{MachineToken: "token-a"}
+	for i := 0; i < 2; i++ {
+		if _, err := client.getOrganizations(ctx, session); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("Expected every call to fetch with caching disabled, got %d calls", calls)
+	}
+}
+
+func TestGetOrganizationsErrorNotCached(t *testing.T) {
+	client := NewClient(false)
+	client.SetOrgCacheTTL(time.Hour)
+
+	calls := 0
+	client.listOrgs = func(_ context.Context, _ *Session) ([]*models.Organization, error) {
+		calls++
+		return nil, errors.New("api unavailable")
+	}
+
+	ctx := context.Background()
+	session := &Session{MachineToken: "token-a"}
+	for i := 0; i < 2; i++ {
+		if _, err := client.getOrganizations(ctx, session); err == nil {
+			t.Fatal("Expected error from org list")
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("Expected failed fetches not to be cached, got %d calls", calls)
+	}
+}
+
+func TestFetchSitesFromAllOrgsConcurrent(t *testing.T) {
+	client := NewClient(false)
+	client.SetOrgConcurrency(2)
+
+	var orgs []*models.Organization
+	for i := 0; i < 6; i++ {
+		orgs = append(orgs, &models.Organization{ID: fmt.Sprintf("org-%d", i)})
+	}
+	client.listOrgs = func(_ context.Context, _ *Session) ([]*models.Organization, error) {
+		return orgs, nil
+	}
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	client.listOrgSites = func(_ context.Context, _ *Session, orgID string) ([]*models.Site, error) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		if orgID == "org-5" {
+			return nil, errors.New("api unavailable")
+		}
+		// Every organization shares site "shared" and the direct membership site
+		return []*models.Site{
+			{ID: "site-" + orgID, Name: "site-" + orgID},
+			{ID: "shared", Name: "shared"},
+			{ID: "direct", Name: "direct"},
+		}, nil
+	}
+
+	siteMap := map[string]SiteListEntry{"direct": {ID: "direct", Name: "direct"}}
+	client.fetchSitesFromAllOrgs(context.Background(), &Session{MachineToken: "token-a"}, siteMap)
+
+	// 5 organization sites, plus shared and direct; org-5 failed
+	if len(siteMap) != 7 {
+		t.Errorf("Expected 7 sites, got %d: %v", len(siteMap), siteMap)
+	}
+	for i := 0; i < 5; i++ {
+		if _, ok := siteMap[fmt.Sprintf("site-org-%d", i)]; !ok {
+			t.Errorf("Expected site from org-%d to be merged", i)
+		}
+	}
+	if got := siteMap["shared"].Organization; got != "org-0" {
+		t.Errorf("Expected a site in several organizations to belong to the first, got %q", got)
+	}
+	if got := siteMap["direct"].Organization; got != "" {
+		t.Errorf("Expected the direct membership site to be kept, got organization %q", got)
+	}
+	if maxRunning > 2 {
+		t.Errorf("Expected at most 2 concurrent fetches, got %d", maxRunning)
+	}
+	if maxRunning < 2 {
+		t.Errorf("Expected organizations to be fetched concurrently, got %d at once", maxRunning)
+	}
+}
+
+// TestFetchAllSitesPaginated checks that sites spread over several API pages are all
+// merged. Pagination is handled by terminus-golang, which requests pages of 100
+// sites using the ID of the last site as the cursor.
+func TestFetchAllSitesPaginated(t *testing.T) {
+	const totalSites = 250
+	var sites []map[string]interface{}
+	for i := 0; i < totalSites; i++ {
+		sites = append(sites, map[string]interface{}{
+			"id":   fmt.Sprintf("membership-%03d", i),
+			"site": map[string]string{"id": fmt.Sprintf("site-%03d", i), "name": fmt.Sprintf("site%03d", i)},
+		})
+	}
+
+	pages := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/authorize/machine-token":
+			_ = json.NewEncoder(w).Encode(map[string]string{"session": "session-123", "user_id": "user-456"})
+		case "/users/user-456":
+			_ = json.NewEncoder(w).Encode(map[string]string{"id": "user-456", "email": "user@example.com"})
+		case "/organizations/org-1/memberships/sites":
+			pages++
+			start := 0
+			if cursor := r.URL.Query().Get("start"); cursor != "" {
+				for i, site := range sites {
+					if site["id"] == cursor {
+						start = i + 1
+					}
+				}
+			}
+			end := start + 100
+			if end > len(sites) {
+				end = len(sites)
+			}
+			_ = json.NewEncoder(w).Encode(sites[start:end])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(false)
+	client.sessionManager.baseURL = server.URL
+
+	siteMap, err := client.FetchAllSites(context.Background(), "abcdefgh12345678", "org-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if pages != 3 {
+		t.Errorf("Expected 3 pages to be requested, got %d", pages)
+	}
+	if len(siteMap) != totalSites {
+		t.Errorf("Expected all %d sites to be merged, got %d", totalSites, len(siteMap))
+	}
+	for _, siteID := range []string{"site-000", "site-100", "site-249"} {
+		if _, ok := siteMap[siteID]; !ok {
+			t.Errorf("Expected %s in site map", siteID)
+		}
+	}
+	if count := client.SiteCounts()["user@example.com"]; count != totalSites {
+		t.Errorf("Expected site count %d for the account, got %d", totalSites, count)
+	}
+}
+
+func TestFetchAllSitesSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/authorize/machine-token":
+			_ = json.NewEncoder(w).Encode(map[string]string{"session": "session-123", "user_id": "user-456"})
+		case "/users/user-456":
+			_ = json.NewEncoder(w).Encode(map[string]string{"id": "user-456", "email": "user@example.com"})
+		case "/users/user-456/memberships/sites":
+			_ = json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"id": "membership-1", "site": map[string]string{"id": "direct", "name": "direct"}},
+			})
+		case "/organizations/org-1/memberships/sites":
+			_ = json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"id": "membership-2", "site": map[string]string{"id": "org-site", "name": "org-site"}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(false)
+	client.sessionManager.baseURL = server.URL
+	client.listOrgs = func(_ context.Context, _ *Session) ([]*models.Organization, error) {
+		return []*models.Organization{{ID: "org-1"}}, nil
+	}
+	client.listOrgSites = func(_ context.Context, _ *Session, _ string) ([]*models.Site, error) {
+		return []*models.Site{
+			{ID: "direct", Name: "direct"},
+			{ID: "org-site", Name: "org-site"},
+		}, nil
+	}
+
+	siteMap, err := client.FetchAllSites(context.Background(), "abcdefgh12345678", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := siteMap["direct"].Source; got != SiteSourceUser {
+		t.Errorf("Expected a direct membership site to have source %q, got %q", SiteSourceUser, got)
+	}
+	if got := siteMap["org-site"].Source; got != SiteSourceOrg {
+		t.Errorf("Expected an organization site to have source %q, got %q", SiteSourceOrg, got)
+	}
+
+	siteMap, err = client.FetchAllSites(context.Background(), "abcdefgh12345678", "org-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for id, site := range siteMap {
+		if site.Source != SiteSourceOrg {
+			t.Errorf("Expected site %s from -orgID to have source %q, got %q", id, SiteSourceOrg, site.Source)
+		}
+	}
+}
@@ -9,18 +9,30 @@ import (
 // ConvertSite converts a library Site to our SiteListEntry.
 func ConvertSite(site *models.Site) SiteListEntry {
 	return SiteListEntry{
-		Name:      site.Name,
-		ID:        site.ID,
-		PlanName:  site.PlanName,
-		Framework: site.Framework,
-		Region:    site.PreferredZoneLabel,
-		Owner:     site.Owner,
-		Created:   site.Created,
-		Frozen:    site.Frozen || site.IsFrozen,
+		Name:         site.Name,
+		ID:           site.ID,
+		PlanName:     site.PlanName,
+		Framework:    site.Framework,
+		Region:       site.PreferredZoneLabel,
+		Owner:        site.Owner,
+		Organization: site.Organization,
+		Created:      site.Created,
+		Frozen:       site.Frozen || site.IsFrozen,
 		// Memberships field would need formatting from MembershipUserID and MembershipRole
 	}
 }
 
+// convertOrgSite converts a site listed from an organization, recording the
+// organization when the API didn't include it.
+func convertOrgSite(site *models.Site, orgID string) SiteListEntry {
+	entry := ConvertSite(site)
+	entry.Source = SiteSourceOrg
+	if entry.Organization == "" {
+		entry.Organization = orgID
+	}
+	return entry
+}
+
 // ConvertSiteListItem converts a library SiteListItem to our SiteListEntry.
 func ConvertSiteListItem(site *models.SiteListItem) SiteListEntry {
 	return SiteListEntry{
@@ -58,6 +70,26 @@ func ConvertMetricsToMap(metrics []*models.Metrics) map[string]MetricData {
 	return result
 }
 
+// ConvertSiteInfo converts a library Site to our SiteInfo.
+func ConvertSiteInfo(site *models.Site) *SiteInfo {
+	return &SiteInfo{
+		ID:           site.ID,
+		Name:         site.Name,
+		Label:        site.Label,
+		Created:      strconv.FormatInt(site.Created, 10),
+		Framework:    site.Framework,
+		Organization: site.Organization,
+		ServiceLevel: site.Service,
+		Upstream:     site.UpstreamLabel,
+		PHPVersion:   site.PHP,
+		HolderType:   site.Holder,
+		HolderID:     site.HolderID,
+		Owner:        site.Owner,
+		Frozen:       site.Frozen || site.IsFrozen,
+		PlanName:     site.PlanName,
+	}
+}
+
 // ConvertSitesToMap converts a slice of library Sites to our map format.
 // The map keys are site IDs.
 func ConvertSitesToMap(sites []*models.Site) map[string]SiteListEntry {
@@ -1,6 +1,7 @@
 package pantheon
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/deviantintegral/terminus-golang/pkg/api/models"
@@ -141,6 +142,28 @@ func TestConvertMetrics(t *testing.T) {
 	}
 }
 
+// TestConvertMetricsCoversAllFields fails when terminus-golang adds a field to
+// models.Metrics, so new traffic data is mapped into MetricData rather than
+// silently discarded.
+func TestConvertMetricsCoversAllFields(t *testing.T) {
+	mapped := map[string]bool{
+		"Timestamp":     true, // Key of ConvertMetricsToMap
+		"Datetime":      true,
+		"Visits":        true,
+		"PagesServed":   true,
+		"CacheHits":     true,
+		"CacheMisses":   true,
+		"CacheHitRatio": true,
+	}
+
+	fields := reflect.TypeOf(models.Metrics{})
+	for i := 0; i < fields.NumField(); i++ {
+		if name := fields.Field(i).Name; !mapped[name] {
+			t.Errorf("models.Metrics.%s is not converted to MetricData", name)
+		}
+	}
+}
+
 func TestConvertMetricsToMap(t *testing.T) {
 	metrics := []*models.Metrics{
 		{
@@ -283,3 +306,44 @@ func TestConvertMetricsZeroValues(t *testing.T) {
 		t.Errorf("Expected CacheHitRatio='0%%', got '%s'", result.CacheHitRatio)
 	}
 }
+
+func TestConvertSiteInfo(t *testing.T) {
+	site := &models.Site{
+		ID:            "site-uuid-1234",
+		Name:          "acme-prod",
+		Label:         "Acme Corp Production",
+		Created:       1705276800,
+		Framework:     "drupal10",
+		UpstreamLabel: "Drupal 10",
+		PlanName:      "Basic",
+		IsFrozen:      true,
+	}
+
+	result := ConvertSiteInfo(site)
+
+	if result.Label != "Acme Corp Production" {
+		t.Errorf("Expected Label='Acme Corp Production', got '%s'", result.Label)
+	}
+	if result.Created != "1705276800" {
+		t.Errorf("Expected Created='1705276800', got '%s'", result.Created)
+	}
+	if result.Upstream != "Drupal 10" {
+		t.Errorf("Expected Upstream='Drupal 10', got '%s'", result.Upstream)
+	}
+	if !result.Frozen {
+		t.Error("Expected Frozen=true when IsFrozen is set")
+	}
+}
+
+func TestSortedSiteIDs(t *testing.T) {
+	sites := map[string]SiteListEntry{
+		"uuid-3": {Name: "charlie"},
+		"uuid-1": {Name: "alpha"},
+		"uuid-b": {Name: "bravo"},
+		"uuid-a": {Name: "bravo"},
+	}
+	expected := []string{"uuid-1", "uuid-a", "uuid-b", "uuid-3"}
+	if got := SortedSiteIDs(sites); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
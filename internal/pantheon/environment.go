@@ -0,0 +1,49 @@
+package pantheon
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Standard Pantheon environments available on every site.
+const (
+	EnvironmentDev  = "dev"
+	EnvironmentTest = "test"
+	EnvironmentLive = "live"
+)
+
+// multidevPattern matches Pantheon multidev environment names: up to 11
+// lowercase letters, digits, or dashes, starting with a letter.
+var multidevPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{0,10}$`)
+
+// environmentTypos maps names commonly used on other platforms to the Pantheon
+// environment that was most likely intended.
+var environmentTypos = map[string]string{
+	"prod":        EnvironmentLive,
+	"production":  EnvironmentLive,
+	"stage":       EnvironmentTest,
+	"staging":     EnvironmentTest,
+	"develop":     EnvironmentDev,
+	"development": EnvironmentDev,
+	"master":      EnvironmentDev,
+	"main":        EnvironmentDev,
+}
+
+// ValidateEnvironment returns an error if env is not a standard Pantheon
+// environment or a plausible multidev name.
+func ValidateEnvironment(env string) error {
+	switch env {
+	case EnvironmentDev, EnvironmentTest, EnvironmentLive:
+		return nil
+	}
+
+	if suggestion, ok := environmentTypos[env]; ok {
+		return fmt.Errorf("invalid environment %q: Pantheon calls this environment %q", env, suggestion)
+	}
+
+	if !multidevPattern.MatchString(env) {
+		return fmt.Errorf("invalid environment %q: must be %s, %s, %s, or a multidev name (up to 11 lowercase letters, digits, or dashes)",
+			env, EnvironmentDev, EnvironmentTest, EnvironmentLive)
+	}
+	return nil
+}
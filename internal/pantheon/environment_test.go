@@ -0,0 +1,17 @@
+package pantheon
+
+import "testing"
+
+func TestValidateEnvironment(t *testing.T) {
+	for _, env := range []string{EnvironmentDev, EnvironmentTest, EnvironmentLive, "feature-1", "pr-123"} {
+		if err := ValidateEnvironment(env); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", env, err)
+		}
+	}
+
+	for _, env := range []string{"", "prod", "staging", "Live", "1feature", "feature_branch", "averylongmultidev"} {
+		if err := ValidateEnvironment(env); err == nil {
+			t.Errorf("Expected %q to be invalid", env)
+		}
+	}
+}
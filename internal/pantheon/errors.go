@@ -0,0 +1,82 @@
+package pantheon
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/deviantintegral/terminus-golang/pkg/api"
+)
+
+// Sentinel errors wrapped into errors returned by Client, so callers can
+// make retry and skip decisions with errors.Is instead of matching strings.
+var (
+	// ErrAuthFailed indicates the machine token was rejected or lacks access.
+	ErrAuthFailed = errors.New("authentication failed")
+
+	// ErrForbidden indicates the token is valid but its role can't access the
+	// requested resource. It also matches ErrAuthFailed.
+	ErrForbidden = fmt.Errorf("%w: access forbidden", ErrAuthFailed)
+
+	// ErrSiteNotFound indicates the requested site or environment does not exist.
+	ErrSiteNotFound = errors.New("site not found")
+
+	// ErrRateLimited indicates the Pantheon API rejected the request due to rate limiting.
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrRetryBudgetExhausted indicates a failed request wasn't retried because
+	// the retry budget shared by all accounts was used up.
+	ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+)
+
+// classifyError wraps a terminus-golang error with the matching sentinel error.
+// Errors that don't map to a sentinel are returned unchanged.
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if sentinel := sentinelFor(err); sentinel != nil {
+		return fmt.Errorf("%w: %w", sentinel, err)
+	}
+	return err
+}
+
+// sentinelFor returns the sentinel error matching an API error, or nil.
+func sentinelFor(err error) error {
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) {
+		if apiErr.StatusCode == retryBudgetStatus && apiErr.Message == retryBudgetMessage {
+			return ErrRetryBudgetExhausted
+		}
+		switch apiErr.StatusCode {
+		case http.StatusUnauthorized:
+			return ErrAuthFailed
+		case http.StatusForbidden:
+			return ErrForbidden
+		case http.StatusNotFound:
+			return ErrSiteNotFound
+		case http.StatusTooManyRequests:
+			return ErrRateLimited
+		}
+		return nil
+	}
+
+	// terminus-golang reports exhausted retries without a typed error
+	if strings.Contains(err.Error(), fmt.Sprintf("status %d", http.StatusTooManyRequests)) {
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// classifyLoginError wraps a login error. Any client error other than rate
+// limiting means the machine token itself was rejected.
+func classifyLoginError(err error) error {
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 &&
+		apiErr.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %w", ErrAuthFailed, err)
+	}
+	return fmt.Errorf("login request failed: %w", classifyError(err))
+}
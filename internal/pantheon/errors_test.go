@@ -0,0 +1,77 @@
+package pantheon
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/deviantintegral/terminus-golang/pkg/api"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{name: "unauthorized", err: &api.Error{StatusCode: 401}, expected: ErrAuthFailed},
+		{name: "forbidden", err: &api.Error{StatusCode: 403}, expected: ErrAuthFailed},
+		{name: "forbidden role", err: &api.Error{StatusCode: 403}, expected: ErrForbidden},
+		{name: "not found", err: &api.Error{StatusCode: 404}, expected: ErrSiteNotFound},
+		{name: "rate limited", err: &api.Error{StatusCode: 429}, expected: ErrRateLimited},
+		{name: "wrapped by library", err: fmt.Errorf("failed to get metrics: %w", &api.Error{StatusCode: 404}), expected: ErrSiteNotFound},
+		{name: "retries exhausted", err: errors.New("request failed with status 429 after 4 attempts"), expected: ErrRateLimited},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Wrap the same way Client methods do
+			err := fmt.Errorf("failed to fetch metrics: %w", classifyError(tt.err))
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected errors.Is(%v, %v) to be true", err, tt.expected)
+			}
+
+			// The original API error is still reachable
+			var apiErr *api.Error
+			if errors.As(tt.err, &apiErr) && !errors.As(err, &apiErr) {
+				t.Error("Expected the original API error to remain discoverable")
+			}
+		})
+	}
+}
+
+func TestClassifyErrorUnmatched(t *testing.T) {
+	original := &api.Error{StatusCode: 500}
+	err := classifyError(original)
+
+	if err != original {
+		t.Errorf("Expected unmatched error to be returned unchanged, got %v", err)
+	}
+	for _, sentinel := range []error{ErrAuthFailed, ErrSiteNotFound, ErrRateLimited} {
+		if errors.Is(err, sentinel) {
+			t.Errorf("Expected server error not to match %v", sentinel)
+		}
+	}
+
+	if classifyError(nil) != nil {
+		t.Error("Expected nil error to stay nil")
+	}
+}
+
+func TestClassifyLoginError(t *testing.T) {
+	if err := classifyLoginError(&api.Error{StatusCode: 400}); !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("Expected rejected login to match ErrAuthFailed, got %v", err)
+	}
+	if err := classifyLoginError(&api.Error{StatusCode: 429}); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected rate limited login to match ErrRateLimited, got %v", err)
+	}
+	if err := classifyLoginError(errors.New("connection refused")); errors.Is(err, ErrAuthFailed) {
+		t.Errorf("Expected network error not to match ErrAuthFailed, got %v", err)
+	}
+}
+
+func TestClassifyErrorUnauthorizedNotForbidden(t *testing.T) {
+	if err := classifyError(&api.Error{StatusCode: 401}); errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected a rejected token not to match ErrForbidden, got %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package pantheon
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// FetchMetricsWithFallback fetches metrics for a site from environment, retrying
+// against fallbackEnv when the primary environment doesn't exist or has no data.
+// It returns the environment the metrics came from. If the fallback fetch fails
+// or is also empty, the primary environment's result is returned.
+func FetchMetricsWithFallback(ctx context.Context, client ClientInterface, machineToken, siteID, environment, fallbackEnv, duration string) (map[string]MetricData, string, error) {
+	metricsData, err := client.FetchMetricsData(ctx, machineToken, siteID, environment, duration)
+	if fallbackEnv == "" || fallbackEnv == environment {
+		return metricsData, environment, err
+	}
+	if err != nil && !errors.Is(err, ErrSiteNotFound) {
+		return metricsData, environment, err
+	}
+	if err == nil && len(metricsData) > 0 {
+		return metricsData, environment, nil
+	}
+
+	fallbackData, fallbackErr := client.FetchMetricsData(ctx, machineToken, siteID, fallbackEnv, duration)
+	if fallbackErr != nil || len(fallbackData) == 0 {
+		return metricsData, environment, err
+	}
+	log.Printf("No %s metrics for site %s, using %s environment", environment, siteID, fallbackEnv)
+	return fallbackData, fallbackEnv, nil
+}
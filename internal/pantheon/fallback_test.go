@@ -0,0 +1,107 @@
+package pantheon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// envMetricsClient is a ClientInterface returning canned metrics per environment
+type envMetricsClient struct {
+	ClientInterface
+	metrics map[string]map[string]MetricData
+	errs    map[string]error
+	calls   []string
+}
+
+func (c *envMetricsClient) FetchMetricsData(_ context.Context, _, _, environment, _ string) (map[string]MetricData, error) {
+	c.calls = append(c.calls, environment)
+	if err := c.errs[environment]; err != nil {
+		return nil, err
+	}
+	return c.metrics[environment], nil
+}
+
+func TestFetchMetricsWithFallback(t *testing.T) {
+	testData := map[string]MetricData{"1762732800": {Visits: 10}}
+	notFound := fmt.Errorf("%w: environment missing", ErrSiteNotFound)
+
+	tests := []struct {
+		name        string
+		metrics     map[string]map[string]MetricData
+		errs        map[string]error
+		fallbackEnv string
+		expectEnv   string
+		expectCalls int
+		expectErr   bool
+	}{
+		{
+			name:        "primary has data",
+			metrics:     map[string]map[string]MetricData{"live": testData, "test": testData},
+			fallbackEnv: "test",
+			expectEnv:   "live",
+			expectCalls: 1,
+		},
+		{
+			name:        "primary empty falls back",
+			metrics:     map[string]map[string]MetricData{"live": {}, "test": testData},
+			fallbackEnv: "test",
+			expectEnv:   "test",
+			expectCalls: 2,
+		},
+		{
+			name:        "primary not found falls back",
+			metrics:     map[string]map[string]MetricData{"test": testData},
+			errs:        map[string]error{"live": notFound},
+			fallbackEnv: "test",
+			expectEnv:   "test",
+			expectCalls: 2,
+		},
+		{
+			name:        "other errors don't fall back",
+			metrics:     map[string]map[string]MetricData{"test": testData},
+			errs:        map[string]error{"live": errors.New("timeout")},
+			fallbackEnv: "test",
+			expectEnv:   "live",
+			expectCalls: 1,
+			expectErr:   true,
+		},
+		{
+			name:        "no fallback configured",
+			metrics:     map[string]map[string]MetricData{"test": testData},
+			errs:        map[string]error{"live": notFound},
+			expectEnv:   "live",
+			expectCalls: 1,
+			expectErr:   true,
+		},
+		{
+			name:        "fallback also empty keeps primary result",
+			errs:        map[string]error{"live": notFound},
+			fallbackEnv: "test",
+			expectEnv:   "live",
+			expectCalls: 2,
+			expectErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &envMetricsClient{metrics: tt.metrics, errs: tt.errs}
+			data, env, err := FetchMetricsWithFallback(context.Background(), client, "token", "site-1", "live", tt.fallbackEnv, "28d")
+
+			if env != tt.expectEnv {
+				t.Errorf("Expected environment %q, got %q", tt.expectEnv, env)
+			}
+			if len(client.calls) != tt.expectCalls {
+				t.Errorf("Expected %d fetches, got %v", tt.expectCalls, client.calls)
+			}
+			if (err != nil) != tt.expectErr {
+				t.Errorf("Expected error %v, got %v", tt.expectErr, err)
+			}
+			if !tt.expectErr && len(data) == 0 {
+				t.Error("Expected metrics data")
+			}
+		})
+	}
+}
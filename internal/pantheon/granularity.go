@@ -0,0 +1,51 @@
+package pantheon
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Metrics granularities supported by the Pantheon traffic API.
+const (
+	GranularityDaily   = "daily"
+	GranularityWeekly  = "weekly"
+	GranularityMonthly = "monthly"
+)
+
+// granularityUnits maps each granularity to its duration suffix and length in days.
+var granularityUnits = map[string]struct {
+	suffix string
+	days   int
+}{
+	GranularityDaily:   {suffix: "d", days: 1},
+	GranularityWeekly:  {suffix: "w", days: 7},
+	GranularityMonthly: {suffix: "m", days: 30},
+}
+
+// ValidateGranularity returns an error if granularity is not a supported value.
+func ValidateGranularity(granularity string) error {
+	if _, ok := granularityUnits[granularity]; !ok {
+		return fmt.Errorf("invalid granularity %q: must be one of %s, %s, %s",
+			granularity, GranularityDaily, GranularityWeekly, GranularityMonthly)
+	}
+	return nil
+}
+
+// translateDuration converts a duration in days (e.g. "28d") into the equivalent
+// number of periods at the given granularity (e.g. "4w"), rounding up so the
+// requested window is always covered. Partial periods are at least one period.
+func translateDuration(duration, granularity string) (string, error) {
+	if err := ValidateGranularity(granularity); err != nil {
+		return "", err
+	}
+
+	days, err := strconv.Atoi(strings.TrimSuffix(duration, "d"))
+	if !strings.HasSuffix(duration, "d") || err != nil || days <= 0 {
+		return "", fmt.Errorf("invalid duration %q: expected a number of days such as 28d", duration)
+	}
+
+	unit := granularityUnits[granularity]
+	periods := (days + unit.days - 1) / unit.days
+	return strconv.Itoa(periods) + unit.suffix, nil
+}
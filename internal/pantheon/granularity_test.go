@@ -0,0 +1,68 @@
+package pantheon
+
+import "testing"
+
+func TestValidateGranularity(t *testing.T) {
+	for _, granularity := range []string{GranularityDaily, GranularityWeekly, GranularityMonthly} {
+		if err := ValidateGranularity(granularity); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", granularity, err)
+		}
+	}
+
+	for _, granularity := range []string{"", "hourly", "Daily", "d"} {
+		if err := ValidateGranularity(granularity); err == nil {
+			t.Errorf("Expected %q to be invalid", granularity)
+		}
+	}
+}
+
+func TestTranslateDuration(t *testing.T) {
+	tests := []struct {
+		name        string
+		duration    string
+		granularity string
+		expected    string
+	}{
+		{name: "daily initial", duration: "28d", granularity: GranularityDaily, expected: "28d"},
+		{name: "daily refresh", duration: "1d", granularity: GranularityDaily, expected: "1d"},
+		{name: "weekly initial", duration: "28d", granularity: GranularityWeekly, expected: "4w"},
+		{name: "weekly refresh rounds up", duration: "1d", granularity: GranularityWeekly, expected: "1w"},
+		{name: "weekly partial week", duration: "10d", granularity: GranularityWeekly, expected: "2w"},
+		{name: "monthly initial", duration: "28d", granularity: GranularityMonthly, expected: "1m"},
+		{name: "monthly long window", duration: "90d", granularity: GranularityMonthly, expected: "3m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := translateDuration(tt.duration, tt.granularity)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("translateDuration(%q, %q) = %q, want %q", tt.duration, tt.granularity, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTranslateDurationInvalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		duration    string
+		granularity string
+	}{
+		{name: "invalid granularity", duration: "28d", granularity: "hourly"},
+		{name: "non-day duration", duration: "4w", granularity: GranularityWeekly},
+		{name: "zero days", duration: "0d", granularity: GranularityDaily},
+		{name: "not a number", duration: "xd", granularity: GranularityDaily},
+		{name: "empty duration", duration: "", granularity: GranularityDaily},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := translateDuration(tt.duration, tt.granularity); err == nil {
+				t.Errorf("Expected error for translateDuration(%q, %q)", tt.duration, tt.granularity)
+			}
+		})
+	}
+}
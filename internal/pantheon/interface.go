@@ -6,7 +6,7 @@ import "context"
 // ClientInterface defines the interface for Pantheon API operations.
 // This allows for mocking in tests.
 type ClientInterface interface {
-	// Authenticate authenticates with a machine token and returns the account email.
+	// Authenticate authenticates with a machine token and returns the account label value.
 	Authenticate(ctx context.Context, machineToken string) (string, error)
 
 	// GetEmail returns the email for the given machine token.
@@ -19,6 +19,13 @@ type ClientInterface interface {
 	// FetchMetricsData fetches metrics data for a site.
 	FetchMetricsData(ctx context.Context, machineToken, siteID, environment, duration string) (map[string]MetricData, error)
 
+	// FetchSiteInfo fetches detailed information for a single site.
+	FetchSiteInfo(ctx context.Context, machineToken, siteID string) (*SiteInfo, error)
+
+	// InvalidateOrgCache discards cached organization lists, so the next
+	// FetchAllSites lists organizations again.
+	InvalidateOrgCache()
+
 	// InvalidateSession removes a session, forcing re-authentication on next use.
 	InvalidateSession(machineToken string)
 }
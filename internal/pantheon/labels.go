@@ -0,0 +1,65 @@
+package pantheon
+
+import (
+	"context"
+	"log"
+
+	"github.com/deviantintegral/terminus-golang/pkg/api"
+	"github.com/deviantintegral/terminus-golang/pkg/api/models"
+)
+
+// SetFetchLabels enables looking up each site's human-readable label, which the
+// site list API doesn't return. Labels cost one extra API call per site and are
+// cached, so each site is only looked up once.
+func (c *Client) SetFetchLabels(enabled bool) {
+	c.labelCacheMu.Lock()
+	defer c.labelCacheMu.Unlock()
+	c.fetchLabels = enabled
+}
+
+// getSite fetches the details of a single site.
+func getSite(ctx context.Context, session *Session, siteID string) (*models.Site, error) {
+	sitesService := api.NewSitesService(session.Client)
+	return sitesService.Get(ctx, siteID)
+}
+
+// siteLabel returns the label for a site, fetching it on first use.
+// Failed lookups aren't cached so they are retried on the next site list refresh.
+func (c *Client) siteLabel(ctx context.Context, session *Session, siteID string) (string, error) {
+	c.labelCacheMu.Lock()
+	label, ok := c.labelCache[siteID]
+	c.labelCacheMu.Unlock()
+	if ok {
+		return label, nil
+	}
+
+	site, err := c.getSiteDetail(ctx, session, siteID)
+	if err != nil {
+		return "", classifyError(err)
+	}
+
+	c.labelCacheMu.Lock()
+	c.labelCache[siteID] = site.Label
+	c.labelCacheMu.Unlock()
+	return site.Label, nil
+}
+
+// applyLabels populates the Label of each site when label fetching is enabled.
+func (c *Client) applyLabels(ctx context.Context, session *Session, siteMap map[string]SiteListEntry) {
+	c.labelCacheMu.Lock()
+	enabled := c.fetchLabels
+	c.labelCacheMu.Unlock()
+	if !enabled {
+		return
+	}
+
+	for siteID, site := range siteMap {
+		label, err := c.siteLabel(ctx, session, siteID)
+		if err != nil {
+			log.Printf("Warning: failed to fetch label for site %s: %v", site.Name, err)
+			continue
+		}
+		site.Label = label
+		siteMap[siteID] = site
+	}
+}
@@ -0,0 +1,93 @@
+package pantheon
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/deviantintegral/terminus-golang/pkg/api/models"
+)
+
+func TestApplyLabelsDisabled(t *testing.T) {
+	client := NewClient(false)
+
+	calls := 0
+	client.getSiteDetail = func(_ context.Context, _ *Session, _ string) (*models.Site, error) {
+		calls++
+		return &models.Site{Label: "Acme Corp Production"}, nil
+	}
+
+	siteMap := map[string]SiteListEntry{"site-1": {Name: "acme-prod", ID: "site-1"}}
+	client.applyLabels(context.Background(), &Session{}, siteMap)
+
+	if calls != 0 {
+		t.Errorf("Expected no site lookups when label fetching is disabled, got %d", calls)
+	}
+	if label := siteMap["site-1"].DisplayLabel(); label != "acme-prod" {
+		t.Errorf("Expected label to fall back to site name, got %q", label)
+	}
+}
+
+func TestApplyLabelsCachesPerSite(t *testing.T) {
+	client := NewClient(false)
+	client.SetFetchLabels(true)
+
+	calls := map[string]int{}
+	client.getSiteDetail = func(_ context.Context, _ *Session, siteID string) (*models.Site, error) {
+		calls[siteID]++
+		return &models.Site{ID: siteID, Label: "Label for " + siteID}, nil
+	}
+
+	ctx := context.Background()
+	session := &Session{}
+	for i := 0; i < 3; i++ {
+		siteMap := map[string]SiteListEntry{
+			"site-1": {Name: "one", ID: "site-1"},
+			"site-2": {Name: "two", ID: "site-2"},
+		}
+		client.applyLabels(ctx, session, siteMap)
+
+		if label := siteMap["site-1"].Label; label != "Label for site-1" {
+			t.Errorf("Expected fetched label, got %q", label)
+		}
+	}
+
+	for _, siteID := range []string{"site-1", "site-2"} {
+		if calls[siteID] != 1 {
+			t.Errorf("Expected %s to be looked up once, got %d", siteID, calls[siteID])
+		}
+	}
+
+	// A newly discovered site is looked up without refetching known ones
+	siteMap := map[string]SiteListEntry{
+		"site-1": {Name: "one", ID: "site-1"},
+		"site-3": {Name: "three", ID: "site-3"},
+	}
+	client.applyLabels(ctx, session, siteMap)
+	if calls["site-1"] != 1 || calls["site-3"] != 1 {
+		t.Errorf("Expected only the new site to be looked up, got %v", calls)
+	}
+}
+
+func TestApplyLabelsErrorNotCached(t *testing.T) {
+	client := NewClient(false)
+	client.SetFetchLabels(true)
+
+	calls := 0
+	client.getSiteDetail = func(_ context.Context, _ *Session, _ string) (*models.Site, error) {
+		calls++
+		return nil, errors.New("api unavailable")
+	}
+
+	for i := 0; i < 2; i++ {
+		siteMap := map[string]SiteListEntry{"site-1": {Name: "acme-prod", ID: "site-1"}}
+		client.applyLabels(context.Background(), &Session{}, siteMap)
+		if label := siteMap["site-1"].DisplayLabel(); label != "acme-prod" {
+			t.Errorf("Expected label to fall back to site name on error, got %q", label)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("Expected failed lookups not to be cached, got %d calls", calls)
+	}
+}
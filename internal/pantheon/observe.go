@@ -0,0 +1,24 @@
+package pantheon
+
+import "time"
+
+// API operations reported to the request observer.
+const (
+	OperationAuthenticate  = "authenticate"
+	OperationFetchAllSites = "fetch_all_sites"
+	OperationFetchMetrics  = "fetch_metrics"
+)
+
+// SetRequestObserver sets a function called with the duration of each
+// Authenticate, FetchAllSites, and FetchMetricsData call, including failed calls.
+func (c *Client) SetRequestObserver(observe func(operation string, duration time.Duration)) {
+	c.observeRequest = observe
+}
+
+// observe reports the time elapsed since start for an operation.
+// It is meant to be deferred with the start time evaluated on entry.
+func (c *Client) observe(operation string, start time.Time) {
+	if c.observeRequest != nil {
+		c.observeRequest(operation, c.now().Sub(start))
+	}
+}
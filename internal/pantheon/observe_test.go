@@ -0,0 +1,60 @@
+package pantheon
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestObserveWithFakeClock(t *testing.T) {
+	client := NewClient(false)
+
+	clock := time.Unix(1762732800, 0)
+	client.now = func() time.Time { return clock }
+
+	var operations []string
+	var durations []time.Duration
+	client.SetRequestObserver(func(operation string, duration time.Duration) {
+		operations = append(operations, operation)
+		durations = append(durations, duration)
+	})
+
+	func() {
+		defer client.observe(OperationFetchMetrics, client.now())
+		clock = clock.Add(1500 * time.Millisecond)
+	}()
+
+	if len(operations) != 1 || operations[0] != OperationFetchMetrics {
+		t.Fatalf("Expected one fetch_metrics observation, got %v", operations)
+	}
+	if durations[0] != 1500*time.Millisecond {
+		t.Errorf("Expected duration 1.5s, got %v", durations[0])
+	}
+}
+
+func TestObserveFailedCalls(t *testing.T) {
+	client := NewClient(false)
+
+	observed := map[string]int{}
+	client.SetRequestObserver(func(operation string, _ time.Duration) {
+		observed[operation]++
+	})
+
+	// Malformed tokens fail without reaching the API, but are still observed
+	ctx := context.Background()
+	_, _ = client.Authenticate(ctx, "invalid")
+	_, _ = client.FetchAllSites(ctx, "invalid", "")
+	_, _ = client.FetchMetricsData(ctx, "invalid", "site-id", "live", "1d")
+
+	for _, operation := range []string{OperationAuthenticate, OperationFetchAllSites, OperationFetchMetrics} {
+		if observed[operation] != 1 {
+			t.Errorf("Expected 1 observation for %s, got %d", operation, observed[operation])
+		}
+	}
+}
+
+func TestObserveWithoutObserver(_ *testing.T) {
+	client := NewClient(false)
+	// Should not panic when no observer is set
+	client.observe(OperationAuthenticate, client.now())
+}
@@ -0,0 +1,68 @@
+package pantheon
+
+import (
+	"context"
+	"log"
+
+	"github.com/deviantintegral/terminus-golang/pkg/api"
+	"github.com/deviantintegral/terminus-golang/pkg/api/models"
+)
+
+// SetResolveOwners enables looking up the email of each site's owner, which the
+// site list API only returns as a user ID. Lookups are cached per owner, so each
+// owner costs one extra API call.
+func (c *Client) SetResolveOwners(enabled bool) {
+	c.ownerCacheMu.Lock()
+	defer c.ownerCacheMu.Unlock()
+	c.resolveOwners = enabled
+}
+
+// getUser fetches a single user's details.
+func getUser(ctx context.Context, session *Session, userID string) (*models.User, error) {
+	authService := api.NewAuthService(session.Client)
+	return authService.Whoami(ctx, userID)
+}
+
+// ownerEmail returns the email for an owner user ID, fetching it on first use.
+// Failed lookups aren't cached so they are retried on the next site list refresh.
+func (c *Client) ownerEmail(ctx context.Context, session *Session, userID string) (string, error) {
+	c.ownerCacheMu.Lock()
+	email, ok := c.ownerCache[userID]
+	c.ownerCacheMu.Unlock()
+	if ok {
+		return email, nil
+	}
+
+	user, err := c.lookupUser(ctx, session, userID)
+	if err != nil {
+		return "", classifyError(err)
+	}
+
+	c.ownerCacheMu.Lock()
+	c.ownerCache[userID] = user.Email
+	c.ownerCacheMu.Unlock()
+	return user.Email, nil
+}
+
+// applyOwners populates the OwnerEmail of each site when owner resolution is enabled.
+func (c *Client) applyOwners(ctx context.Context, session *Session, siteMap map[string]SiteListEntry) {
+	c.ownerCacheMu.Lock()
+	enabled := c.resolveOwners
+	c.ownerCacheMu.Unlock()
+	if !enabled {
+		return
+	}
+
+	for siteID, site := range siteMap {
+		if site.Owner == "" {
+			continue
+		}
+		email, err := c.ownerEmail(ctx, session, site.Owner)
+		if err != nil {
+			log.Printf("Warning: failed to resolve owner of site %s: %v", site.Name, err)
+			continue
+		}
+		site.OwnerEmail = email
+		siteMap[siteID] = site
+	}
+}
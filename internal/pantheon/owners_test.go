@@ -0,0 +1,67 @@
+package pantheon
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/deviantintegral/terminus-golang/pkg/api/models"
+)
+
+func TestApplyOwnersDisabled(t *testing.T) {
+	client := NewClient(false)
+
+	calls := 0
+	client.lookupUser = func(_ context.Context, _ *Session, _ string) (*models.User, error) {
+		calls++
+		return &models.User{Email: "owner@example.com"}, nil
+	}
+
+	siteMap := map[string]SiteListEntry{"site-1": {Name: "acme-prod", ID: "site-1", Owner: "user-1"}}
+	client.applyOwners(context.Background(), &Session{}, siteMap)
+
+	if calls != 0 {
+		t.Errorf("Expected no owner lookups when resolution is disabled, got %d", calls)
+	}
+	if owner := siteMap["site-1"].DisplayOwner(); owner != "user-1" {
+		t.Errorf("Expected raw owner ID, got %q", owner)
+	}
+}
+
+func TestApplyOwnersResolvesAndCaches(t *testing.T) {
+	client := NewClient(false)
+	client.SetResolveOwners(true)
+
+	calls := map[string]int{}
+	client.lookupUser = func(_ context.Context, _ *Session, userID string) (*models.User, error) {
+		calls[userID]++
+		if userID == "user-broken" {
+			return nil, errors.New("forbidden")
+		}
+		return &models.User{ID: userID, Email: userID + "@example.com"}, nil
+	}
+
+	siteMap := map[string]SiteListEntry{
+		"site-1": {Name: "one", ID: "site-1", Owner: "user-1"},
+		"site-2": {Name: "two", ID: "site-2", Owner: "user-1"},
+		"site-3": {Name: "three", ID: "site-3", Owner: "user-broken"},
+		"site-4": {Name: "four", ID: "site-4"},
+	}
+	client.applyOwners(context.Background(), &Session{}, siteMap)
+
+	for _, siteID := range []string{"site-1", "site-2"} {
+		if owner := siteMap[siteID].DisplayOwner(); owner != "user-1@example.com" {
+			t.Errorf("Expected resolved owner email for %s, got %q", siteID, owner)
+		}
+	}
+	if calls["user-1"] != 1 {
+		t.Errorf("Expected each owner to be looked up once, got %d", calls["user-1"])
+	}
+	// Failed lookups fall back to the raw ID
+	if owner := siteMap["site-3"].DisplayOwner(); owner != "user-broken" {
+		t.Errorf("Expected raw owner ID after failed lookup, got %q", owner)
+	}
+	if _, ok := calls[""]; ok {
+		t.Error("Expected sites without an owner to be skipped")
+	}
+}
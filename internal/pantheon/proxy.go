@@ -0,0 +1,34 @@
+package pantheon
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// NewProxyTransport returns an HTTP transport that sends requests through
+// proxyURL. If proxyURL is empty, the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY
+// environment variables are used instead.
+func NewProxyTransport(proxyURL string) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyURL == "" {
+		transport.Proxy = http.ProxyFromEnvironment
+		return transport, nil
+	}
+
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", proxyURL, err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: must include a scheme and host", proxyURL)
+	}
+	transport.Proxy = http.ProxyURL(parsed)
+	return transport, nil
+}
+
+// SetHTTPProxy routes all Pantheon API requests through proxyURL, with the
+// default transport options. It must be called before any account is authenticated.
+func (c *Client) SetHTTPProxy(proxyURL string) error {
+	return c.SetHTTPTransport(proxyURL, DefaultTransportOptions)
+}
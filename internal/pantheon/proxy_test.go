@@ -0,0 +1,56 @@
+package pantheon
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNewProxyTransport(t *testing.T) {
+	transport, err := NewProxyTransport("http://proxy.example.com:3128")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://terminus.pantheon.io/api/sites", nil)
+	proxyURL, err := transport.Proxy(req)
+	if err != nil {
+		t.Fatalf("Unexpected proxy error: %v", err)
+	}
+	if proxyURL == nil || proxyURL.String() != "http://proxy.example.com:3128" {
+		t.Errorf("Expected proxy http://proxy.example.com:3128, got %v", proxyURL)
+	}
+}
+
+func TestNewProxyTransportFromEnvironment(t *testing.T) {
+	transport, err := NewProxyTransport("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// http.ProxyFromEnvironment reads the environment once per process, so
+	// only check that a proxy function is configured
+	if transport.Proxy == nil {
+		t.Error("Expected proxy function to fall back to the environment")
+	}
+}
+
+func TestNewProxyTransportInvalid(t *testing.T) {
+	for _, proxy := range []string{"proxy.example.com", "://bad", "http://"} {
+		if _, err := NewProxyTransport(proxy); err == nil {
+			t.Errorf("Expected error for proxy %q", proxy)
+		}
+	}
+}
+
+func TestSetHTTPProxy(t *testing.T) {
+	client := NewClient(false)
+	if err := client.SetHTTPProxy("http://proxy.example.com:3128"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if client.sessionManager.httpClient == nil {
+		t.Fatal("Expected session manager HTTP client to be set")
+	}
+
+	if err := client.SetHTTPProxy("not a url"); err == nil {
+		t.Error("Expected error for invalid proxy URL")
+	}
+}
@@ -0,0 +1,173 @@
+package pantheon
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deviantintegral/terminus-golang/pkg/api"
+)
+
+// DefaultRetryBudget is how many API retries are allowed per minute across all accounts.
+const DefaultRetryBudget = 60
+
+// traceIDHeader is set once per request by terminus-golang and kept on every retry of it.
+const traceIDHeader = "X-Pantheon-Trace-Id"
+
+// retryBudgetStatus is the status of the local response returned in place of a
+// denied retry. terminus-golang retries 5xx and 429 responses only, so a 4xx
+// status makes it give up at once instead of backing off and trying again.
+const retryBudgetStatus = 499
+
+// retryBudgetMessage is the body of the local response returned in place of a
+// denied retry, which classifyError maps to ErrRetryBudgetExhausted.
+const retryBudgetMessage = "retry budget exhausted"
+
+// retrySeenTTL is how long a request's trace ID is remembered. It is longer
+// than terminus-golang spends backing off between all attempts of a request.
+const retrySeenTTL = 10 * time.Minute
+
+// retryBudget is a token bucket shared by every session, refilling perMinute
+// retries each minute up to a burst of perMinute. A budget of 0 is unlimited.
+type retryBudget struct {
+	mu        sync.Mutex
+	perMinute int
+	tokens    float64
+	updated   time.Time
+	seen      map[string]time.Time // Trace IDs of recent requests, by when they were first sent
+	pruned    time.Time
+	retries   int64 // Retries sent
+	exhausted int64 // Retries denied because the budget was empty
+	now       func() time.Time
+}
+
+// newRetryBudget creates a full retry budget allowing perMinute retries per minute.
+func newRetryBudget(perMinute int) *retryBudget {
+	now := time.Now()
+	return &retryBudget{
+		perMinute: perMinute,
+		tokens:    float64(perMinute),
+		updated:   now,
+		seen:      make(map[string]time.Time),
+		pruned:    now,
+		now:       time.Now,
+	}
+}
+
+// setPerMinute changes the budget, refilling it to the new burst size.
+func (b *retryBudget) setPerMinute(perMinute int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.perMinute = perMinute
+	b.tokens = float64(perMinute)
+	b.updated = b.now()
+}
+
+// allow reports whether a request with traceID may be sent. First attempts are
+// always allowed; a retry is allowed while the budget has a token left.
+func (b *retryBudget) allow(traceID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if now.Sub(b.pruned) >= retrySeenTTL {
+		for id, first := range b.seen {
+			if now.Sub(first) >= retrySeenTTL {
+				delete(b.seen, id)
+			}
+		}
+		b.pruned = now
+	}
+
+	if traceID == "" {
+		return true
+	}
+	if _, retry := b.seen[traceID]; !retry {
+		b.seen[traceID] = now
+		return true
+	}
+
+	if b.perMinute > 0 {
+		if elapsed := now.Sub(b.updated); elapsed > 0 {
+			b.tokens += elapsed.Minutes() * float64(b.perMinute)
+		}
+		if b.tokens > float64(b.perMinute) {
+			b.tokens = float64(b.perMinute)
+		}
+		b.updated = now
+		if b.tokens < 1 {
+			b.exhausted++
+			return false
+		}
+		b.tokens--
+	}
+	b.retries++
+	return true
+}
+
+// stats returns the number of retries sent and denied.
+func (b *retryBudget) stats() (retries, exhausted int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.retries, b.exhausted
+}
+
+// retryBudgetTransport charges retries of a request against a retry budget,
+// answering retries beyond it locally so they fail fast.
+type retryBudgetTransport struct {
+	next   http.RoundTripper
+	budget *retryBudget
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryBudgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.budget.allow(req.Header.Get(traceIDHeader)) {
+		return t.next.RoundTrip(req)
+	}
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	return &http.Response{
+		Status:     "499 Retry Budget Exhausted",
+		StatusCode: retryBudgetStatus,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": []string{"text/plain"}},
+		Body:       io.NopCloser(strings.NewReader(retryBudgetMessage)),
+		Request:    req,
+	}, nil
+}
+
+// withRetryBudget returns a copy of httpClient whose requests are charged
+// against budget. A nil httpClient is treated as the terminus-golang default.
+func withRetryBudget(httpClient *http.Client, budget *retryBudget) *http.Client {
+	budgeted := &http.Client{}
+	if httpClient != nil {
+		*budgeted = *httpClient
+	} else {
+		budgeted.Timeout = api.DefaultTimeout
+	}
+	next := budgeted.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	budgeted.Transport = &retryBudgetTransport{next: next, budget: budget}
+	return budgeted
+}
+
+// SetRetryBudget limits API retries across all accounts to perMinute per
+// minute, with bursts of up to perMinute. Once the budget is used up, failed
+// requests return ErrRetryBudgetExhausted instead of being retried, so a
+// widespread outage doesn't turn into a retry storm. 0 means unlimited.
+func (c *Client) SetRetryBudget(perMinute int) {
+	c.retryBudget.setPerMinute(perMinute)
+}
+
+// RetryStats returns the number of API retries sent, and the number denied
+// because the retry budget was exhausted.
+func (c *Client) RetryStats() (retries, exhausted int64) {
+	return c.retryBudget.stats()
+}
@@ -0,0 +1,121 @@
+package pantheon
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deviantintegral/terminus-golang/pkg/api"
+)
+
+// countingTransport always fails with 503 and counts the requests it receives
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Body:       io.NopCloser(strings.NewReader("unavailable")),
+		Request:    req,
+	}, nil
+}
+
+// sendAttempt sends one attempt of the request with traceID through transport
+func sendAttempt(t *testing.T, transport http.RoundTripper, traceID string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/api/sites", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(traceIDHeader, traceID)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return resp
+}
+
+func TestRetryBudgetStopsRetries(t *testing.T) {
+	now := time.Now()
+	budget := newRetryBudget(2)
+	budget.now = func() time.Time { return now }
+	next := &countingTransport{}
+	transport := &retryBudgetTransport{next: next, budget: budget}
+
+	// The first attempt and two retries are sent; the third retry is denied
+	for attempt := 0; attempt < 3; attempt++ {
+		if resp := sendAttempt(t, transport, "trace-a"); resp.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("Attempt %d: expected the request to be sent, got status %d", attempt, resp.StatusCode)
+		}
+	}
+	resp := sendAttempt(t, transport, "trace-a")
+	if resp.StatusCode != retryBudgetStatus {
+		t.Fatalf("Expected a retry beyond the budget to be denied, got status %d", resp.StatusCode)
+	}
+	if next.requests != 3 {
+		t.Errorf("Expected 3 requests to reach the API, got %d", next.requests)
+	}
+
+	// First attempts of other requests are never charged
+	if resp := sendAttempt(t, transport, "trace-b"); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected a first attempt to be sent with the budget drained, got status %d", resp.StatusCode)
+	}
+
+	if retries, exhausted := budget.stats(); retries != 2 || exhausted != 1 {
+		t.Errorf("Expected 2 retries and 1 exhausted, got %d and %d", retries, exhausted)
+	}
+
+	// Half a minute refills one retry
+	now = now.Add(30 * time.Second)
+	if resp := sendAttempt(t, transport, "trace-b"); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected a retry once the budget refilled, got status %d", resp.StatusCode)
+	}
+	if resp := sendAttempt(t, transport, "trace-b"); resp.StatusCode != retryBudgetStatus {
+		t.Errorf("Expected the refilled budget to be drained again, got status %d", resp.StatusCode)
+	}
+}
+
+func TestRetryBudgetUnlimited(t *testing.T) {
+	budget := newRetryBudget(0)
+	next := &countingTransport{}
+	transport := &retryBudgetTransport{next: next, budget: budget}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		sendAttempt(t, transport, "trace-a")
+	}
+	if next.requests != 10 {
+		t.Errorf("Expected every retry to be sent without a budget, got %d", next.requests)
+	}
+	if retries, exhausted := budget.stats(); retries != 9 || exhausted != 0 {
+		t.Errorf("Expected 9 retries and none exhausted, got %d and %d", retries, exhausted)
+	}
+}
+
+func TestRetryBudgetFailsFast(t *testing.T) {
+	budget := newRetryBudget(1)
+	budget.tokens = 0 // Drained by other requests
+	next := &countingTransport{}
+	client := api.NewClient(
+		api.WithBaseURL("https://example.com/api"),
+		api.WithHTTPClient(withRetryBudget(&http.Client{Transport: next}, budget)),
+	)
+
+	// The first attempt fails and, after terminus-golang's 1s backoff, the
+	// retry is denied, ending the request instead of backing off again
+	start := time.Now()
+	_, err := client.Get(t.Context(), "/sites")
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the request to fail fast once the budget ran out, took %v", elapsed)
+	}
+	if !errors.Is(classifyError(err), ErrRetryBudgetExhausted) {
+		t.Errorf("Expected ErrRetryBudgetExhausted, got %v", err)
+	}
+	if next.requests != 1 {
+		t.Errorf("Expected only the first attempt to reach the API, got %d", next.requests)
+	}
+}
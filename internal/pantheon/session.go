@@ -3,8 +3,10 @@ package pantheon
 
 import (
 	"context"
-	"fmt"
+	"log"
+	"net/http"
 	"sync"
+	"time"
 
 	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/version"
 	"github.com/deviantintegral/terminus-golang/pkg/api"
@@ -17,52 +19,105 @@ type Session struct {
 	UserID       string
 	Email        string
 	Client       *api.Client
+	CreatedAt    time.Time
+	WhoamiFailed bool   // Login succeeded but the email lookup failed, so Email is the token-based fallback
+	Account      string // Identifies the account in metrics, as chosen by the account label
 }
 
 // SessionManager handles authentication and client creation.
 // Sessions are stored in memory only (no disk persistence).
 type SessionManager struct {
 	mu           sync.RWMutex
-	sessions     map[string]*Session // key: machineToken
-	debugEnabled bool
+	sessions     map[string]*Session                 // key: machineToken
+	verbosity    api.VerbosityLevel                  // API logging level for new sessions
+	newLogger    func(api.VerbosityLevel) api.Logger // Creates the API logger when verbosity is set
+	httpClient   *http.Client                        // Optional; the terminus-golang default is used when nil
+	budget       *retryBudget                        // Optional retry budget shared by all sessions
+	baseURL      string                              // Optional API base URL override; the terminus-golang default is used when empty
+	accountLabel string                              // Which AccountLabel value identifies accounts; email when empty
+	aliases      map[string]string                   // Account aliases by machine token, overriding accountLabel
 }
 
 // NewSessionManager creates a new session manager.
+// If debug is true, API requests are logged at trace verbosity.
 func NewSessionManager(debug bool) *SessionManager {
+	verbosity := api.VerbosityNone
+	if debug {
+		verbosity = api.VerbosityTrace
+	}
 	return &SessionManager{
-		sessions:     make(map[string]*Session),
-		debugEnabled: debug,
+		sessions:  make(map[string]*Session),
+		verbosity: verbosity,
+		newLogger: newAPILogger,
 	}
 }
 
+// SetVerbosity sets the API logging level for sessions created after this call.
+func (sm *SessionManager) SetVerbosity(verbosity api.VerbosityLevel) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.verbosity = verbosity
+}
+
+// SetHTTPClient sets the HTTP client used by sessions created after this call.
+func (sm *SessionManager) SetHTTPClient(httpClient *http.Client) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.httpClient = httpClient
+}
+
+// setAccountLabel sets which AccountLabel value identifies sessions created after this call.
+func (sm *SessionManager) setAccountLabel(label string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.accountLabel = label
+}
+
+// setTokenAliases sets the aliases identifying the sessions of machine tokens
+// authenticated after this call.
+func (sm *SessionManager) setTokenAliases(aliases map[string]string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.aliases = aliases
+}
+
 // Authenticate creates a new session for a machine token.
 // This always performs a fresh login, replacing any existing session.
 func (sm *SessionManager) Authenticate(ctx context.Context, machineToken string) (*Session, error) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
-	// Create unauthenticated client for login with custom user agent and debug logging if enabled
-	var client *api.Client
-	if sm.debugEnabled {
-		logger := api.NewLogger(api.VerbosityTrace)
-		client = api.NewClient(api.WithUserAgent(version.UserAgent()), api.WithLogger(logger))
-	} else {
-		client = api.NewClient(api.WithUserAgent(version.UserAgent()))
+	// Create unauthenticated client for login with custom user agent and API logging if enabled
+	options := []api.ClientOption{api.WithUserAgent(version.UserAgent())}
+	if sm.verbosity > api.VerbosityNone {
+		options = append(options, api.WithLogger(sm.newLogger(sm.verbosity)))
+	}
+	if sm.budget != nil {
+		options = append(options, api.WithHTTPClient(withRetryBudget(sm.httpClient, sm.budget)))
+	} else if sm.httpClient != nil {
+		options = append(options, api.WithHTTPClient(sm.httpClient))
+	}
+	if sm.baseURL != "" {
+		options = append(options, api.WithBaseURL(sm.baseURL))
 	}
+	client := api.NewClient(options...)
 
 	// Authenticate with machine token
 	authService := api.NewAuthService(client)
 	loginResult, err := authService.Login(ctx, machineToken)
 	if err != nil {
-		return nil, fmt.Errorf("authentication failed: %w", err)
+		return nil, classifyLoginError(err)
 	}
 
-	// Get user email
+	// Get user email. The session is usable without it, so a failed lookup falls back
+	// to the account ID from the token and flags the session as degraded.
 	var email string
+	whoamiFailed := false
 	user, err := authService.Whoami(ctx, loginResult.UserID)
 	if err != nil {
-		// Fall back to account ID from token if whoami fails
 		email = GetAccountID(machineToken)
+		whoamiFailed = true
+		log.Printf("Warning: Logged in account %s but failed to look up its email: %v", email, classifyError(err))
 	} else {
 		email = user.Email
 	}
@@ -73,6 +128,12 @@ func (sm *SessionManager) Authenticate(ctx context.Context, machineToken string)
 		UserID:       loginResult.UserID,
 		Email:        email,
 		Client:       client,
+		CreatedAt:    time.Now(),
+		WhoamiFailed: whoamiFailed,
+	}
+	session.Account = accountFor(sm.accountLabel, session)
+	if alias, ok := sm.aliases[machineToken]; ok {
+		session.Account = alias
 	}
 
 	sm.sessions[machineToken] = session
@@ -125,3 +186,36 @@ func (sm *SessionManager) InvalidateSession(machineToken string) {
 	defer sm.mu.Unlock()
 	delete(sm.sessions, machineToken)
 }
+
+// SessionCount returns the number of sessions currently held in memory.
+func (sm *SessionManager) SessionCount() int {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return len(sm.sessions)
+}
+
+// SessionAges returns the age of each session keyed by account.
+func (sm *SessionManager) SessionAges() map[string]time.Duration {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	now := time.Now()
+	ages := make(map[string]time.Duration, len(sm.sessions))
+	for _, session := range sm.sessions {
+		ages[session.Account] = now.Sub(session.CreatedAt)
+	}
+	return ages
+}
+
+// SessionsDegraded reports, for each session keyed by account, whether the
+// account email lookup failed after a successful login.
+func (sm *SessionManager) SessionsDegraded() map[string]bool {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	degraded := make(map[string]bool, len(sm.sessions))
+	for _, session := range sm.sessions {
+		degraded[session.Account] = session.WhoamiFailed
+	}
+	return degraded
+}
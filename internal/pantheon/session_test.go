@@ -2,7 +2,11 @@ package pantheon
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/deviantintegral/terminus-golang/pkg/api"
 )
@@ -283,3 +287,205 @@ func TestMultipleInvalidateSessions(t *testing.T) {
 		t.Errorf("Expected 0 sessions after invalidation, got %d", len(sm.sessions))
 	}
 }
+
+func TestSessionCountAndAges(t *testing.T) {
+	sm := NewSessionManager(false)
+
+	// Populate several sessions with known creation times
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		token := "token-" + string(rune('a'+i))
+		sm.sessions[token] = &Session{
+			MachineToken: token,
+			Email:        token + "@example.com",
+			Account:      token + "@example.com",
+			Client:       api.NewClient(),
+			CreatedAt:    now.Add(-time.Duration(i+1) * time.Minute),
+		}
+	}
+
+	if count := sm.SessionCount(); count != 3 {
+		t.Errorf("Expected 3 sessions, got %d", count)
+	}
+
+	ages := sm.SessionAges()
+	if len(ages) != 3 {
+		t.Fatalf("Expected 3 session ages, got %d", len(ages))
+	}
+	if age := ages["token-b@example.com"]; age < 2*time.Minute || age > 3*time.Minute {
+		t.Errorf("Expected token-b age around 2m, got %v", age)
+	}
+
+	sm.InvalidateSession("token-a")
+	if count := sm.SessionCount(); count != 2 {
+		t.Errorf("Expected 2 sessions after invalidation, got %d", count)
+	}
+}
+
+// newAuthServer returns a test API server that accepts any machine token and
+// responds to whoami lookups with whoamiStatus.
+func newAuthServer(t *testing.T, whoamiStatus int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/authorize/machine-token":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"session": "session-123",
+				"user_id": "user-456",
+			})
+		case "/users/user-456":
+			w.WriteHeader(whoamiStatus)
+			if whoamiStatus == http.StatusOK {
+				_ = json.NewEncoder(w).Encode(map[string]string{"id": "user-456", "email": "user@example.com"})
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAuthenticateWhoamiSucceeds(t *testing.T) {
+	server := newAuthServer(t, http.StatusOK)
+	sm := NewSessionManager(false)
+	sm.baseURL = server.URL
+
+	session, err := sm.Authenticate(context.Background(), "abcdefgh12345678")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if session.Email != "user@example.com" {
+		t.Errorf("Expected email from whoami, got %q", session.Email)
+	}
+	if session.WhoamiFailed {
+		t.Error("Expected session not to be degraded")
+	}
+	if degraded := sm.SessionsDegraded(); degraded["user@example.com"] {
+		t.Errorf("Expected no degraded sessions, got %v", degraded)
+	}
+}
+
+func TestAuthenticateWhoamiFails(t *testing.T) {
+	server := newAuthServer(t, http.StatusForbidden)
+	sm := NewSessionManager(false)
+	sm.baseURL = server.URL
+
+	token := "abcdefgh12345678"
+	session, err := sm.Authenticate(context.Background(), token)
+	if err != nil {
+		t.Fatalf("Expected login to succeed despite whoami failure, got %v", err)
+	}
+
+	fallback := GetAccountID(token)
+	if session.Email != fallback {
+		t.Errorf("Expected fallback email %q, got %q", fallback, session.Email)
+	}
+	if !session.WhoamiFailed {
+		t.Error("Expected session to be flagged as degraded")
+	}
+	if session.SessionToken != "session-123" || session.UserID != "user-456" {
+		t.Errorf("Expected usable session from login, got token %q user %q", session.SessionToken, session.UserID)
+	}
+
+	// The degraded session is still returned for later calls
+	email, err := sm.GetEmail(context.Background(), token)
+	if err != nil || email != fallback {
+		t.Errorf("Expected GetEmail to return fallback %q, got %q (err %v)", fallback, email, err)
+	}
+	if degraded := sm.SessionsDegraded(); !degraded[fallback] {
+		t.Errorf("Expected %s to be reported as degraded, got %v", fallback, degraded)
+	}
+}
+
+func TestAuthenticateLoginFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	sm := NewSessionManager(false)
+	sm.baseURL = server.URL
+
+	if _, err := sm.Authenticate(context.Background(), "abcdefgh12345678"); err == nil {
+		t.Error("Expected login failure to return an error")
+	}
+	if count := sm.SessionCount(); count != 0 {
+		t.Errorf("Expected no session after failed login, got %d", count)
+	}
+}
+
+func TestAuthenticateAccountLabel(t *testing.T) {
+	server := newAuthServer(t, http.StatusOK)
+	token := "abcdefgh12345678"
+
+	tests := []struct {
+		label    string
+		expected string
+	}{
+		{"", "user@example.com"},
+		{AccountLabelEmail, "user@example.com"},
+		{AccountLabelUserID, "user-456"},
+		{AccountLabelTokenSuffix, "12345678"},
+	}
+
+	for _, tt := range tests {
+		client := NewClient(false)
+		client.sessionManager.baseURL = server.URL
+		if tt.label != "" {
+			if err := client.SetAccountLabel(tt.label); err != nil {
+				t.Fatalf("SetAccountLabel(%q) returned error: %v", tt.label, err)
+			}
+		}
+
+		account, err := client.Authenticate(context.Background(), token)
+		if err != nil {
+			t.Fatalf("Unexpected error for label %q: %v", tt.label, err)
+		}
+		if account != tt.expected {
+			t.Errorf("Label %q: expected account %q, got %q", tt.label, tt.expected, account)
+		}
+		if _, ok := client.SessionAges()[tt.expected]; !ok {
+			t.Errorf("Label %q: expected session ages keyed by %q, got %v", tt.label, tt.expected, client.SessionAges())
+		}
+
+		// The email is still available whichever label is used
+		if email, _ := client.GetEmail(context.Background(), token); email != "user@example.com" {
+			t.Errorf("Label %q: expected email to be unchanged, got %q", tt.label, email)
+		}
+	}
+}
+
+func TestSetAccountLabelInvalid(t *testing.T) {
+	client := NewClient(false)
+	for _, label := range []string{"", "Email", "name", "token"} {
+		if err := client.SetAccountLabel(label); err == nil {
+			t.Errorf("Expected %q to be rejected", label)
+		}
+	}
+}
+
+func TestAuthenticateTokenAlias(t *testing.T) {
+	server := newAuthServer(t, http.StatusOK)
+	client := NewClient(false)
+	client.sessionManager.baseURL = server.URL
+	client.SetTokenAliases(map[string]string{"abcdefgh12345678": "agency"})
+
+	account, err := client.Authenticate(context.Background(), "abcdefgh12345678")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if account != "agency" {
+		t.Errorf("Expected the alias as the account, got %q", account)
+	}
+
+	// Tokens without an alias fall back to the account label
+	account, err = client.Authenticate(context.Background(), "ijklmnop87654321")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if account != "user@example.com" {
+		t.Errorf("Expected an unaliased token to use the email, got %q", account)
+	}
+}
@@ -0,0 +1,68 @@
+package pantheon
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/deviantintegral/terminus-golang/pkg/api"
+	"github.com/deviantintegral/terminus-golang/pkg/api/models"
+)
+
+// SetSiteTagKeys sets which site tags are looked up for each site. Pantheon tags
+// are plain names, so a tag is treated as a key-value pair when written as
+// "key:value", e.g. "team:payments". Tags cost one extra API call per site on
+// each site list refresh, so they are only fetched when keys are set.
+func (c *Client) SetSiteTagKeys(keys []string) {
+	c.tagKeys = keys
+}
+
+// getTags fetches the tags of a single site within an organization.
+func getTags(ctx context.Context, session *Session, siteID, orgID string) ([]*models.Tag, error) {
+	sitesService := api.NewSitesService(session.Client)
+	return sitesService.GetTags(ctx, siteID, orgID)
+}
+
+// ExtractTags returns the value of each key among tags written as "key:value".
+// Keys without a matching tag map to an empty string.
+func ExtractTags(tags []string, keys []string) map[string]string {
+	values := make(map[string]string, len(keys))
+	for _, key := range keys {
+		values[key] = ""
+	}
+
+	for _, tag := range tags {
+		key, value, ok := strings.Cut(tag, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if _, wanted := values[key]; wanted {
+			values[key] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
+
+// applyTags populates the Tags of each site when tag keys are set. Sites outside
+// an organization can't have tags, so their tag values are empty.
+func (c *Client) applyTags(ctx context.Context, session *Session, siteMap map[string]SiteListEntry) {
+	if len(c.tagKeys) == 0 {
+		return
+	}
+
+	for siteID, site := range siteMap {
+		var names []string
+		if site.Organization != "" {
+			tags, err := c.getSiteTags(ctx, session, siteID, site.Organization)
+			if err != nil {
+				log.Printf("Warning: failed to fetch tags for site %s: %v", site.Name, classifyError(err))
+			}
+			for _, tag := range tags {
+				names = append(names, tag.Name)
+			}
+		}
+		site.Tags = ExtractTags(names, c.tagKeys)
+		siteMap[siteID] = site
+	}
+}
@@ -0,0 +1,82 @@
+package pantheon
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/deviantintegral/terminus-golang/pkg/api/models"
+)
+
+func TestExtractTags(t *testing.T) {
+	tags := []string{"team:payments", "cost-center: cc-42 ", "featured", "region:us:east"}
+	values := ExtractTags(tags, []string{"team", "cost-center", "region", "owner"})
+
+	expected := map[string]string{
+		"team":        "payments",
+		"cost-center": "cc-42",
+		"region":      "us:east",
+		"owner":       "",
+	}
+	if len(values) != len(expected) {
+		t.Errorf("Expected %d values, got %v", len(expected), values)
+	}
+	for key, want := range expected {
+		if got, ok := values[key]; !ok || got != want {
+			t.Errorf("Expected %s=%q, got %q (present: %v)", key, want, got, ok)
+		}
+	}
+}
+
+func TestApplyTagsDisabled(t *testing.T) {
+	client := NewClient(false)
+
+	calls := 0
+	client.getSiteTags = func(_ context.Context, _ *Session, _, _ string) ([]*models.Tag, error) {
+		calls++
+		return nil, nil
+	}
+
+	siteMap := map[string]SiteListEntry{"site-1": {Name: "acme-prod", ID: "site-1", Organization: "org-1"}}
+	client.applyTags(context.Background(), &Session{}, siteMap)
+
+	if calls != 0 {
+		t.Errorf("Expected no tag lookups when no tag keys are set, got %d", calls)
+	}
+	if siteMap["site-1"].Tags != nil {
+		t.Errorf("Expected no tags, got %v", siteMap["site-1"].Tags)
+	}
+}
+
+func TestApplyTags(t *testing.T) {
+	client := NewClient(false)
+	client.SetSiteTagKeys([]string{"team"})
+
+	client.getSiteTags = func(_ context.Context, _ *Session, siteID, orgID string) ([]*models.Tag, error) {
+		if orgID != "org-1" {
+			t.Errorf("Expected lookup in org-1, got %q", orgID)
+		}
+		if siteID == "site-broken" {
+			return nil, errors.New("api unavailable")
+		}
+		return []*models.Tag{{Name: "team:payments"}, {Name: "featured"}}, nil
+	}
+
+	siteMap := map[string]SiteListEntry{
+		"site-1":      {Name: "acme-prod", ID: "site-1", Organization: "org-1"},
+		"site-broken": {Name: "broken", ID: "site-broken", Organization: "org-1"},
+		"site-solo":   {Name: "solo", ID: "site-solo"},
+	}
+	client.applyTags(context.Background(), &Session{}, siteMap)
+
+	if got := siteMap["site-1"].Tags["team"]; got != "payments" {
+		t.Errorf("Expected team tag 'payments', got %q", got)
+	}
+	// Failed lookups and sites outside an organization get empty values
+	for _, siteID := range []string{"site-broken", "site-solo"} {
+		tags := siteMap[siteID].Tags
+		if value, ok := tags["team"]; !ok || value != "" {
+			t.Errorf("Expected empty team tag for %s, got %v", siteID, tags)
+		}
+	}
+}
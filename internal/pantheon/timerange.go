@@ -0,0 +1,86 @@
+package pantheon
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// dateLayout is the format of the dates bounding a TimeRange
+const dateLayout = "2006-01-02"
+
+// TimeRange is an inclusive range of UTC days whose metrics are fetched, in
+// place of the duration-based windows. The zero TimeRange is unset.
+type TimeRange struct {
+	Start time.Time // Midnight UTC of the first day
+	End   time.Time // Midnight UTC of the last day
+}
+
+// IsZero reports whether the range is unset
+func (r TimeRange) IsZero() bool {
+	return r.Start.IsZero()
+}
+
+// ParseTimeRange parses a range of days written as YYYY-MM-DD. An empty end
+// means today. Both empty returns the zero TimeRange. The range may not start
+// after it ends, or end after today.
+func ParseTimeRange(start, end string, now time.Time) (TimeRange, error) {
+	if start == "" {
+		if end != "" {
+			return TimeRange{}, fmt.Errorf("invalid time range: an end date %q needs a start date", end)
+		}
+		return TimeRange{}, nil
+	}
+
+	today := now.UTC().Truncate(24 * time.Hour)
+	startDay, err := time.Parse(dateLayout, start)
+	if err != nil {
+		return TimeRange{}, fmt.Errorf("invalid start date %q: expected YYYY-MM-DD", start)
+	}
+	endDay := today
+	if end != "" {
+		if endDay, err = time.Parse(dateLayout, end); err != nil {
+			return TimeRange{}, fmt.Errorf("invalid end date %q: expected YYYY-MM-DD", end)
+		}
+	}
+
+	if endDay.After(today) {
+		return TimeRange{}, fmt.Errorf("invalid time range: end date %s is in the future", endDay.Format(dateLayout))
+	}
+	if startDay.After(endDay) {
+		return TimeRange{}, fmt.Errorf("invalid time range: start date %s is after end date %s", startDay.Format(dateLayout), endDay.Format(dateLayout))
+	}
+	return TimeRange{Start: startDay, End: endDay}, nil
+}
+
+// duration returns the duration, in days, the Pantheon API must be asked for
+// to cover the range. The API only returns the most recent days, so the
+// window always reaches back from today to the start of the range.
+func (r TimeRange) duration(now time.Time) string {
+	today := now.UTC().Truncate(24 * time.Hour)
+	days := int(today.Sub(r.Start).Hours()/24) + 1
+	return strconv.Itoa(days) + "d"
+}
+
+// filter returns the samples in metricsData whose timestamps fall within the range
+func (r TimeRange) filter(metricsData map[string]MetricData) map[string]MetricData {
+	end := r.End.Add(24 * time.Hour)
+	filtered := make(map[string]MetricData, len(metricsData))
+	for timestampStr, data := range metricsData {
+		timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		if ts := time.Unix(timestamp, 0); !ts.Before(r.Start) && ts.Before(end) {
+			filtered[timestampStr] = data
+		}
+	}
+	return filtered
+}
+
+// SetTimeRange makes every metrics fetch cover the days in r instead of the
+// requested duration, keeping only the samples within it. This backfills a
+// specific historical window; a zero r restores duration-based fetches.
+func (c *Client) SetTimeRange(r TimeRange) {
+	c.timeRange = r
+}
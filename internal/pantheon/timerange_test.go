@@ -0,0 +1,131 @@
+package pantheon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseTimeRange(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+
+	r, err := ParseTimeRange("2024-02-01", "2024-02-07", now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !r.Start.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) || !r.End.Equal(time.Date(2024, 2, 7, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 2024-02-01 to 2024-02-07, got %v to %v", r.Start, r.End)
+	}
+
+	r, err = ParseTimeRange("2024-03-01", "", now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !r.End.Equal(time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the end to default to today, got %v", r.End)
+	}
+
+	if r, err := ParseTimeRange("", "", now); err != nil || !r.IsZero() {
+		t.Errorf("Expected no range without dates, got %+v (err %v)", r, err)
+	}
+
+	for _, tt := range []struct{ start, end string }{
+		{"", "2024-02-07"},
+		{"2024-02-30", ""},
+		{"02/01/2024", ""},
+		{"2024-02-01", "tomorrow"},
+		{"2024-02-07", "2024-02-01"},
+		{"2024-03-01", "2024-03-11"},
+		{"2024-03-11", ""},
+	} {
+		if _, err := ParseTimeRange(tt.start, tt.end, now); err == nil {
+			t.Errorf("Expected start %q and end %q to be invalid", tt.start, tt.end)
+		}
+	}
+}
+
+func TestTimeRangeDuration(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		start, end string
+		expected   string
+	}{
+		{"2024-03-10", "", "1d"},
+		{"2024-03-01", "", "10d"},
+		{"2024-02-01", "2024-02-07", "39d"},
+	}
+	for _, tt := range tests {
+		r, err := ParseTimeRange(tt.start, tt.end, now)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got := r.duration(now); got != tt.expected {
+			t.Errorf("duration(%s..%s) = %q, expected %q", tt.start, tt.end, got, tt.expected)
+		}
+	}
+}
+
+func TestTimeRangeFilter(t *testing.T) {
+	r, err := ParseTimeRange("2024-02-01", "2024-02-02", time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data := map[string]MetricData{
+		"1706659200": {Visits: 1}, // 2024-01-31
+		"1706745600": {Visits: 2}, // 2024-02-01
+		"1706832000": {Visits: 3}, // 2024-02-02
+		"1706918400": {Visits: 4}, // 2024-02-03
+		"invalid":    {Visits: 5},
+	}
+	filtered := r.filter(data)
+	if len(filtered) != 2 || filtered["1706745600"].Visits != 2 || filtered["1706832000"].Visits != 3 {
+		t.Errorf("Expected only the samples for 2024-02-01 and 2024-02-02, got %v", filtered)
+	}
+}
+
+func TestFetchMetricsDataTimeRange(t *testing.T) {
+	var durations []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/authorize/machine-token":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"session": "session-123", "user_id": "user-456"})
+		case "/sites/12345678-1234-1234-1234-123456789012/environments/live/traffic":
+			durations = append(durations, r.URL.Query().Get("duration"))
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"timeseries": []map[string]int64{
+					{"timestamp": 1706659200, "visits": 1},
+					{"timestamp": 1706745600, "visits": 2},
+					{"timestamp": 1706832000, "visits": 3},
+				},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(false)
+	client.sessionManager.baseURL = server.URL
+	now := time.Date(2024, 2, 3, 12, 0, 0, 0, time.UTC)
+	client.now = func() time.Time { return now }
+	r, err := ParseTimeRange("2024-02-01", "2024-02-01", now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	client.SetTimeRange(r)
+
+	data, err := client.FetchMetricsData(context.Background(), "abcdefgh12345678", "12345678-1234-1234-1234-123456789012", "live", "1d")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(durations) != 1 || durations[0] != "3d" {
+		t.Errorf("Expected the range to be fetched with duration 3d, got %v", durations)
+	}
+	if len(data) != 1 || data["1706745600"].Visits != 2 {
+		t.Errorf("Expected only the sample for 2024-02-01, got %v", data)
+	}
+}
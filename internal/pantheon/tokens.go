@@ -0,0 +1,64 @@
+package pantheon
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// minTokenLength is the shortest string accepted as a machine token.
+const minTokenLength = 32
+
+// tokenPattern matches the characters Pantheon uses in machine tokens.
+var tokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ParseTokens parses machine tokens from the raw PANTHEON_MACHINE_TOKENS value.
+// Tokens are separated by commas and/or whitespace, and anything after a "#" on a line is a comment.
+// Surrounding quotes, commas, and brackets (as left over from pasted JSON arrays)
+// are stripped from each token. Returns the valid tokens and any rejected values
+// that don't look like machine tokens. Aliases are dropped; see ParseAliasedTokens.
+func ParseTokens(raw string) (valid, rejected []string) {
+	valid, _, rejected = ParseAliasedTokens(raw)
+	return valid, rejected
+}
+
+// ParseAliasedTokens parses machine tokens like ParseTokens, additionally
+// accepting tokens written as alias=token. It returns the aliases keyed by
+// token. An alias used by an earlier token rejects the later entry.
+func ParseAliasedTokens(raw string) (valid []string, aliases map[string]string, rejected []string) {
+	aliases = make(map[string]string)
+	used := make(map[string]bool)
+	for _, line := range strings.Split(raw, "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+
+		for _, field := range splitTokens(line) {
+			token := strings.Trim(field, "\"'`,[]")
+			if token == "" {
+				continue
+			}
+			alias, aliased, hasAlias := strings.Cut(token, "=")
+			if hasAlias {
+				token = aliased
+			}
+			if len(token) < minTokenLength || !tokenPattern.MatchString(token) || (hasAlias && (alias == "" || used[alias])) {
+				rejected = append(rejected, token)
+				continue
+			}
+			if hasAlias {
+				aliases[token] = alias
+				used[alias] = true
+			}
+			valid = append(valid, token)
+		}
+	}
+	return valid, aliases, rejected
+}
+
+// splitTokens splits raw on commas and whitespace, dropping empty fields.
+func splitTokens(raw string) []string {
+	return strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
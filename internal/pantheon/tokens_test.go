@@ -0,0 +1,124 @@
+package pantheon
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	testTokenA = "abcdefghijklmnopqrstuvwxyz0123456789_-AB"
+	testTokenB = "ZYXWVUTSRQPONMLKJIHGFEDCBA9876543210-_ab"
+)
+
+func TestParseTokens(t *testing.T) {
+	tests := []struct {
+		name             string
+		raw              string
+		expectedValid    []string
+		expectedRejected []string
+	}{
+		{
+			name:          "space separated",
+			raw:           testTokenA + " " + testTokenB,
+			expectedValid: []string{testTokenA, testTokenB},
+		},
+		{
+			name:          "newlines and tabs",
+			raw:           "\n\t" + testTokenA + "\n  " + testTokenB + "\t\n",
+			expectedValid: []string{testTokenA, testTokenB},
+		},
+		{
+			name:          "surrounding quotes",
+			raw:           `"` + testTokenA + `" '` + testTokenB + `'`,
+			expectedValid: []string{testTokenA, testTokenB},
+		},
+		{
+			name:          "pasted JSON array",
+			raw:           `["` + testTokenA + `", "` + testTokenB + `"]`,
+			expectedValid: []string{testTokenA, testTokenB},
+		},
+		{
+			name:          "comments",
+			raw:           "# production accounts\n" + testTokenA + " # agency\n#" + testTokenB,
+			expectedValid: []string{testTokenA},
+		},
+		{
+			name:             "too short",
+			raw:              testTokenA + " short",
+			expectedValid:    []string{testTokenA},
+			expectedRejected: []string{"short"},
+		},
+		{
+			name:             "invalid characters",
+			raw:              testTokenA + ":" + " " + testTokenB,
+			expectedValid:    []string{testTokenB},
+			expectedRejected: []string{testTokenA + ":"},
+		},
+		{
+			name:          "comma separated without spaces",
+			raw:           testTokenA + "," + testTokenB,
+			expectedValid: []string{testTokenA, testTokenB},
+		},
+		{
+			name: "empty",
+			raw:  "  \n ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, rejected := ParseTokens(tt.raw)
+			if !reflect.DeepEqual(valid, tt.expectedValid) {
+				t.Errorf("Expected valid tokens %v, got %v", tt.expectedValid, valid)
+			}
+			if !reflect.DeepEqual(rejected, tt.expectedRejected) {
+				t.Errorf("Expected rejected tokens %v, got %v", tt.expectedRejected, rejected)
+			}
+		})
+	}
+}
+
+func TestParseAliasedTokens(t *testing.T) {
+	valid, aliases, rejected := ParseAliasedTokens("agency=" + testTokenA + ", " + testTokenB + "\n=" + testTokenA + " agency=" + testTokenB + " client=short")
+
+	if !reflect.DeepEqual(valid, []string{testTokenA, testTokenB}) {
+		t.Errorf("Expected valid tokens %v, got %v", []string{testTokenA, testTokenB}, valid)
+	}
+	if !reflect.DeepEqual(aliases, map[string]string{testTokenA: "agency"}) {
+		t.Errorf("Expected only %s to be aliased as agency, got %v", testTokenA, aliases)
+	}
+	// An empty alias, a reused alias, and a malformed token are rejected
+	if !reflect.DeepEqual(rejected, []string{testTokenA, testTokenB, "short"}) {
+		t.Errorf("Expected 3 rejected entries, got %v", rejected)
+	}
+
+	valid, rejected = ParseTokens("agency=" + testTokenA)
+	if !reflect.DeepEqual(valid, []string{testTokenA}) || len(rejected) != 0 {
+		t.Errorf("Expected ParseTokens to drop the alias, got %v (rejected %v)", valid, rejected)
+	}
+}
+
+func TestSplitTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected []string
+	}{
+		{name: "comma only", raw: "a,b,,c", expected: []string{"a", "b", "c"}},
+		{name: "space only", raw: "a b\tc\n d", expected: []string{"a", "b", "c", "d"}},
+		{name: "mixed", raw: " a, b ,c\nd,", expected: []string{"a", "b", "c", "d"}},
+		{name: "separators only", raw: " , ,\n", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitTokens(tt.raw)
+			if len(got) == 0 && len(tt.expected) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
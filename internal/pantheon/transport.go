@@ -0,0 +1,74 @@
+package pantheon
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/deviantintegral/terminus-golang/pkg/api"
+)
+
+// TransportOptions tunes how connections to the Pantheon API are reused.
+// Nearly every request goes to the same host, so the per-host limit matters
+// most: Go's default keeps only 2 idle connections per host, and any request
+// fanned out beyond that opens a new connection with a fresh TLS handshake.
+type TransportOptions struct {
+	MaxIdleConns        int           // Idle connections kept across all hosts (0 = no limit)
+	MaxIdleConnsPerHost int           // Idle connections kept per host (0 = Go's default of 2)
+	IdleConnTimeout     time.Duration // How long an idle connection is kept (0 = no limit)
+}
+
+// DefaultTransportOptions keeps enough idle connections to reuse them across
+// concurrent site refreshes.
+var DefaultTransportOptions = TransportOptions{
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 32,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+// validate returns an error if any option is negative
+func (o TransportOptions) validate() error {
+	if o.MaxIdleConns < 0 {
+		return fmt.Errorf("invalid max idle connections %d: must be 0 or more", o.MaxIdleConns)
+	}
+	if o.MaxIdleConnsPerHost < 0 {
+		return fmt.Errorf("invalid max idle connections per host %d: must be 0 or more", o.MaxIdleConnsPerHost)
+	}
+	if o.IdleConnTimeout < 0 {
+		return fmt.Errorf("invalid idle connection timeout %v: must be 0 or more", o.IdleConnTimeout)
+	}
+	return nil
+}
+
+// NewTransport returns an HTTP transport for Pantheon API requests that sends
+// them through proxyURL, as NewProxyTransport does, and keeps idle
+// connections according to options. HTTP/2 is used when the server supports it.
+func NewTransport(proxyURL string, options TransportOptions) (*http.Transport, error) {
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	transport, err := NewProxyTransport(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+	transport.ForceAttemptHTTP2 = true
+	transport.MaxIdleConns = options.MaxIdleConns
+	transport.MaxIdleConnsPerHost = options.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = options.IdleConnTimeout
+	return transport, nil
+}
+
+// SetHTTPTransport routes all Pantheon API requests through proxyURL (see
+// NewProxyTransport) and tunes connection reuse with options.
+// It must be called before any account is authenticated.
+func (c *Client) SetHTTPTransport(proxyURL string, options TransportOptions) error {
+	transport, err := NewTransport(proxyURL, options)
+	if err != nil {
+		return err
+	}
+	c.sessionManager.SetHTTPClient(&http.Client{
+		Timeout:   api.DefaultTimeout,
+		Transport: transport,
+	})
+	return nil
+}
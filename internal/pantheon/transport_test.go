@@ -0,0 +1,70 @@
+package pantheon
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewTransport(t *testing.T) {
+	transport, err := NewTransport("http://proxy.example.com:3128", TransportOptions{
+		MaxIdleConns:        50,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     30 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if transport.MaxIdleConns != 50 {
+		t.Errorf("Expected MaxIdleConns 50, got %d", transport.MaxIdleConns)
+	}
+	if transport.MaxIdleConnsPerHost != 10 {
+		t.Errorf("Expected MaxIdleConnsPerHost 10, got %d", transport.MaxIdleConnsPerHost)
+	}
+	if transport.IdleConnTimeout != 30*time.Second {
+		t.Errorf("Expected IdleConnTimeout 30s, got %v", transport.IdleConnTimeout)
+	}
+	if !transport.ForceAttemptHTTP2 {
+		t.Error("Expected HTTP/2 to be attempted")
+	}
+
+	// The proxy is still applied
+	req, _ := http.NewRequest(http.MethodGet, "https://terminus.pantheon.io/api/sites", nil)
+	if proxyURL, err := transport.Proxy(req); err != nil || proxyURL == nil || proxyURL.Host != "proxy.example.com:3128" {
+		t.Errorf("Expected proxy proxy.example.com:3128, got %v (err %v)", proxyURL, err)
+	}
+}
+
+func TestNewTransportInvalid(t *testing.T) {
+	for _, options := range []TransportOptions{
+		{MaxIdleConns: -1},
+		{MaxIdleConnsPerHost: -1},
+		{IdleConnTimeout: -time.Second},
+	} {
+		if _, err := NewTransport("", options); err == nil {
+			t.Errorf("Expected error for options %+v", options)
+		}
+	}
+	if _, err := NewTransport("not a url", DefaultTransportOptions); err == nil {
+		t.Error("Expected error for invalid proxy URL")
+	}
+}
+
+func TestSetHTTPTransport(t *testing.T) {
+	client := NewClient(false)
+	if err := client.SetHTTPTransport("", DefaultTransportOptions); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	httpClient := client.sessionManager.httpClient
+	if httpClient == nil {
+		t.Fatal("Expected session manager HTTP client to be set")
+	}
+	transport, ok := httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Expected an *http.Transport, got %T", httpClient.Transport)
+	}
+	if transport.MaxIdleConnsPerHost != DefaultTransportOptions.MaxIdleConnsPerHost {
+		t.Errorf("Expected MaxIdleConnsPerHost %d, got %d", DefaultTransportOptions.MaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	}
+}
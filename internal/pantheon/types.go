@@ -1,6 +1,8 @@
 // Package pantheon provides types and client functions for interacting with Pantheon via Terminus CLI.
 package pantheon
 
+import "sort"
+
 // MetricData represents a single metric entry from Terminus
 type MetricData struct {
 	DateTime      string `json:"datetime"`
@@ -41,17 +43,63 @@ type SiteInfo struct {
 	PlanName     string `json:"plan_name"`
 }
 
+// Sources of a site in an account's site list
+const (
+	SiteSourceUser = "user" // The account is a direct member of the site
+	SiteSourceOrg  = "org"  // The site belongs to one of the account's organizations
+)
+
 // SiteListEntry represents a single site from terminus site:list
 type SiteListEntry struct {
-	Name        string `json:"name"`
-	ID          string `json:"id"`
-	PlanName    string `json:"plan_name"`
-	Framework   string `json:"framework"`
-	Region      string `json:"region"`
-	Owner       string `json:"owner"`
-	Created     int64  `json:"created"`
-	Memberships string `json:"memberships"`
-	Frozen      bool   `json:"frozen"`
+	Name         string            `json:"name"`
+	ID           string            `json:"id"`
+	Label        string            `json:"label"` // Only populated when label fetching is enabled
+	PlanName     string            `json:"plan_name"`
+	Framework    string            `json:"framework"`
+	Region       string            `json:"region"`
+	Owner        string            `json:"owner"`
+	OwnerEmail   string            `json:"owner_email,omitempty"` // Only populated when owner resolution is enabled
+	Organization string            `json:"organization"`
+	Created      int64             `json:"created"`
+	Memberships  string            `json:"memberships"`
+	Frozen       bool              `json:"frozen"`
+	Tags         map[string]string `json:"tags,omitempty"` // Only populated when site tag keys are set
+	Source       string            `json:"source"`         // How the account can see the site: SiteSourceUser or SiteSourceOrg
+}
+
+// SortedSiteIDs returns the IDs of the sites in a site list, ordered by site
+// name and then ID, so a -siteLimit always keeps the same sites.
+func SortedSiteIDs(sites map[string]SiteListEntry) []string {
+	ids := make([]string, 0, len(sites))
+	for id := range sites {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := sites[ids[i]], sites[ids[j]]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// DisplayLabel returns the site's human-readable label, falling back to its name
+// when no label was fetched.
+func (s SiteListEntry) DisplayLabel() string {
+	if s.Label != "" {
+		return s.Label
+	}
+	return s.Name
+}
+
+// DisplayOwner returns the site owner's email, falling back to the owner's user ID
+// when it wasn't resolved.
+func (s SiteListEntry) DisplayOwner() string {
+	if s.OwnerEmail != "" {
+		return s.OwnerEmail
+	}
+	return s.Owner
 }
 
 // SiteMetrics holds metrics data for a specific site
@@ -60,7 +108,14 @@ type SiteMetrics struct {
 	SiteID      string // Site UUID for API calls
 	Label       string
 	PlanName    string
-	Account     string // Account identifier (email or truncated token)
+	Account     string            // Account identifier (email or truncated token)
+	Owner       string            // Owner email when resolved, otherwise the owner's user ID
+	Environment string            // Environment the metrics were fetched from ("" = the configured environment)
+	Created     int64             // Unix timestamp when the site was created (0 if unknown)
+	Frozen      bool              // Whether the site is frozen
+	Framework   string            // Site framework, e.g. drupal8 or wordpress
+	Tags        map[string]string // Promoted site tag values by key (empty if the site lacks the tag)
+	Source      string            // How the account can see the site: SiteSourceUser or SiteSourceOrg ("" = unknown)
 	MetricsData map[string]MetricData
 }
 
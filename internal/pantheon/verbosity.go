@@ -0,0 +1,50 @@
+package pantheon
+
+import (
+	"fmt"
+
+	"github.com/deviantintegral/terminus-golang/pkg/api"
+)
+
+// API logging verbosities accepted by ParseAPIVerbosity.
+const (
+	APIVerbosityNone  = "none"
+	APIVerbosityInfo  = "info"
+	APIVerbosityDebug = "debug"
+	APIVerbosityTrace = "trace"
+)
+
+// apiVerbosityLevels maps each verbosity name to its terminus-golang logger level.
+var apiVerbosityLevels = map[string]api.VerbosityLevel{
+	APIVerbosityNone:  api.VerbosityNone,
+	APIVerbosityInfo:  api.VerbosityInfo,
+	APIVerbosityDebug: api.VerbosityDebug,
+	APIVerbosityTrace: api.VerbosityTrace,
+}
+
+// ParseAPIVerbosity returns the terminus-golang logger level for a verbosity name.
+func ParseAPIVerbosity(verbosity string) (api.VerbosityLevel, error) {
+	level, ok := apiVerbosityLevels[verbosity]
+	if !ok {
+		return api.VerbosityNone, fmt.Errorf("invalid API verbosity %q: must be one of %s, %s, %s, %s",
+			verbosity, APIVerbosityNone, APIVerbosityInfo, APIVerbosityDebug, APIVerbosityTrace)
+	}
+	return level, nil
+}
+
+// newAPILogger creates the logger passed to terminus-golang clients.
+func newAPILogger(verbosity api.VerbosityLevel) api.Logger {
+	return api.NewLogger(verbosity)
+}
+
+// SetAPIVerbosity sets how much terminus-golang logs about API requests, overriding
+// the debug setting passed to NewClient. It must be called before any account is
+// authenticated.
+func (c *Client) SetAPIVerbosity(verbosity string) error {
+	level, err := ParseAPIVerbosity(verbosity)
+	if err != nil {
+		return err
+	}
+	c.sessionManager.SetVerbosity(level)
+	return nil
+}
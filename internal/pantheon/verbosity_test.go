@@ -0,0 +1,88 @@
+package pantheon
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/deviantintegral/terminus-golang/pkg/api"
+)
+
+func TestParseAPIVerbosity(t *testing.T) {
+	tests := map[string]api.VerbosityLevel{
+		APIVerbosityNone:  api.VerbosityNone,
+		APIVerbosityInfo:  api.VerbosityInfo,
+		APIVerbosityDebug: api.VerbosityDebug,
+		APIVerbosityTrace: api.VerbosityTrace,
+	}
+	for name, expected := range tests {
+		level, err := ParseAPIVerbosity(name)
+		if err != nil {
+			t.Errorf("Expected %q to be valid, got %v", name, err)
+		}
+		if level != expected {
+			t.Errorf("Expected %q to map to %d, got %d", name, expected, level)
+		}
+	}
+
+	for _, name := range []string{"", "verbose", "Trace", "3"} {
+		if _, err := ParseAPIVerbosity(name); err == nil {
+			t.Errorf("Expected %q to be invalid", name)
+		}
+	}
+}
+
+func TestAuthenticateLoggerVerbosity(t *testing.T) {
+	tests := []struct {
+		name       string
+		debug      bool
+		verbosity  string
+		wantLogger bool
+		wantLevel  api.VerbosityLevel
+	}{
+		{name: "default", wantLogger: false},
+		{name: "debug flag", debug: true, wantLogger: true, wantLevel: api.VerbosityTrace},
+		{name: "info", verbosity: APIVerbosityInfo, wantLogger: true, wantLevel: api.VerbosityInfo},
+		{name: "verbosity overrides debug", debug: true, verbosity: APIVerbosityDebug, wantLogger: true, wantLevel: api.VerbosityDebug},
+		{name: "none overrides debug", debug: true, verbosity: APIVerbosityNone, wantLogger: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newAuthServer(t, http.StatusOK)
+			client := NewClient(tt.debug)
+			if tt.verbosity != "" {
+				if err := client.SetAPIVerbosity(tt.verbosity); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+
+			var levels []api.VerbosityLevel
+			client.sessionManager.baseURL = server.URL
+			client.sessionManager.newLogger = func(level api.VerbosityLevel) api.Logger {
+				levels = append(levels, level)
+				return api.NewLogger(api.VerbosityNone)
+			}
+
+			if _, err := client.sessionManager.Authenticate(context.Background(), "abcdefgh12345678"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !tt.wantLogger {
+				if len(levels) != 0 {
+					t.Errorf("Expected no logger, got levels %v", levels)
+				}
+				return
+			}
+			if len(levels) != 1 || levels[0] != tt.wantLevel {
+				t.Errorf("Expected one logger at level %d, got %v", tt.wantLevel, levels)
+			}
+		})
+	}
+}
+
+func TestSetAPIVerbosityInvalid(t *testing.T) {
+	client := NewClient(false)
+	if err := client.SetAPIVerbosity("loud"); err == nil {
+		t.Error("Expected an error for an invalid verbosity")
+	}
+}
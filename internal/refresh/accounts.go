@@ -0,0 +1,100 @@
+package refresh
+
+import (
+	"sort"
+	"time"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+)
+
+// AccountStatus describes the health of one monitored account
+type AccountStatus struct {
+	Account             string    // Account email, or the token-based account ID if it never authenticated
+	Authenticated       bool      // Whether the most recent authentication succeeded
+	LastSiteListRefresh time.Time // When the account's site list was last fetched successfully (zero if never)
+	SiteListFailed      bool      // Whether the most recent site list fetch failed
+	LastError           string    // Error from the most recent failed authentication or site list fetch
+}
+
+// accountUp reports whether an account is healthy: its most recent
+// authentication and site list fetch succeeded, and at least one of its sites
+// has had its metrics fetched.
+func accountUp(status AccountStatus, metricsFetched bool) bool {
+	return status.Authenticated && !status.SiteListFailed && metricsFetched
+}
+
+// accountStatusFor returns the status entry for a token, creating it if needed.
+// Callers must hold rm.mu.
+func (rm *Manager) accountStatusFor(token string) *AccountStatus {
+	status, ok := rm.accountStatus[token]
+	if !ok {
+		status = &AccountStatus{Account: pantheon.GetAccountID(token)}
+		rm.accountStatus[token] = status
+	}
+	return status
+}
+
+// recordAuthentication records the outcome of authenticating token. A failed
+// authentication keeps the account's last known email.
+func (rm *Manager) recordAuthentication(token, accountID string, err error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	status := rm.accountStatusFor(token)
+	status.Authenticated = err == nil
+	if err != nil {
+		status.LastError = err.Error()
+		return
+	}
+	status.Account = accountID
+	status.LastError = ""
+}
+
+// recordSiteListFetch records the outcome of fetching token's site list at now
+func (rm *Manager) recordSiteListFetch(token string, err error, now time.Time) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	status := rm.accountStatusFor(token)
+	status.SiteListFailed = err != nil
+	if err != nil {
+		status.LastError = err.Error()
+		return
+	}
+	status.LastSiteListRefresh = now
+	status.LastError = ""
+}
+
+// AccountStatuses returns the status of every monitored account, sorted by account
+func (rm *Manager) AccountStatuses() []AccountStatus {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	statuses := make([]AccountStatus, 0, len(rm.accountStatus))
+	for _, status := range rm.accountStatus {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Account < statuses[j].Account
+	})
+	return statuses
+}
+
+// AccountsUp reports whether each monitored account is healthy (see
+// accountUp), keyed by account. Accounts that authenticated but were excluded
+// by the site filter's allowed accounts aren't included.
+func (rm *Manager) AccountsUp() map[string]bool {
+	refreshed := rm.collector.RefreshedAccounts()
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	up := make(map[string]bool, len(rm.accountStatus))
+	for _, status := range rm.accountStatus {
+		if _, monitored := rm.accountTokenMap[status.Account]; status.Authenticated && !monitored {
+			continue
+		}
+		up[status.Account] = accountUp(*status, refreshed[status.Account])
+	}
+	return up
+}
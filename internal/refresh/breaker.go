@@ -0,0 +1,175 @@
+package refresh
+
+import (
+	"sync"
+	"time"
+)
+
+// Circuit breaker defaults
+const (
+	DefaultBreakerThreshold = 5                // Consecutive failures that open the breaker
+	DefaultBreakerWindow    = 30 * time.Minute // Failures older than this are forgotten
+	DefaultBreakerCooldown  = 15 * time.Minute // How long an open breaker skips the account
+)
+
+// breakerState is the state of an account's circuit breaker
+type breakerState int
+
+const (
+	breakerClosed   breakerState = iota // Requests flow normally
+	breakerOpen                         // Requests are skipped until the cooldown elapses
+	breakerHalfOpen                     // A single probe request is allowed through
+)
+
+// accountBreaker tracks failures for a single account
+type accountBreaker struct {
+	state        breakerState
+	failures     int       // Consecutive failures in the current window
+	firstFailure time.Time // Time of the first failure in the current window
+	openedAt     time.Time // When the breaker last opened
+	probing      bool      // Whether a half-open probe is in flight
+}
+
+// circuitBreaker stops requests for accounts that keep failing. After threshold
+// consecutive failures within window, an account's breaker opens and its requests
+// are skipped for cooldown. It then half-opens to let one probe through: success
+// closes the breaker, failure opens it again.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int // 0 disables the breaker
+	window    time.Duration
+	cooldown  time.Duration
+	now       func() time.Time
+	accounts  map[string]*accountBreaker
+}
+
+// newCircuitBreaker creates a circuit breaker with the given settings
+func newCircuitBreaker(threshold int, window, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		window:    window,
+		cooldown:  cooldown,
+		now:       time.Now,
+		accounts:  make(map[string]*accountBreaker),
+	}
+}
+
+// account returns the breaker for an account, creating it if needed. Callers must hold mu.
+func (cb *circuitBreaker) account(accountID string) *accountBreaker {
+	ab, ok := cb.accounts[accountID]
+	if !ok {
+		ab = &accountBreaker{}
+		cb.accounts[accountID] = ab
+	}
+	return ab
+}
+
+// Allow reports whether a request for the account should be made
+func (cb *circuitBreaker) Allow(accountID string) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.threshold <= 0 {
+		return true
+	}
+
+	ab := cb.account(accountID)
+	switch ab.state {
+	case breakerOpen:
+		if cb.now().Sub(ab.openedAt) < cb.cooldown {
+			return false
+		}
+		ab.state = breakerHalfOpen
+		ab.probing = true
+		return true
+	case breakerHalfOpen:
+		if ab.probing {
+			return false
+		}
+		ab.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// RecordSuccess closes the account's breaker and clears its failures
+func (cb *circuitBreaker) RecordSuccess(accountID string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if _, ok := cb.accounts[accountID]; ok {
+		cb.accounts[accountID] = &accountBreaker{}
+	}
+}
+
+// Release ends a half-open probe whose outcome says nothing about the account,
+// such as a single forbidden site, so the next request can probe instead
+func (cb *circuitBreaker) Release(accountID string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if ab, ok := cb.accounts[accountID]; ok {
+		ab.probing = false
+	}
+}
+
+// RecordFailure counts a failure for the account, opening the breaker if needed.
+// It reports whether this failure opened the breaker.
+func (cb *circuitBreaker) RecordFailure(accountID string) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.threshold <= 0 {
+		return false
+	}
+
+	now := cb.now()
+	ab := cb.account(accountID)
+	switch ab.state {
+	case breakerHalfOpen:
+		// The probe failed, so wait another cooldown
+		ab.state = breakerOpen
+		ab.openedAt = now
+		ab.probing = false
+		return true
+	case breakerOpen:
+		return false
+	}
+
+	if ab.failures == 0 || now.Sub(ab.firstFailure) > cb.window {
+		ab.failures = 0
+		ab.firstFailure = now
+	}
+	ab.failures++
+
+	if ab.failures >= cb.threshold {
+		ab.state = breakerOpen
+		ab.openedAt = now
+		return true
+	}
+	return false
+}
+
+// State returns the current state of the account's breaker
+func (cb *circuitBreaker) State(accountID string) breakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if ab, ok := cb.accounts[accountID]; ok {
+		return ab.state
+	}
+	return breakerClosed
+}
+
+// OpenAccounts returns whether each known account's breaker is open or half-open
+func (cb *circuitBreaker) OpenAccounts() map[string]bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	result := make(map[string]bool, len(cb.accounts))
+	for accountID, ab := range cb.accounts {
+		result[accountID] = ab.state != breakerClosed
+	}
+	return result
+}
@@ -0,0 +1,161 @@
+package refresh
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestBreaker returns a breaker with a fake clock and a function to advance it
+func newTestBreaker(threshold int) (*circuitBreaker, func(time.Duration)) {
+	clock := time.Unix(1762732800, 0)
+	cb := newCircuitBreaker(threshold, 10*time.Minute, 5*time.Minute)
+	cb.now = func() time.Time { return clock }
+	return cb, func(d time.Duration) { clock = clock.Add(d) }
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	cb, advance := newTestBreaker(3)
+	const account = "account@example.com"
+
+	// Closed: failures below the threshold keep requests flowing
+	for i := 0; i < 2; i++ {
+		if !cb.Allow(account) {
+			t.Fatalf("Expected closed breaker to allow request %d", i)
+		}
+		if cb.RecordFailure(account) {
+			t.Fatalf("Expected breaker to stay closed after %d failures", i+1)
+		}
+	}
+
+	// Closed -> open on the threshold failure
+	if !cb.RecordFailure(account) {
+		t.Fatal("Expected third failure to open the breaker")
+	}
+	if cb.State(account) != breakerOpen {
+		t.Fatalf("Expected open state, got %v", cb.State(account))
+	}
+	if cb.Allow(account) {
+		t.Error("Expected open breaker to skip requests")
+	}
+
+	// Open -> half-open once the cooldown elapses, allowing a single probe
+	advance(5 * time.Minute)
+	if !cb.Allow(account) {
+		t.Fatal("Expected a probe after the cooldown")
+	}
+	if cb.State(account) != breakerHalfOpen {
+		t.Fatalf("Expected half-open state, got %v", cb.State(account))
+	}
+	if cb.Allow(account) {
+		t.Error("Expected only one probe while half-open")
+	}
+
+	// Half-open -> open when the probe fails
+	cb.RecordFailure(account)
+	if cb.State(account) != breakerOpen {
+		t.Fatalf("Expected failed probe to reopen the breaker, got %v", cb.State(account))
+	}
+	advance(time.Minute)
+	if cb.Allow(account) {
+		t.Error("Expected reopened breaker to wait a full cooldown")
+	}
+
+	// Half-open -> closed when the probe succeeds
+	advance(4 * time.Minute)
+	if !cb.Allow(account) {
+		t.Fatal("Expected a probe after the second cooldown")
+	}
+	cb.RecordSuccess(account)
+	if cb.State(account) != breakerClosed {
+		t.Fatalf("Expected successful probe to close the breaker, got %v", cb.State(account))
+	}
+	if !cb.Allow(account) || !cb.Allow(account) {
+		t.Error("Expected closed breaker to allow requests")
+	}
+}
+
+func TestCircuitBreakerRelease(t *testing.T) {
+	cb, advance := newTestBreaker(1)
+	const account = "account@example.com"
+
+	cb.RecordFailure(account)
+	advance(5 * time.Minute)
+	if !cb.Allow(account) {
+		t.Fatal("Expected a probe after the cooldown")
+	}
+
+	// A released probe leaves the breaker half-open for another probe
+	cb.Release(account)
+	if cb.State(account) != breakerHalfOpen {
+		t.Fatalf("Expected the breaker to stay half-open, got %v", cb.State(account))
+	}
+	if !cb.Allow(account) {
+		t.Error("Expected another probe after the first was released")
+	}
+	if cb.Allow(account) {
+		t.Error("Expected only one probe at a time after a release")
+	}
+
+	// Releasing an unknown account is a no-op
+	cb.Release("other@example.com")
+	if cb.State("other@example.com") != breakerClosed {
+		t.Error("Expected an unknown account to stay closed")
+	}
+}
+
+func TestCircuitBreakerWindow(t *testing.T) {
+	cb, advance := newTestBreaker(3)
+	const account = "account@example.com"
+
+	cb.RecordFailure(account)
+	cb.RecordFailure(account)
+
+	// Failures outside the window are forgotten
+	advance(11 * time.Minute)
+	if cb.RecordFailure(account) {
+		t.Error("Expected failures outside the window not to open the breaker")
+	}
+	if cb.State(account) != breakerClosed {
+		t.Errorf("Expected closed state, got %v", cb.State(account))
+	}
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	cb, _ := newTestBreaker(2)
+	const account = "account@example.com"
+
+	cb.RecordFailure(account)
+	cb.RecordSuccess(account)
+	if cb.RecordFailure(account) {
+		t.Error("Expected success to reset consecutive failures")
+	}
+}
+
+func TestCircuitBreakerPerAccount(t *testing.T) {
+	cb, _ := newTestBreaker(1)
+
+	cb.RecordFailure("a@example.com")
+	if cb.Allow("a@example.com") {
+		t.Error("Expected failing account to be skipped")
+	}
+	if !cb.Allow("b@example.com") {
+		t.Error("Expected other accounts to be unaffected")
+	}
+
+	open := cb.OpenAccounts()
+	if !open["a@example.com"] || open["b@example.com"] {
+		t.Errorf("Expected only a@example.com to be open, got %v", open)
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	cb, _ := newTestBreaker(0)
+	const account = "account@example.com"
+
+	for i := 0; i < 10; i++ {
+		cb.RecordFailure(account)
+	}
+	if !cb.Allow(account) {
+		t.Error("Expected disabled breaker to always allow requests")
+	}
+}
@@ -0,0 +1,75 @@
+package refresh
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/collector"
+)
+
+// environmentKey identifies one environment of a site within an account
+type environmentKey struct {
+	account     string
+	site        string
+	environment string
+}
+
+// environmentStatus tracks whether the last metrics fetch from each site
+// environment succeeded, so one environment breaking shows up even while
+// another of the same site keeps working
+type environmentStatus struct {
+	mu sync.Mutex
+	up map[environmentKey]bool
+}
+
+// newEnvironmentStatus creates an empty environment status tracker
+func newEnvironmentStatus() *environmentStatus {
+	return &environmentStatus{up: make(map[environmentKey]bool)}
+}
+
+// record sets the outcome of the latest metrics fetch from a site environment
+func (e *environmentStatus) record(account, site, environment string, up bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.up[environmentKey{account, site, environment}] = up
+}
+
+// forget drops every environment of a site, once it is no longer monitored
+func (e *environmentStatus) forget(account, site string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for key := range e.up {
+		if key.account == account && key.site == site {
+			delete(e.up, key)
+		}
+	}
+}
+
+// all returns the status of every site environment fetched so far, sorted
+func (e *environmentStatus) all() []collector.EnvironmentUp {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	envs := make([]collector.EnvironmentUp, 0, len(e.up))
+	for key, up := range e.up {
+		envs = append(envs, collector.EnvironmentUp{Account: key.account, Site: key.site, Environment: key.environment, Up: up})
+	}
+	sort.Slice(envs, func(i, j int) bool {
+		if envs[i].Account != envs[j].Account {
+			return envs[i].Account < envs[j].Account
+		}
+		if envs[i].Site != envs[j].Site {
+			return envs[i].Site < envs[j].Site
+		}
+		return envs[i].Environment < envs[j].Environment
+	})
+	return envs
+}
+
+// EnvironmentsUp returns whether the last metrics fetch from each site
+// environment succeeded (thread-safe). A failed fetch marks the configured
+// environment down; a successful one marks up the environment the metrics
+// came from, which is the fallback environment when it was used.
+func (rm *Manager) EnvironmentsUp() []collector.EnvironmentUp {
+	return rm.environments.all()
+}
@@ -0,0 +1,59 @@
+package refresh
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/collector"
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+)
+
+func TestEnvironmentsUpIndependent(t *testing.T) {
+	const account = "account@example.com"
+	client := newFakeClient()
+	client.accounts[testToken32] = account
+	client.sites[testToken32] = map[string]pantheon.SiteListEntry{
+		"site-uuid-1": {Name: "site1", ID: "site-uuid-1"},
+	}
+
+	coll := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "site1", SiteID: "site-uuid-1", Account: account},
+	})
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Minute, coll, 0, "")
+	manager.InitializeAccountTokenMap()
+
+	if err := manager.refreshSiteMetrics(account, "site1", "site-uuid-1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The same site's test environment starts failing
+	manager.environment = "test"
+	client.metricsErr = errors.New("api unavailable")
+	if err := manager.refreshSiteMetrics(account, "site1", "site-uuid-1"); err == nil {
+		t.Fatal("Expected the test environment fetch to fail")
+	}
+
+	expected := []collector.EnvironmentUp{
+		{Account: account, Site: "site1", Environment: "live", Up: true},
+		{Account: account, Site: "site1", Environment: "test", Up: false},
+	}
+	got := manager.EnvironmentsUp()
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], got[i])
+		}
+	}
+
+	// Environments of a site no longer listed are dropped
+	client.sites[testToken32] = map[string]pantheon.SiteListEntry{
+		"site-uuid-2": {Name: "site2", ID: "site-uuid-2"},
+	}
+	manager.refreshAllSiteLists()
+	if got := manager.EnvironmentsUp(); len(got) != 0 {
+		t.Errorf("Expected no environments for a removed site, got %v", got)
+	}
+}
@@ -0,0 +1,40 @@
+package refresh
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// MinRefreshInterval is the shortest refresh interval ParseRefreshInterval accepts
+const MinRefreshInterval = 10 * time.Second
+
+// MinDefaultSiteListInterval is the shortest site list interval used when none
+// is set. Sub-minute refresh intervals are meant for testing metrics refreshes,
+// and re-listing every organization and site that often multiplies API calls.
+const MinDefaultSiteListInterval = time.Minute
+
+// defaultSiteListInterval returns the site list interval used when none is
+// set: the refresh interval, but at least MinDefaultSiteListInterval
+func defaultSiteListInterval(refreshInterval time.Duration) time.Duration {
+	return max(refreshInterval, MinDefaultSiteListInterval)
+}
+
+// ParseRefreshInterval parses a refresh interval given either as a whole
+// number of minutes such as "60", for compatibility, or as a Go duration such
+// as "90m", "2h" or "30s". It must be at least MinRefreshInterval.
+func ParseRefreshInterval(value string) (time.Duration, error) {
+	var interval time.Duration
+	if minutes, err := strconv.Atoi(value); err == nil {
+		interval = time.Duration(minutes) * time.Minute
+	} else {
+		interval, err = time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid interval %q: expected a number of minutes such as 60 or a duration such as 90m", value)
+		}
+	}
+	if interval < MinRefreshInterval {
+		return 0, fmt.Errorf("invalid interval %q: must be at least %v", value, MinRefreshInterval)
+	}
+	return interval, nil
+}
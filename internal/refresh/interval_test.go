@@ -0,0 +1,83 @@
+package refresh
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/collector"
+)
+
+func TestParseRefreshInterval(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"60", time.Hour},
+		{"1", time.Minute},
+		{"90m", 90 * time.Minute},
+		{"2h", 2 * time.Hour},
+		{"30s", 30 * time.Second},
+		{"1h30m", 90 * time.Minute},
+		{"10s", MinRefreshInterval},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRefreshInterval(tt.value)
+		if err != nil {
+			t.Errorf("ParseRefreshInterval(%q) returned error: %v", tt.value, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("ParseRefreshInterval(%q) = %v, expected %v", tt.value, got, tt.expected)
+		}
+	}
+
+	for _, value := range []string{"", "0", "-5", "1.5", "ten", "5m30", "0s", "-1h", "9s", "500ms"} {
+		if _, err := ParseRefreshInterval(value); err == nil {
+			t.Errorf("Expected %q to be invalid", value)
+		}
+	}
+}
+
+func TestDefaultSiteListInterval(t *testing.T) {
+	tests := []struct {
+		refreshInterval time.Duration
+		expected        time.Duration
+	}{
+		{MinRefreshInterval, time.Minute},
+		{30 * time.Second, time.Minute},
+		{time.Minute, time.Minute},
+		{90 * time.Minute, 90 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := defaultSiteListInterval(tt.refreshInterval); got != tt.expected {
+			t.Errorf("defaultSiteListInterval(%v) = %v, expected %v", tt.refreshInterval, got, tt.expected)
+		}
+	}
+}
+
+func TestSubMinuteRefreshInterval(t *testing.T) {
+	interval, err := ParseRefreshInterval("10s")
+	if err != nil {
+		t.Fatalf("Expected a 10s interval to be accepted, got %v", err)
+	}
+	manager := NewManager(newFakeClient(), nil, testEnvLive, interval, collector.NewPantheonCollector(nil), 0, "")
+
+	// Metrics follow the short interval, while site lists keep to once a minute
+	if manager.refreshInterval != interval {
+		t.Errorf("Expected refresh interval %v, got %v", interval, manager.refreshInterval)
+	}
+	if manager.siteListInterval != time.Minute {
+		t.Errorf("Expected site lists to refresh once a minute, got %v", manager.siteListInterval)
+	}
+	if n := sitesPerTick(25, interval); n != 25 {
+		t.Errorf("Expected every site refreshed per tick, got %d", n)
+	}
+
+	// An explicit site list interval is kept, however short
+	manager.SetSiteListInterval(interval)
+	if manager.siteListInterval != interval {
+		t.Errorf("Expected explicit site list interval %v, got %v", interval, manager.siteListInterval)
+	}
+}
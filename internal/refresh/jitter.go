@@ -0,0 +1,56 @@
+package refresh
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// jitterDuration returns base adjusted by a random offset within ±fraction of base.
+// A fraction of 0 or less returns base unchanged.
+func jitterDuration(base time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return base
+	}
+	offset := (rand.Float64()*2 - 1) * fraction * float64(base) // #nosec G404 - jitter does not need a cryptographic source
+	return base + time.Duration(offset)
+}
+
+// jitterTicker delivers ticks like time.Ticker, but randomizes every interval
+// (including the first) within ±fraction of the base interval so that replicas
+// and refresh loops don't fire in lockstep.
+type jitterTicker struct {
+	C    <-chan time.Time
+	stop chan struct{}
+}
+
+// newJitterTicker starts a ticker firing approximately every base interval
+func newJitterTicker(base time.Duration, fraction float64) *jitterTicker {
+	ch := make(chan time.Time, 1)
+	t := &jitterTicker{C: ch, stop: make(chan struct{})}
+
+	go func() {
+		timer := time.NewTimer(jitterDuration(base, fraction))
+		defer timer.Stop()
+
+		for {
+			select {
+			case now := <-timer.C:
+				// Drop the tick if the receiver is still busy, matching time.Ticker
+				select {
+				case ch <- now:
+				default:
+				}
+				timer.Reset(jitterDuration(base, fraction))
+			case <-t.stop:
+				return
+			}
+		}
+	}()
+
+	return t
+}
+
+// Stop turns off the ticker
+func (t *jitterTicker) Stop() {
+	close(t.stop)
+}
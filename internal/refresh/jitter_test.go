@@ -0,0 +1,55 @@
+package refresh
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitterDurationWithinBounds(t *testing.T) {
+	base := 60 * time.Second
+	fraction := 0.1
+	lower := base - 6*time.Second
+	upper := base + 6*time.Second
+
+	sawBelow := false
+	sawAbove := false
+	for i := 0; i < 10000; i++ {
+		d := jitterDuration(base, fraction)
+		if d < lower || d > upper {
+			t.Fatalf("Jittered duration %v outside bounds [%v, %v]", d, lower, upper)
+		}
+		if d < base {
+			sawBelow = true
+		}
+		if d > base {
+			sawAbove = true
+		}
+	}
+
+	if !sawBelow || !sawAbove {
+		t.Error("Expected jittered durations on both sides of the base interval")
+	}
+}
+
+func TestJitterDurationDisabled(t *testing.T) {
+	base := 60 * time.Second
+	if d := jitterDuration(base, 0); d != base {
+		t.Errorf("Expected %v with no jitter, got %v", base, d)
+	}
+	if d := jitterDuration(base, -0.5); d != base {
+		t.Errorf("Expected %v with negative jitter, got %v", base, d)
+	}
+}
+
+func TestJitterTickerFires(t *testing.T) {
+	ticker := newJitterTicker(10*time.Millisecond, 0.5)
+	defer ticker.Stop()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-ticker.C:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected tick %d within 1s", i+1)
+		}
+	}
+}
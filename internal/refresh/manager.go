@@ -3,12 +3,16 @@ package refresh
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"math"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/collector"
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/filter"
 	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
 )
 
@@ -18,34 +22,81 @@ const RefreshMetricsDuration = "1d"
 // InitialMetricsDuration is used for the first metrics fetch for new sites (28 days of history).
 const InitialMetricsDuration = "28d"
 
+// initialFetchWeight is how many established sites' worth of the per-minute
+// budget a site costs while it still needs its InitialMetricsDuration fetch.
+const initialFetchWeight = 4
+
+// ErrUnknownSite is returned by RefreshSite for a site that isn't monitored.
+// It differs from pantheon.ErrSiteNotFound, which means the Pantheon API
+// doesn't know the site.
+var ErrUnknownSite = errors.New("site is not monitored")
+
+// DefaultDrainTimeout is how long Stop waits for in-flight metrics refreshes to finish.
+const DefaultDrainTimeout = 30 * time.Second
+
 // Manager manages periodic refresh of site lists and metrics
 type Manager struct {
-	client          *pantheon.Client
-	tokens          []string
-	environment     string
-	refreshInterval time.Duration
-	collector       *collector.PantheonCollector
-	discoveredSites map[string]bool   // Track sites discovered since app start (account:site format)
-	accountTokenMap map[string]string // Map from account email to token
-	tickerInterval  time.Duration     // Interval for metrics refresh ticker (defaults to 1 minute)
-	tickerFireCount int64             // Counter for ticker fires (for testing)
-	siteLimit       int               // Maximum number of sites to query (0 = no limit)
-	orgID           string            // Organization ID to filter sites (empty for all sites)
+	client              pantheon.ClientInterface
+	tokens              []string
+	environment         string
+	refreshInterval     time.Duration // Time to cycle through metrics for every site
+	siteListInterval    time.Duration // Time between site list refreshes
+	collector           *collector.PantheonCollector
+	mu                  sync.Mutex                // Guards discoveredSites, historyFetched, accountTokenMap, lastSiteListRefresh, and stopped
+	siteListMu          sync.Mutex                // Serializes site list refreshes
+	discoveredSites     map[string]bool           // Track sites discovered since app start (account:site format)
+	historyFetched      map[string]bool           // Sites whose InitialMetricsDuration fetch succeeded (account:site format)
+	accountTokenMap     map[string]string         // Map from account email to token
+	lastSiteListRefresh time.Time                 // When site lists were last refreshed for every account
+	tickerInterval      time.Duration             // Interval for metrics refresh ticker (defaults to 1 minute)
+	tickerFireCount     int64                     // Counter for ticker fires (for testing)
+	batchesRunning      int64                     // Batches of metrics refreshes still running
+	refreshGoroutines   int64                     // Site metrics refresh goroutines still running
+	siteLimit           int                       // Maximum number of sites to query (0 = no limit)
+	orgID               string                    // Organization ID to filter sites (empty for all sites)
+	jitter              float64                   // Fraction of each refresh interval to randomize (0 = no jitter)
+	siteFilter          filter.Sites              // Selects which sites are monitored
+	breaker             *circuitBreaker           // Skips accounts that keep failing
+	unauthorized        *unauthorizedSites        // Skips sites whose metrics the token may not read
+	environments        *environmentStatus        // Outcome of the last metrics fetch from each site environment
+	fallbackEnv         string                    // Environment to fetch from when a site has no metrics in environment
+	prioritySites       map[string]bool           // Site names refreshed on every tick, outside the rotation
+	cycle               *cycleStats               // Refresh outcomes for the current queue cycle
+	logFormat           string                    // Format of the per-cycle summary line
+	skipHistory         bool                      // Fetch only RefreshMetricsDuration for newly discovered sites
+	snapshotFile        string                    // File the collector is saved to after each queue cycle ("" = never saved)
+	afterRefresh        func()                    // Called once each batch of metrics refreshes has finished (nil = none)
+	accountStatus       map[string]*AccountStatus // Health of each account, keyed by token; guarded by mu
+	newSites            []pantheon.SiteMetrics    // Sites added by a site list refresh awaiting their first fetch; guarded by mu
+	newSitesReady       chan struct{}             // Signals the metrics loop that newSites is not empty
+	stop                chan struct{}             // Closed by Stop to end the refresh loops
+	stopped             bool                      // Whether Stop has been called
+	inFlight            sync.WaitGroup            // Metrics refreshes that have not finished writing to the collector
 }
 
 // NewManager creates a new refresh manager
-func NewManager(client *pantheon.Client, tokens []string, environment string, refreshInterval time.Duration, c *collector.PantheonCollector, siteLimit int, orgID string) *Manager {
+func NewManager(client pantheon.ClientInterface, tokens []string, environment string, refreshInterval time.Duration, c *collector.PantheonCollector, siteLimit int, orgID string) *Manager {
 	return &Manager{
-		client:          client,
-		tokens:          tokens,
-		environment:     environment,
-		refreshInterval: refreshInterval,
-		collector:       c,
-		discoveredSites: make(map[string]bool),
-		accountTokenMap: make(map[string]string),
-		tickerInterval:  1 * time.Minute, // Default to 1 minute
-		siteLimit:       siteLimit,
-		orgID:           orgID,
+		client:           client,
+		tokens:           tokens,
+		environment:      environment,
+		refreshInterval:  refreshInterval,
+		siteListInterval: defaultSiteListInterval(refreshInterval),
+		collector:        c,
+		discoveredSites:  make(map[string]bool),
+		historyFetched:   make(map[string]bool),
+		accountTokenMap:  make(map[string]string),
+		accountStatus:    make(map[string]*AccountStatus),
+		tickerInterval:   1 * time.Minute, // Default to 1 minute
+		siteLimit:        siteLimit,
+		orgID:            orgID,
+		breaker:          newCircuitBreaker(DefaultBreakerThreshold, DefaultBreakerWindow, DefaultBreakerCooldown),
+		unauthorized:     newUnauthorizedSites(DefaultUnauthorizedCooldown),
+		environments:     newEnvironmentStatus(),
+		stop:             make(chan struct{}),
+		newSitesReady:    make(chan struct{}, 1),
+		cycle:            newCycleStats(time.Now()),
+		logFormat:        LogFormatText,
 	}
 }
 
@@ -54,6 +105,79 @@ func (rm *Manager) SetTickerInterval(interval time.Duration) {
 	rm.tickerInterval = interval
 }
 
+// SetSiteListInterval sets the time between site list refreshes, independently of the
+// metrics refresh cycle. Defaults to the refresh interval, but at least a minute;
+// non-positive values are ignored.
+func (rm *Manager) SetSiteListInterval(interval time.Duration) {
+	if interval > 0 {
+		rm.siteListInterval = interval
+	}
+}
+
+// SetJitter sets the percentage (0-100) by which refresh intervals are randomized
+func (rm *Manager) SetJitter(percent float64) {
+	rm.jitter = percent / 100
+}
+
+// SetBreakerThreshold sets how many consecutive metrics fetch failures open an
+// account's circuit breaker (0 disables the breaker)
+func (rm *Manager) SetBreakerThreshold(threshold int) {
+	rm.breaker.mu.Lock()
+	defer rm.breaker.mu.Unlock()
+	rm.breaker.threshold = threshold
+}
+
+// AccountCircuitsOpen reports, for each account that has failed, whether its
+// circuit breaker is currently open (thread-safe)
+func (rm *Manager) AccountCircuitsOpen() map[string]bool {
+	return rm.breaker.OpenAccounts()
+}
+
+// SetFallbackEnvironment sets the environment to fetch metrics from for sites
+// with no data in the configured environment (empty disables the fallback)
+func (rm *Manager) SetFallbackEnvironment(environment string) {
+	rm.fallbackEnv = environment
+}
+
+// SetPrioritySites sets the names of sites refreshed on every tick in addition
+// to the normal rotation, e.g. high-traffic sites that need fresher metrics
+func (rm *Manager) SetPrioritySites(names []string) {
+	rm.prioritySites = make(map[string]bool, len(names))
+	for _, name := range names {
+		rm.prioritySites[name] = true
+	}
+}
+
+// SetLogFormat sets the format of the summary logged after each refresh cycle,
+// LogFormatText or LogFormatJSON
+func (rm *Manager) SetLogFormat(format string) {
+	rm.logFormat = format
+}
+
+// SetSkipHistory makes the first fetch for a site use RefreshMetricsDuration
+// instead of InitialMetricsDuration, so new sites start without history
+func (rm *Manager) SetSkipHistory(skip bool) {
+	rm.skipHistory = skip
+}
+
+// SetSnapshotFile makes the manager save the collector's sites and metrics to
+// path after each full metrics refresh cycle, for loading on the next start
+func (rm *Manager) SetSnapshotFile(path string) {
+	rm.snapshotFile = path
+}
+
+// SetAfterRefresh sets a function called once each batch of metrics refreshes
+// has been written to the collector, e.g. to push the new samples. Batches
+// that overlap may call it concurrently.
+func (rm *Manager) SetAfterRefresh(fn func()) {
+	rm.afterRefresh = fn
+}
+
+// SetSiteFilter sets the filter selecting which sites are monitored
+func (rm *Manager) SetSiteFilter(siteFilter filter.Sites) {
+	rm.siteFilter = siteFilter
+}
+
 // GetTickerFireCount returns the number of times the ticker has fired (useful for testing)
 func (rm *Manager) GetTickerFireCount() int64 {
 	return atomic.LoadInt64(&rm.tickerFireCount)
@@ -61,28 +185,138 @@ func (rm *Manager) GetTickerFireCount() int64 {
 
 // InitializeDiscoveredSites populates the discovered sites map with initial sites
 func (rm *Manager) InitializeDiscoveredSites() {
-	sites := rm.collector.GetSites()
-	for _, site := range sites {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.collector.ForEachSite(func(site pantheon.SiteMetrics) {
 		key := site.Account + ":" + site.SiteName
 		rm.discoveredSites[key] = true
-	}
+	})
 	log.Printf("Initialized with %d discovered sites", len(rm.discoveredSites))
 }
 
+// ResetDiscoveredSites forgets which sites have been fetched, so each site's
+// next fetch gets the full history again, e.g. after the collector is cleared
+func (rm *Manager) ResetDiscoveredSites() {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.discoveredSites = make(map[string]bool)
+	rm.historyFetched = make(map[string]bool)
+}
+
 // InitializeAccountTokenMap authenticates all tokens and populates the account-to-token mapping.
 // This must be called before Start() to ensure tokens are available for metrics refresh.
 func (rm *Manager) InitializeAccountTokenMap() {
 	ctx := context.Background()
 	for _, token := range rm.tokens {
 		accountID, err := rm.client.Authenticate(ctx, token)
+		rm.recordAuthentication(token, accountID, err)
 		if err != nil {
 			accountID = pantheon.GetAccountID(token)
 			log.Printf("Warning: Failed to authenticate account %s during token map initialization: %v", accountID, err)
 			continue
 		}
-		rm.accountTokenMap[accountID] = token
+		if !rm.accountAllowed(ctx, token, accountID) {
+			continue
+		}
+		rm.setAccountToken(accountID, token)
 	}
-	log.Printf("Initialized account token map with %d accounts", len(rm.accountTokenMap))
+	log.Printf("Initialized account token map with %d accounts", rm.accountCount())
+}
+
+// accountAllowed reports whether the account a token authenticated as is
+// selected by the site filter's allowed accounts, logging accounts that aren't.
+// The email comes from the token's existing session.
+func (rm *Manager) accountAllowed(ctx context.Context, token, accountID string) bool {
+	if len(rm.siteFilter.Accounts) == 0 {
+		return true
+	}
+	email, err := rm.client.GetEmail(ctx, token)
+	if err != nil || !rm.siteFilter.AllowsAccount(email) {
+		log.Printf("Skipping account %s: not in the allowed accounts", accountID)
+		return false
+	}
+	return true
+}
+
+// setAccountToken records the token for an account (thread-safe)
+func (rm *Manager) setAccountToken(accountID, token string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.accountTokenMap[accountID] = token
+}
+
+// getAccountToken returns the token for an account (thread-safe)
+func (rm *Manager) getAccountToken(accountID string) (string, bool) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	token, ok := rm.accountTokenMap[accountID]
+	return token, ok
+}
+
+// accountCount returns the number of accounts with a known token (thread-safe)
+func (rm *Manager) accountCount() int {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	return len(rm.accountTokenMap)
+}
+
+// AccountCounts returns the number of accounts with a known token, which have
+// authenticated at least once, and the number of configured tokens (thread-safe)
+func (rm *Manager) AccountCounts() (authenticated, total int) {
+	return rm.accountCount(), len(rm.tokens)
+}
+
+// LastSiteListRefresh returns when site lists were last refreshed successfully for
+// every account, or the zero time if no periodic refresh has succeeded yet (thread-safe)
+func (rm *Manager) LastSiteListRefresh() time.Time {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	return rm.lastSiteListRefresh
+}
+
+// fetchWeight returns the share of the per-minute budget refreshing site costs:
+// initialFetchWeight while it still needs its first InitialMetricsDuration
+// fetch, otherwise 1.
+func (rm *Manager) fetchWeight(site pantheon.SiteMetrics) int {
+	if rm.skipHistory {
+		return 1
+	}
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	if rm.needsHistory(site.Account+":"+site.SiteName, len(site.MetricsData) > 0) {
+		return initialFetchWeight
+	}
+	return 1
+}
+
+// needsHistory reports whether a site's next fetch should cover
+// InitialMetricsDuration: it has never been fetched, or it is known but has no
+// metrics and no InitialMetricsDuration fetch of it has succeeded, e.g. because
+// an initial collection timeout skipped it. A site that still has no metrics
+// after one such fetch, such as a frozen site, isn't fetched in full again.
+// The caller must hold rm.mu.
+func (rm *Manager) needsHistory(key string, hasMetrics bool) bool {
+	if !rm.discoveredSites[key] {
+		return true
+	}
+	return !hasMetrics && !rm.historyFetched[key]
+}
+
+// claimFetch marks a site as discovered and reports whether its fetch should
+// cover InitialMetricsDuration (thread-safe)
+func (rm *Manager) claimFetch(key string, hasMetrics bool) bool {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	full := rm.needsHistory(key, hasMetrics)
+	rm.discoveredSites[key] = true
+	return full
+}
+
+// markHistoryFetched records that a site's InitialMetricsDuration fetch succeeded (thread-safe)
+func (rm *Manager) markHistoryFetched(key string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.historyFetched[key] = true
 }
 
 // Start begins the periodic refresh process
@@ -94,17 +328,167 @@ func (rm *Manager) Start() {
 	go rm.refreshMetricsWithQueue()
 }
 
+// Stop ends the refresh loops and waits up to timeout for in-flight metrics
+// refreshes to finish writing to the collector. It reports whether they all
+// finished before the timeout. No new refreshes start once Stop is called.
+func (rm *Manager) Stop(timeout time.Duration) bool {
+	rm.mu.Lock()
+	if !rm.stopped {
+		rm.stopped = true
+		close(rm.stop)
+	}
+	rm.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		rm.inFlight.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// startSiteRefresh refreshes a site's metrics in a new goroutine tracked for
+// Stop and, if non-nil, by batch. It returns false without refreshing if the
+// manager has been stopped.
+func (rm *Manager) startSiteRefresh(accountID, siteName, siteID string, batch *sync.WaitGroup) bool {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	if rm.stopped {
+		return false
+	}
+
+	rm.inFlight.Add(1)
+	if batch != nil {
+		batch.Add(1)
+	}
+	atomic.AddInt64(&rm.refreshGoroutines, 1)
+	go func() {
+		defer atomic.AddInt64(&rm.refreshGoroutines, -1)
+		defer rm.inFlight.Done()
+		if batch != nil {
+			defer batch.Done()
+		}
+		rm.refreshSiteMetrics(accountID, siteName, siteID)
+	}()
+	return true
+}
+
+// refreshBatch starts a metrics refresh for each site, reporting a refresh in
+// progress until all of them have finished. It returns false if the manager
+// was stopped before every refresh started.
+func (rm *Manager) refreshBatch(sites []pantheon.SiteMetrics) bool {
+	if len(sites) == 0 {
+		return true
+	}
+
+	var batch sync.WaitGroup
+	atomic.AddInt64(&rm.batchesRunning, 1)
+	started := true
+	for _, site := range sites {
+		if !rm.startSiteRefresh(site.Account, site.SiteName, site.SiteID, &batch) {
+			started = false
+			break
+		}
+	}
+	go func() {
+		batch.Wait()
+		atomic.AddInt64(&rm.batchesRunning, -1)
+		if started && rm.afterRefresh != nil {
+			rm.afterRefresh()
+		}
+	}()
+	return started
+}
+
+// RefreshInProgress reports whether a batch of metrics refreshes is running
+func (rm *Manager) RefreshInProgress() bool {
+	return atomic.LoadInt64(&rm.batchesRunning) > 0
+}
+
+// RefreshGoroutines returns the number of site metrics refresh goroutines
+// currently running. It should stay near the batch size; steady growth means
+// refreshes are being started faster than they finish.
+func (rm *Manager) RefreshGoroutines() int {
+	return int(atomic.LoadInt64(&rm.refreshGoroutines))
+}
+
 // refreshSiteListsPeriodically refreshes site lists for all accounts
 func (rm *Manager) refreshSiteListsPeriodically() {
-	ticker := time.NewTicker(rm.refreshInterval)
+	ticker := newJitterTicker(rm.siteListInterval, rm.jitter)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-rm.stop:
+			return
+		case <-ticker.C:
+		}
+
 		log.Printf("Starting site list refresh...")
 		rm.refreshAllSiteLists()
 	}
 }
 
+// sitesPerTick returns how many sites must be refreshed each minute to cycle
+// through totalSites within the refresh interval. An interval under a minute
+// refreshes every site each minute rather than dividing by a fraction of one.
+func sitesPerTick(totalSites int, refreshInterval time.Duration) int {
+	if refreshInterval < time.Minute {
+		return totalSites
+	}
+	return int(math.Ceil(float64(totalSites) / refreshInterval.Minutes()))
+}
+
+// partitionPrioritySites splits sites into those named in priority and the rest,
+// preserving their order
+func partitionPrioritySites(sites []pantheon.SiteMetrics, priority map[string]bool) (prioritySites, normalSites []pantheon.SiteMetrics) {
+	if len(priority) == 0 {
+		return nil, sites
+	}
+	for _, site := range sites {
+		if priority[site.SiteName] {
+			prioritySites = append(prioritySites, site)
+		} else {
+			normalSites = append(normalSites, site)
+		}
+	}
+	return prioritySites, normalSites
+}
+
+// nextBatch returns the sites to refresh on one tick: every priority site plus
+// normal sites starting at siteIndex until their combined weight reaches
+// perTick, and the index the following batch of normal sites starts at. A nil
+// weight counts every site as 1. The batch always includes at least one normal
+// site so that the rotation keeps moving.
+func nextBatch(prioritySites, normalSites []pantheon.SiteMetrics, siteIndex, perTick int, weight func(pantheon.SiteMetrics) int) ([]pantheon.SiteMetrics, int) {
+	endIndex := siteIndex
+	used := 0
+	for endIndex < len(normalSites) {
+		cost := 1
+		if weight != nil {
+			cost = weight(normalSites[endIndex])
+		}
+		if endIndex > siteIndex && used+cost > perTick {
+			break
+		}
+		used += cost
+		endIndex++
+	}
+
+	batch := make([]pantheon.SiteMetrics, 0, len(prioritySites)+endIndex-siteIndex)
+	batch = append(batch, prioritySites...)
+	batch = append(batch, normalSites[siteIndex:endIndex]...)
+	return batch, endIndex
+}
+
 // buildSiteKeyMap creates a map of site keys from a list of sites
 func buildSiteKeyMap(sites []pantheon.SiteMetrics) map[string]bool {
 	siteMap := make(map[string]bool)
@@ -140,8 +524,20 @@ func findRemovedSites(currentSites, newSites map[string]bool) []string {
 	return removed
 }
 
+// ReloadSiteLists discards the client's cached organization lists and
+// refreshes every account's site list now, returning once it has finished.
+// Sites and organizations added since the last refresh are picked up without
+// waiting for the site list interval or the organization cache TTL.
+func (rm *Manager) ReloadSiteLists() {
+	rm.client.InvalidateOrgCache()
+	rm.refreshAllSiteLists()
+}
+
 // refreshAllSiteLists refreshes the site list for all accounts
 func (rm *Manager) refreshAllSiteLists() {
+	rm.siteListMu.Lock()
+	defer rm.siteListMu.Unlock()
+
 	ctx := context.Background()
 	var allSiteMetrics []pantheon.SiteMetrics
 
@@ -152,13 +548,7 @@ func (rm *Manager) refreshAllSiteLists() {
 	// Track new sites for this refresh
 	newSitesMap := make(map[string]bool)
 	totalSitesFound := 0
-
-	// Get existing metrics for sites (do this once outside the loop)
-	existingMetricsMap := make(map[string]map[string]pantheon.MetricData)
-	for _, site := range existingSites {
-		key := site.Account + ":" + site.SiteName
-		existingMetricsMap[key] = site.MetricsData
-	}
+	failed := false
 
 	for _, token := range rm.tokens {
 		// Check if we've reached the site limit
@@ -168,29 +558,39 @@ func (rm *Manager) refreshAllSiteLists() {
 
 		// Authenticate with this token
 		accountID, err := rm.client.Authenticate(ctx, token)
+		rm.recordAuthentication(token, accountID, err)
 		if err != nil {
 			// Use token suffix as fallback for logging if auth fails
 			accountID = pantheon.GetAccountID(token)
 			log.Printf("Warning: Failed to authenticate account %s during refresh: %v", accountID, err)
+			failed = true
+			continue
+		}
+		if !rm.accountAllowed(ctx, token, accountID) {
 			continue
 		}
 
 		// Store the mapping for later use
-		rm.accountTokenMap[accountID] = token
+		rm.setAccountToken(accountID, token)
 
 		log.Printf("Refreshing site list for account %s", accountID)
 
 		// Fetch all sites for this account (filtered by orgID if provided)
 		siteList, err := rm.client.FetchAllSites(ctx, token, rm.orgID)
+		rm.recordSiteListFetch(token, err, time.Now())
 		if err != nil {
 			log.Printf("Warning: Failed to fetch site list for account %s during refresh: %v", accountID, err)
+			failed = true
 			continue
 		}
 
+		siteList = rm.siteFilter.Apply(siteList)
 		totalSitesFound += len(siteList)
 
-		// Create site metrics entries, preserving existing metrics data
-		for siteID, site := range siteList {
+		// Create site metrics entries in name order, so the site limit keeps the
+		// same sites; MergeSites preserves existing metrics data
+		for _, siteID := range pantheon.SortedSiteIDs(siteList) {
+			site := siteList[siteID]
 			// Check if we've reached the site limit
 			if rm.siteLimit > 0 && len(allSiteMetrics) >= rm.siteLimit {
 				log.Printf("Site limit reached (%d sites), stopping refresh", rm.siteLimit)
@@ -200,35 +600,44 @@ func (rm *Manager) refreshAllSiteLists() {
 			key := accountID + ":" + site.Name
 			newSitesMap[key] = true
 
-			metricsData := existingMetricsMap[key]
-			if metricsData == nil {
-				metricsData = make(map[string]pantheon.MetricData)
-			}
-
 			siteMetrics := pantheon.SiteMetrics{
-				SiteName:    site.Name,
-				SiteID:      siteID,
-				Label:       site.Name,
-				PlanName:    site.PlanName,
-				Account:     accountID,
-				MetricsData: metricsData,
+				SiteName:  site.Name,
+				SiteID:    siteID,
+				Label:     site.DisplayLabel(),
+				PlanName:  site.PlanName,
+				Account:   accountID,
+				Owner:     site.DisplayOwner(),
+				Created:   site.Created,
+				Frozen:    site.Frozen,
+				Framework: site.Framework,
+				Tags:      site.Tags,
+				Source:    site.Source,
 			}
 			allSiteMetrics = append(allSiteMetrics, siteMetrics)
 		}
 	}
 
-	// Find added and removed sites
-	addedSites := findAddedSites(currentSitesMap, newSitesMap, rm.discoveredSites)
-	removedSites := findRemovedSites(currentSitesMap, newSitesMap)
+	// Report sites shared by several accounts under a single account
+	if rm.siteFilter.Dedupe {
+		allSiteMetrics = rm.siteFilter.DedupeShared(allSiteMetrics)
+		newSitesMap = buildSiteKeyMap(allSiteMetrics)
+	}
 
-	// Mark newly added sites as discovered
+	// Find added and removed sites, marking newly added sites as discovered
+	rm.mu.Lock()
+	addedSites := findAddedSites(currentSitesMap, newSitesMap, rm.discoveredSites)
 	for _, key := range addedSites {
 		rm.discoveredSites[key] = true
 	}
+	if !failed {
+		rm.lastSiteListRefresh = time.Now()
+	}
+	rm.mu.Unlock()
+	removedSites := findRemovedSites(currentSitesMap, newSitesMap)
 
 	// Update collector
 	if len(allSiteMetrics) > 0 {
-		rm.collector.UpdateSites(allSiteMetrics)
+		rm.collector.MergeSites(allSiteMetrics)
 		log.Printf("Site list updated: %d sites found", totalSitesFound)
 
 		if len(addedSites) > 0 {
@@ -238,18 +647,88 @@ func (rm *Manager) refreshAllSiteLists() {
 		if len(removedSites) > 0 {
 			log.Printf("Sites removed: %v", removedSites)
 		}
+		// Environments of removed sites are no longer reported
+		for _, site := range existingSites {
+			if !newSitesMap[site.Account+":"+site.SiteName] {
+				rm.environments.forget(site.Account, site.SiteName)
+			}
+		}
+
+		// Fetch newly added sites ahead of the rotation, unless every site is
+		// new because the collector had none yet. They are queued only once
+		// merged, so their first fetch finds them unfetched and gets the full
+		// history, and its results are kept.
+		if len(addedSites) > 0 && len(existingSites) > 0 {
+			rm.queueNewSites(addedSites, allSiteMetrics)
+		}
+	}
+}
+
+// queueNewSites queues the sites in sites whose keys are in added for an
+// immediate metrics fetch, and wakes the metrics loop. Only sites already in
+// the collector are queued, as a fetch for any other site would be dropped.
+func (rm *Manager) queueNewSites(added []string, sites []pantheon.SiteMetrics) {
+	keys := make(map[string]bool, len(added))
+	for _, key := range added {
+		keys[key] = true
+	}
+
+	var queued []pantheon.SiteMetrics
+	for _, site := range sites {
+		if !keys[site.Account+":"+site.SiteName] {
+			continue
+		}
+		if merged, ok := rm.collector.GetSite(site.Account, site.SiteName); ok {
+			queued = append(queued, merged)
+		}
+	}
+	if len(queued) == 0 {
+		return
+	}
+
+	rm.mu.Lock()
+	rm.newSites = append(rm.newSites, queued...)
+	rm.mu.Unlock()
+
+	select {
+	case rm.newSitesReady <- struct{}{}:
+	default: // The loop is already signalled
 	}
 }
 
-// refreshMetricsWithQueue processes metrics refresh using a queue to prevent stampedes
+// takeNewSites returns and clears the sites queued by queueNewSites
+func (rm *Manager) takeNewSites() []pantheon.SiteMetrics {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	sites := rm.newSites
+	rm.newSites = nil
+	return sites
+}
+
+// refreshMetricsWithQueue processes metrics refresh using a queue to prevent stampedes.
+// Sites added by a site list refresh are fetched as soon as they are queued,
+// without waiting for the next tick or for the rotation to reach them.
 func (rm *Manager) refreshMetricsWithQueue() {
-	ticker := time.NewTicker(rm.tickerInterval)
+	ticker := newJitterTicker(rm.tickerInterval, rm.jitter)
 	defer ticker.Stop()
 
 	siteIndex := 0
 	lastTotalSites := 0
 
-	for range ticker.C {
+	for {
+		select {
+		case <-rm.stop:
+			return
+		case <-rm.newSitesReady:
+			newSites := rm.takeNewSites()
+			log.Printf("Refreshing metrics for %d newly added sites", len(newSites))
+			if !rm.refreshBatch(newSites) {
+				return
+			}
+			continue
+		case <-ticker.C:
+		}
+
 		// Increment ticker fire count for testing
 		atomic.AddInt64(&rm.tickerFireCount, 1)
 		// Get current sites
@@ -259,19 +738,18 @@ func (rm *Manager) refreshMetricsWithQueue() {
 			continue
 		}
 
-		// If this is the first time we have sites, log the configuration
-		if lastTotalSites == 0 && len(currentSites) > 0 {
-			totalSites := len(currentSites)
-			refreshMinutes := rm.refreshInterval.Minutes()
-			sitesPerMinute := int(math.Ceil(float64(totalSites) / refreshMinutes))
-			log.Printf("Metrics refresh: processing %d sites per minute (%d sites total, %.0f minute interval)",
-				sitesPerMinute, totalSites, refreshMinutes)
-		}
+		// Priority sites are refreshed on every tick; the rest rotate in batches
+		prioritySites, currentSites := partitionPrioritySites(currentSites, rm.prioritySites)
 
 		// Recalculate sites per minute in case site count has changed
 		totalSites := len(currentSites)
-		refreshMinutes := rm.refreshInterval.Minutes()
-		sitesPerMinute := int(math.Ceil(float64(totalSites) / refreshMinutes))
+		sitesPerMinute := sitesPerTick(totalSites, rm.refreshInterval)
+
+		// If this is the first time we have sites, log the configuration
+		if lastTotalSites == 0 {
+			log.Printf("Metrics refresh: processing %d sites per minute (%d sites total, %.0f minute interval)",
+				sitesPerMinute, totalSites, rm.refreshInterval.Minutes())
+		}
 
 		// Reset index if it exceeds current site count
 		if siteIndex >= len(currentSites) {
@@ -279,57 +757,111 @@ func (rm *Manager) refreshMetricsWithQueue() {
 		}
 
 		// Process the next batch of sites
-		endIndex := siteIndex + sitesPerMinute
-		if endIndex > len(currentSites) {
-			endIndex = len(currentSites)
-		}
-
-		sitesToProcess := currentSites[siteIndex:endIndex]
-		log.Printf("Refreshing metrics for %d sites (sites %d-%d of %d)",
-			len(sitesToProcess), siteIndex+1, endIndex, len(currentSites))
+		// Sites still needing their 28 day history take more of the budget
+		sitesToProcess, endIndex := nextBatch(prioritySites, currentSites, siteIndex, sitesPerMinute, rm.fetchWeight)
+		log.Printf("Refreshing metrics for %d sites (sites %d-%d of %d, plus %d priority sites)",
+			len(sitesToProcess), siteIndex+1, endIndex, len(currentSites), len(prioritySites))
 
-		for _, site := range sitesToProcess {
-			go rm.refreshSiteMetrics(site.Account, site.SiteName, site.SiteID)
+		if !rm.refreshBatch(sitesToProcess) {
+			return
 		}
 
 		siteIndex = endIndex
-		if siteIndex >= len(currentSites) {
+		if siteIndex >= len(currentSites) && len(currentSites) > 0 {
 			siteIndex = 0
 			log.Printf("Completed full metrics refresh cycle, starting over")
+			logCycleSummary(rm.cycle.finish(len(currentSites)+len(prioritySites), time.Now()), rm.logFormat)
+			rm.saveSnapshot()
 		}
 
 		lastTotalSites = totalSites
 	}
 }
 
-// refreshSiteMetrics refreshes metrics for a single site
-func (rm *Manager) refreshSiteMetrics(accountID, siteName, siteID string) {
+// saveSnapshot saves the collector to the snapshot file, if one is set
+func (rm *Manager) saveSnapshot() {
+	if rm.snapshotFile == "" {
+		return
+	}
+	if err := rm.collector.SaveSnapshot(rm.snapshotFile); err != nil {
+		log.Printf("Warning: Failed to save metrics snapshot: %v", err)
+	}
+}
+
+// RefreshSite refreshes a monitored site's metrics immediately, returning once
+// the refresh has finished. It returns ErrUnknownSite if the site isn't in the
+// collector, or the error that made the refresh fail.
+func (rm *Manager) RefreshSite(accountID, siteName string) error {
+	site, ok := rm.collector.GetSite(accountID, siteName)
+	if !ok {
+		return ErrUnknownSite
+	}
+	return rm.refreshSiteMetrics(accountID, siteName, site.SiteID)
+}
+
+// refreshSiteMetrics refreshes metrics for a single site, returning why it failed
+func (rm *Manager) refreshSiteMetrics(accountID, siteName, siteID string) error {
 	ctx := context.Background()
 
 	// Find the token for this account from the mapping
-	token, ok := rm.accountTokenMap[accountID]
+	token, ok := rm.getAccountToken(accountID)
 	if !ok {
 		log.Printf("Warning: No token found for account %s", accountID)
-		return
+		return fmt.Errorf("no token found for account %s", accountID)
 	}
 
-	// Determine duration based on whether this site has been fetched before
+	// Skip sites the token was recently forbidden from reading. This is checked
+	// first so a skipped site never takes the account's half-open probe.
+	if rm.unauthorized.skip(accountID, siteName) {
+		return fmt.Errorf("site %s.%s is skipped after its metrics were forbidden", accountID, siteName)
+	}
+
+	// Skip accounts whose circuit breaker is open
+	if !rm.breaker.Allow(accountID) {
+		return fmt.Errorf("account %s is paused after repeated failures", accountID)
+	}
+
+	// Determine duration based on whether this site's history has been fetched
 	duration := RefreshMetricsDuration
 	key := accountID + ":" + siteName
-	if !rm.discoveredSites[key] {
-		// First time fetching this site, use longer duration
+	site, ok := rm.collector.GetSite(accountID, siteName)
+	if rm.claimFetch(key, ok && len(site.MetricsData) > 0) {
 		duration = InitialMetricsDuration
-		rm.discoveredSites[key] = true
+	}
+	if rm.skipHistory {
+		// Even a site's first fetch only covers the latest day
+		duration = RefreshMetricsDuration
 	}
 
 	// Fetch metrics for this site
-	metricsData, err := rm.client.FetchMetricsData(ctx, token, siteID, rm.environment, duration)
+	metricsData, usedEnv, err := pantheon.FetchMetricsWithFallback(ctx, rm.client, token, siteID, rm.environment, rm.fallbackEnv, duration)
 	if err != nil {
+		rm.collector.RecordSiteFailure(accountID, siteName, err)
+		rm.environments.record(accountID, siteName, rm.environment, false)
+		rm.cycle.record(accountID, false)
+		// A forbidden site is a role problem with that site, not a failing account
+		if errors.Is(err, pantheon.ErrForbidden) && rm.unauthorized.backOff(accountID, siteName) {
+			rm.breaker.Release(accountID)
+			log.Printf("Warning: Not allowed to read metrics for %s.%s, skipping it for %v: %v", accountID, siteName, rm.unauthorized.cooldown, err)
+			return err
+		}
 		log.Printf("Warning: Failed to refresh metrics for %s.%s: %v", accountID, siteName, err)
-		return
+		if rm.breaker.RecordFailure(accountID) {
+			log.Printf("Warning: Too many failures for account %s, skipping its sites for %v", accountID, rm.breaker.cooldown)
+		}
+		return err
+	}
+	rm.breaker.RecordSuccess(accountID)
+	rm.unauthorized.clear(accountID, siteName)
+	if duration == InitialMetricsDuration {
+		rm.markHistoryFetched(key)
 	}
+	rm.environments.record(accountID, siteName, usedEnv, true)
+	rm.cycle.record(accountID, true)
 
 	// Update the collector
 	rm.collector.UpdateSiteMetrics(accountID, siteName, metricsData)
+	rm.collector.SetSiteEnvironment(accountID, siteName, usedEnv)
 	log.Printf("Updated metrics for site %s.%s", accountID, siteName)
+	return nil
 }
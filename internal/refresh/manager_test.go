@@ -1,10 +1,17 @@
 package refresh
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/collector"
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/filter"
 	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
 )
 
@@ -313,6 +320,24 @@ func TestInitializeDiscoveredSites(t *testing.T) {
 	}
 }
 
+func TestResetDiscoveredSites(t *testing.T) {
+	manager, _ := newDelayedManager(2, 0)
+	manager.InitializeDiscoveredSites()
+	if len(manager.discoveredSites) != 2 {
+		t.Fatalf("Expected 2 discovered sites, got %d", len(manager.discoveredSites))
+	}
+
+	manager.ResetDiscoveredSites()
+	if len(manager.discoveredSites) != 0 {
+		t.Errorf("Expected no discovered sites after a reset, got %d", len(manager.discoveredSites))
+	}
+	manager.markHistoryFetched("account@example.com:site0")
+	manager.ResetDiscoveredSites()
+	if !manager.claimFetch("account@example.com:site0", true) {
+		t.Error("Expected a site's full history to be fetched again after a reset")
+	}
+}
+
 func TestInitializeDiscoveredSitesWithSites(t *testing.T) {
 	client := pantheon.NewClient(false)
 	tokens := []string{"token1"}
@@ -793,3 +818,1070 @@ func TestInitializeAccountTokenMapWithInvalidTokens(t *testing.T) {
 	// Since auth fails, the map should remain empty (or have fallback account IDs)
 	// The important thing is that the method doesn't panic
 }
+
+func TestAllowedAccounts(t *testing.T) {
+	const allowedToken = "allowed-token-0000000000000000000"
+	const otherToken = "other-token-00000000000000000000"
+
+	client := newFakeClient()
+	client.accounts[allowedToken] = "allowed@example.com"
+	client.accounts[otherToken] = "other@example.com"
+	client.sites[allowedToken] = map[string]pantheon.SiteListEntry{
+		"site-uuid-1": {Name: "allowed-site", ID: "site-uuid-1"},
+	}
+	client.sites[otherToken] = map[string]pantheon.SiteListEntry{
+		"site-uuid-2": {Name: "other-site", ID: "site-uuid-2"},
+	}
+
+	coll := collector.NewPantheonCollector(nil)
+	manager := NewManager(client, []string{allowedToken, otherToken}, testEnvLive, time.Minute, coll, 0, "")
+	manager.SetSiteFilter(filter.Sites{Accounts: []string{"Allowed@Example.com"}})
+
+	manager.InitializeAccountTokenMap()
+	if _, ok := manager.getAccountToken("allowed@example.com"); !ok {
+		t.Error("Expected the allowed account in the token map")
+	}
+	if _, ok := manager.getAccountToken("other@example.com"); ok {
+		t.Error("Expected the disallowed account to be dropped from the token map")
+	}
+
+	manager.refreshAllSiteLists()
+	sites := coll.GetSites()
+	if len(sites) != 1 || sites[0].SiteName != "allowed-site" {
+		t.Errorf("Expected only the allowed account's site after a site list refresh, got %v", sites)
+	}
+}
+
+func TestSetJitter(t *testing.T) {
+	client := pantheon.NewClient(false)
+	collector := collector.NewPantheonCollector([]pantheon.SiteMetrics{})
+	manager := NewManager(client, []string{}, testEnvLive, 1*time.Minute, collector, 0, "")
+
+	if manager.jitter != 0 {
+		t.Errorf("Expected no jitter by default, got %v", manager.jitter)
+	}
+
+	manager.SetJitter(10)
+	if manager.jitter != 0.1 {
+		t.Errorf("Expected jitter fraction 0.1, got %v", manager.jitter)
+	}
+}
+
+// fakeClient is a thread-safe in-memory pantheon.ClientInterface
+type fakeClient struct {
+	mu            sync.Mutex
+	accounts      map[string]string // token -> account email
+	sites         map[string]map[string]pantheon.SiteListEntry
+	durations     map[string]string // siteID -> last requested duration
+	metricsCalls  int
+	siteListCalls int
+	invalidations int           // Calls to InvalidateOrgCache
+	metricsErr    error         // Returned by FetchMetricsData when set
+	siteListErr   error         // Returned by FetchAllSites when set
+	metricsDelay  time.Duration // How long FetchMetricsData takes
+	noMetrics     bool          // FetchMetricsData returns no data points when set
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		accounts:  make(map[string]string),
+		sites:     make(map[string]map[string]pantheon.SiteListEntry),
+		durations: make(map[string]string),
+	}
+}
+
+func (f *fakeClient) Authenticate(_ context.Context, token string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	email, ok := f.accounts[token]
+	if !ok {
+		return "", pantheon.ErrAuthFailed
+	}
+	return email, nil
+}
+
+func (f *fakeClient) GetEmail(ctx context.Context, token string) (string, error) {
+	return f.Authenticate(ctx, token)
+}
+
+func (f *fakeClient) FetchAllSites(_ context.Context, token, _ string) (map[string]pantheon.SiteListEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.siteListCalls++
+	if f.siteListErr != nil {
+		return nil, f.siteListErr
+	}
+	result := make(map[string]pantheon.SiteListEntry, len(f.sites[token]))
+	for id, site := range f.sites[token] {
+		result[id] = site
+	}
+	return result, nil
+}
+
+func (f *fakeClient) FetchMetricsData(_ context.Context, _, siteID, _, duration string) (map[string]pantheon.MetricData, error) {
+	time.Sleep(f.metricsDelay)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.metricsCalls++
+	f.durations[siteID] = duration
+	if f.metricsErr != nil {
+		return nil, f.metricsErr
+	}
+	if f.noMetrics {
+		return map[string]pantheon.MetricData{}, nil
+	}
+	return map[string]pantheon.MetricData{
+		"1762732800": {DateTime: "2025-11-10T00:00:00", Visits: 10},
+	}, nil
+}
+
+func (f *fakeClient) FetchSiteInfo(_ context.Context, _, siteID string) (*pantheon.SiteInfo, error) {
+	return &pantheon.SiteInfo{ID: siteID}, nil
+}
+
+func (f *fakeClient) InvalidateOrgCache() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.invalidations++
+}
+
+func (f *fakeClient) InvalidateSession(_ string) {}
+
+func TestRefreshSiteMetricsConcurrent(t *testing.T) {
+	const account = "account@example.com"
+	const siteCount = 50
+
+	client := newFakeClient()
+	client.accounts[testToken32] = account
+	siteList := make(map[string]pantheon.SiteListEntry)
+	var sites []pantheon.SiteMetrics
+	for i := 0; i < siteCount; i++ {
+		id := fmt.Sprintf("site-uuid-%d", i)
+		name := fmt.Sprintf("site%d", i)
+		siteList[id] = pantheon.SiteListEntry{Name: name, ID: id, PlanName: "Basic"}
+		sites = append(sites, pantheon.SiteMetrics{
+			SiteName:    name,
+			SiteID:      id,
+			Account:     account,
+			MetricsData: make(map[string]pantheon.MetricData),
+		})
+	}
+	client.sites[testToken32] = siteList
+
+	coll := collector.NewPantheonCollector(sites)
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Minute, coll, 0, "")
+	manager.InitializeAccountTokenMap()
+
+	var wg sync.WaitGroup
+	for _, site := range sites {
+		wg.Add(1)
+		go func(site pantheon.SiteMetrics) {
+			defer wg.Done()
+			manager.refreshSiteMetrics(site.Account, site.SiteName, site.SiteID)
+		}(site)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		manager.refreshAllSiteLists()
+	}()
+	wg.Wait()
+
+	if client.metricsCalls != siteCount {
+		t.Errorf("Expected %d metrics fetches, got %d", siteCount, client.metricsCalls)
+	}
+	for _, site := range sites {
+		key := site.Account + ":" + site.SiteName
+		if !manager.discoveredSites[key] {
+			t.Errorf("Expected site %s to be marked as discovered", key)
+		}
+		if client.durations[site.SiteID] != InitialMetricsDuration {
+			t.Errorf("Expected first fetch of %s to use %s, got %s", key, InitialMetricsDuration, client.durations[site.SiteID])
+		}
+	}
+}
+
+func TestLastSiteListRefresh(t *testing.T) {
+	client := newFakeClient()
+	client.accounts[testToken32] = "account@example.com"
+	client.sites[testToken32] = map[string]pantheon.SiteListEntry{
+		"site-uuid-1": {Name: "site1", ID: "site-uuid-1"},
+	}
+
+	coll := collector.NewPantheonCollector(nil)
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Minute, coll, 0, "")
+
+	if !manager.LastSiteListRefresh().IsZero() {
+		t.Error("Expected no site list refresh before the first refresh")
+	}
+
+	manager.refreshAllSiteLists()
+	first := manager.LastSiteListRefresh()
+	if first.IsZero() {
+		t.Fatal("Expected site list refresh time to be set after a successful refresh")
+	}
+
+	time.Sleep(time.Millisecond)
+	manager.refreshAllSiteLists()
+	if !manager.LastSiteListRefresh().After(first) {
+		t.Error("Expected site list refresh time to advance after another successful refresh")
+	}
+}
+
+func TestLastSiteListRefreshNotUpdatedOnFailure(t *testing.T) {
+	client := newFakeClient()
+	client.accounts[testToken32] = "account@example.com"
+
+	coll := collector.NewPantheonCollector(nil)
+	// The second token is unknown to the fake client, so its authentication fails
+	manager := NewManager(client, []string{testToken32, "unknown-token"}, testEnvLive, time.Minute, coll, 0, "")
+
+	manager.refreshAllSiteLists()
+	if !manager.LastSiteListRefresh().IsZero() {
+		t.Error("Expected site list refresh time to stay unset when an account fails")
+	}
+}
+
+func TestSetSiteListInterval(t *testing.T) {
+	coll := collector.NewPantheonCollector(nil)
+	manager := NewManager(newFakeClient(), nil, testEnvLive, 15*time.Minute, coll, 0, "")
+
+	if manager.siteListInterval != 15*time.Minute {
+		t.Errorf("Expected site list interval to default to the refresh interval, got %v", manager.siteListInterval)
+	}
+
+	manager.SetSiteListInterval(time.Hour)
+	if manager.siteListInterval != time.Hour {
+		t.Errorf("Expected site list interval 1h, got %v", manager.siteListInterval)
+	}
+	if manager.refreshInterval != 15*time.Minute {
+		t.Errorf("Expected refresh interval to stay 15m, got %v", manager.refreshInterval)
+	}
+
+	manager.SetSiteListInterval(0)
+	if manager.siteListInterval != time.Hour {
+		t.Errorf("Expected zero interval to be ignored, got %v", manager.siteListInterval)
+	}
+}
+
+func TestSiteListRefreshUsesSiteListInterval(t *testing.T) {
+	client := newFakeClient()
+	client.accounts[testToken32] = "account@example.com"
+
+	coll := collector.NewPantheonCollector(nil)
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Hour, coll, 0, "")
+	manager.SetSiteListInterval(20 * time.Millisecond)
+
+	go manager.refreshSiteListsPeriodically()
+	time.Sleep(150 * time.Millisecond)
+
+	client.mu.Lock()
+	calls := client.siteListCalls
+	client.mu.Unlock()
+
+	// With the hour-long refresh interval no refresh would have happened yet
+	if calls < 2 {
+		t.Errorf("Expected site lists to refresh on the site list interval, got %d refreshes", calls)
+	}
+}
+
+func TestSitesPerTick(t *testing.T) {
+	tests := []struct {
+		totalSites int
+		interval   time.Duration
+		expected   int
+	}{
+		{totalSites: 100, interval: 60 * time.Minute, expected: 2},
+		{totalSites: 100, interval: 15 * time.Minute, expected: 7},
+		{totalSites: 60, interval: 60 * time.Minute, expected: 1},
+		{totalSites: 1, interval: 60 * time.Minute, expected: 1},
+		{totalSites: 100, interval: 0, expected: 100},
+		{totalSites: 100, interval: 30 * time.Second, expected: 100},
+		{totalSites: 0, interval: 0, expected: 0},
+	}
+
+	for _, tt := range tests {
+		if got := sitesPerTick(tt.totalSites, tt.interval); got != tt.expected {
+			t.Errorf("sitesPerTick(%d, %v) = %d, expected %d", tt.totalSites, tt.interval, got, tt.expected)
+		}
+	}
+}
+
+func TestRefreshSiteMetricsCircuitBreaker(t *testing.T) {
+	const account = "account@example.com"
+
+	client := newFakeClient()
+	client.metricsErr = pantheon.ErrAuthFailed
+
+	coll := collector.NewPantheonCollector([]pantheon.SiteMetrics{{SiteName: "site1", SiteID: "site-uuid-1", Account: account}})
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Minute, coll, 0, "")
+	manager.SetBreakerThreshold(2)
+	manager.accountTokenMap[account] = testToken32
+
+	for i := 0; i < 5; i++ {
+		manager.refreshSiteMetrics(account, "site1", "site-uuid-1")
+	}
+
+	if client.metricsCalls != 2 {
+		t.Errorf("Expected fetches to stop after 2 failures, got %d calls", client.metricsCalls)
+	}
+	if !manager.AccountCircuitsOpen()[account] {
+		t.Error("Expected account circuit to be open")
+	}
+}
+
+func TestRefreshSiteMetricsFetchesHistoryForEmptySite(t *testing.T) {
+	const account = "account@example.com"
+
+	client := newFakeClient()
+	coll := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "site1", SiteID: "site-uuid-1", Account: account, MetricsData: map[string]pantheon.MetricData{}},
+	})
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Minute, coll, 0, "")
+	manager.accountTokenMap[account] = testToken32
+
+	// Discovered at startup but never fetched, e.g. because the initial collection timed out
+	manager.InitializeDiscoveredSites()
+	manager.refreshSiteMetrics(account, "site1", "site-uuid-1")
+	if client.durations["site-uuid-1"] != InitialMetricsDuration {
+		t.Errorf("Expected unfetched site to use %s, got %s", InitialMetricsDuration, client.durations["site-uuid-1"])
+	}
+
+	// Once it has metrics, later refreshes use the short duration
+	manager.refreshSiteMetrics(account, "site1", "site-uuid-1")
+	if client.durations["site-uuid-1"] != RefreshMetricsDuration {
+		t.Errorf("Expected fetched site to use %s, got %s", RefreshMetricsDuration, client.durations["site-uuid-1"])
+	}
+}
+
+func TestRefreshSiteMetricsFetchesHistoryOnceForSiteWithoutData(t *testing.T) {
+	const account = "account@example.com"
+
+	client := newFakeClient()
+	client.noMetrics = true
+	site := pantheon.SiteMetrics{SiteName: "site1", SiteID: "site-uuid-1", Account: account}
+	coll := collector.NewPantheonCollector([]pantheon.SiteMetrics{site})
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Minute, coll, 0, "")
+	manager.accountTokenMap[account] = testToken32
+	manager.InitializeDiscoveredSites()
+
+	// A failed history fetch is retried in full
+	client.metricsErr = errors.New("api unavailable")
+	manager.refreshSiteMetrics(account, "site1", "site-uuid-1")
+	client.metricsErr = nil
+	manager.refreshSiteMetrics(account, "site1", "site-uuid-1")
+	if client.durations["site-uuid-1"] != InitialMetricsDuration {
+		t.Errorf("Expected a failed history fetch to be retried with %s, got %s", InitialMetricsDuration, client.durations["site-uuid-1"])
+	}
+
+	// A site that returned no data, such as a frozen site, isn't fetched in full again
+	if weight := manager.fetchWeight(site); weight != 1 {
+		t.Errorf("Expected a site without data to stop counting as a full fetch, got weight %d", weight)
+	}
+	manager.refreshSiteMetrics(account, "site1", "site-uuid-1")
+	if client.durations["site-uuid-1"] != RefreshMetricsDuration {
+		t.Errorf("Expected a site without data to fall back to %s, got %s", RefreshMetricsDuration, client.durations["site-uuid-1"])
+	}
+}
+
+func TestReloadSiteLists(t *testing.T) {
+	const account = "account@example.com"
+	client := newFakeClient()
+	client.accounts[testToken32] = account
+	client.sites[testToken32] = map[string]pantheon.SiteListEntry{
+		"site-uuid-1": {Name: "site1", ID: "site-uuid-1"},
+	}
+	coll := collector.NewPantheonCollector(nil)
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Hour, coll, 0, "")
+
+	manager.ReloadSiteLists()
+
+	if client.invalidations != 1 {
+		t.Errorf("Expected the organization cache to be invalidated once, got %d", client.invalidations)
+	}
+	if client.siteListCalls != 1 {
+		t.Errorf("Expected the site list to be fetched once, got %d", client.siteListCalls)
+	}
+	if _, ok := coll.GetSite(account, "site1"); !ok {
+		t.Error("Expected the reloaded site list to add site1")
+	}
+}
+
+func TestRefreshAllSiteListsDedupe(t *testing.T) {
+	const otherToken = "abcdefabcdefabcdefabcdefabcdefab"
+
+	client := newFakeClient()
+	client.accounts[testToken32] = "agency@example.com"
+	client.accounts[otherToken] = "client@example.com"
+	client.sites[testToken32] = map[string]pantheon.SiteListEntry{
+		"shared": {Name: "client-site", ID: "shared"},
+	}
+	client.sites[otherToken] = map[string]pantheon.SiteListEntry{
+		"shared": {Name: "client-site", ID: "shared"},
+	}
+
+	coll := collector.NewPantheonCollector(nil)
+	manager := NewManager(client, []string{testToken32, otherToken}, testEnvLive, time.Minute, coll, 0, "")
+	manager.SetSiteFilter(filter.Sites{Dedupe: true, PreferAccounts: []string{"client@example.com"}})
+
+	manager.refreshAllSiteLists()
+
+	sites := coll.GetSites()
+	if len(sites) != 1 {
+		t.Fatalf("Expected shared site to be reported once, got %d entries", len(sites))
+	}
+	if sites[0].Account != "client@example.com" {
+		t.Errorf("Expected shared site to be owned by the preferred account, got %s", sites[0].Account)
+	}
+}
+
+// newDelayedManager returns a manager for siteCount sites whose metrics fetches take delay
+func newDelayedManager(siteCount int, delay time.Duration) (*Manager, *collector.PantheonCollector) {
+	const account = "account@example.com"
+	client := newFakeClient()
+	client.accounts[testToken32] = account
+	client.metricsDelay = delay
+
+	var sites []pantheon.SiteMetrics
+	for i := 0; i < siteCount; i++ {
+		sites = append(sites, pantheon.SiteMetrics{
+			SiteName: fmt.Sprintf("site%d", i),
+			SiteID:   fmt.Sprintf("site-uuid-%d", i),
+			Account:  account,
+		})
+	}
+
+	coll := collector.NewPantheonCollector(sites)
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Minute, coll, 0, "")
+	manager.InitializeAccountTokenMap()
+	return manager, coll
+}
+
+func TestStopWaitsForInFlightRefreshes(t *testing.T) {
+	const delay = 100 * time.Millisecond
+	manager, coll := newDelayedManager(3, delay)
+
+	start := time.Now()
+	for _, site := range coll.GetSites() {
+		if !manager.startSiteRefresh(site.Account, site.SiteName, site.SiteID, nil) {
+			t.Fatal("Expected refresh to start before Stop")
+		}
+	}
+
+	if !manager.Stop(5 * time.Second) {
+		t.Fatal("Expected in-flight refreshes to finish before the timeout")
+	}
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Errorf("Expected Stop to block for at least %v, returned after %v", delay, elapsed)
+	}
+	for _, site := range coll.GetSites() {
+		if len(site.MetricsData) == 0 {
+			t.Errorf("Expected metrics for %s to be written before Stop returned", site.SiteName)
+		}
+	}
+
+	// No refreshes start after Stop
+	if manager.startSiteRefresh("account@example.com", "site0", "site-uuid-0", nil) {
+		t.Error("Expected no refresh to start after Stop")
+	}
+}
+
+func TestStopTimesOut(t *testing.T) {
+	manager, coll := newDelayedManager(2, 2*time.Second)
+
+	for _, site := range coll.GetSites() {
+		manager.startSiteRefresh(site.Account, site.SiteName, site.SiteID, nil)
+	}
+
+	start := time.Now()
+	if manager.Stop(50 * time.Millisecond) {
+		t.Error("Expected Stop to report that refreshes were still running")
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("Expected Stop to return after its timeout, took %v", elapsed)
+	}
+
+	// Stop can be called again, e.g. with a longer timeout
+	if !manager.Stop(5 * time.Second) {
+		t.Error("Expected refreshes to finish within the longer timeout")
+	}
+}
+
+func TestRefreshInProgress(t *testing.T) {
+	manager, coll := newDelayedManager(2, 100*time.Millisecond)
+	defer manager.Stop(5 * time.Second)
+
+	if manager.RefreshInProgress() {
+		t.Fatal("Expected no refresh in progress before a batch starts")
+	}
+	if !manager.refreshBatch(coll.GetSites()) {
+		t.Fatal("Expected the batch to start")
+	}
+	if !manager.RefreshInProgress() {
+		t.Error("Expected a refresh in progress while the batch runs")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for manager.RefreshInProgress() {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the refresh to finish once the batch completed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAfterRefresh(t *testing.T) {
+	manager, coll := newDelayedManager(2, 50*time.Millisecond)
+	defer manager.Stop(5 * time.Second)
+
+	withData := make(chan int, 1)
+	manager.SetAfterRefresh(func() {
+		count := 0
+		for _, site := range coll.GetSites() {
+			if len(site.MetricsData) > 0 {
+				count++
+			}
+		}
+		withData <- count
+	})
+	if !manager.refreshBatch(coll.GetSites()) {
+		t.Fatal("Expected the batch to start")
+	}
+
+	select {
+	case count := <-withData:
+		if count != 2 {
+			t.Errorf("Expected both sites to have metrics when the batch finished, got %d", count)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the after refresh function to be called once the batch finished")
+	}
+}
+
+func TestRefreshGoroutines(t *testing.T) {
+	manager, coll := newDelayedManager(3, 100*time.Millisecond)
+	defer manager.Stop(5 * time.Second)
+
+	if got := manager.RefreshGoroutines(); got != 0 {
+		t.Fatalf("Expected no refresh goroutines before a batch starts, got %d", got)
+	}
+	if !manager.refreshBatch(coll.GetSites()) {
+		t.Fatal("Expected the batch to start")
+	}
+	if got := manager.RefreshGoroutines(); got != 3 {
+		t.Errorf("Expected 3 refresh goroutines while the batch runs, got %d", got)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for manager.RefreshGoroutines() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected refresh goroutines to finish, %d still running", manager.RefreshGoroutines())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPriorityBatches(t *testing.T) {
+	var sites []pantheon.SiteMetrics
+	for i := 0; i < 10; i++ {
+		sites = append(sites, pantheon.SiteMetrics{SiteName: fmt.Sprintf("site%d", i), Account: "account1"})
+	}
+
+	manager := NewManager(newFakeClient(), nil, testEnvLive, time.Minute, collector.NewPantheonCollector(nil), 0, "")
+	manager.SetPrioritySites([]string{"site3", "site7"})
+
+	prioritySites, normalSites := partitionPrioritySites(sites, manager.prioritySites)
+	if len(prioritySites) != 2 || len(normalSites) != 8 {
+		t.Fatalf("Expected 2 priority and 8 normal sites, got %d and %d", len(prioritySites), len(normalSites))
+	}
+
+	// Walk a full rotation of 3 normal sites per tick
+	refreshed := map[string]int{}
+	siteIndex := 0
+	for tick := 0; tick < 3; tick++ {
+		batch, endIndex := nextBatch(prioritySites, normalSites, siteIndex, 3, nil)
+
+		inBatch := map[string]bool{}
+		for _, site := range batch {
+			inBatch[site.SiteName] = true
+			refreshed[site.SiteName]++
+		}
+		if !inBatch["site3"] || !inBatch["site7"] {
+			t.Errorf("Tick %d: expected priority sites in batch, got %v", tick, inBatch)
+		}
+		siteIndex = endIndex
+	}
+
+	if siteIndex != len(normalSites) {
+		t.Errorf("Expected rotation to reach the end of the normal sites, got index %d", siteIndex)
+	}
+	for _, site := range sites {
+		expected := 1
+		if site.SiteName == "site3" || site.SiteName == "site7" {
+			expected = 3
+		}
+		if refreshed[site.SiteName] != expected {
+			t.Errorf("Expected %s to be refreshed %d times, got %d", site.SiteName, expected, refreshed[site.SiteName])
+		}
+	}
+}
+
+func TestWeightedBatches(t *testing.T) {
+	const account = "account@example.com"
+	metrics := map[string]pantheon.MetricData{"1762732800": {Visits: 1}}
+
+	var established, mixed []pantheon.SiteMetrics
+	for i := 0; i < 8; i++ {
+		name := fmt.Sprintf("site%d", i)
+		established = append(established, pantheon.SiteMetrics{SiteName: name, Account: account, MetricsData: metrics})
+		site := pantheon.SiteMetrics{SiteName: name, Account: account, MetricsData: metrics}
+		if i < 2 {
+			// Newly discovered sites have no metrics yet
+			site.SiteName = fmt.Sprintf("new%d", i)
+			site.MetricsData = nil
+		}
+		mixed = append(mixed, site)
+	}
+
+	manager := NewManager(newFakeClient(), nil, testEnvLive, time.Minute, collector.NewPantheonCollector(established), 0, "")
+	manager.InitializeDiscoveredSites()
+
+	establishedBatch, _ := nextBatch(nil, established, 0, 4, manager.fetchWeight)
+	mixedBatch, _ := nextBatch(nil, mixed, 0, 4, manager.fetchWeight)
+
+	if len(establishedBatch) != 4 {
+		t.Errorf("Expected 4 established sites per batch, got %d", len(establishedBatch))
+	}
+	if len(mixedBatch) >= len(establishedBatch) {
+		t.Errorf("Expected a batch with new sites to be smaller than %d, got %d", len(establishedBatch), len(mixedBatch))
+	}
+	if len(mixedBatch) == 0 || mixedBatch[0].SiteName != "new0" {
+		t.Errorf("Expected the batch to still include the first new site, got %v", mixedBatch)
+	}
+
+	// With history skipped every site costs the same
+	manager.SetSkipHistory(true)
+	if batch, _ := nextBatch(nil, mixed, 0, 4, manager.fetchWeight); len(batch) != 4 {
+		t.Errorf("Expected 4 sites per batch with history skipped, got %d", len(batch))
+	}
+}
+
+func TestPartitionPrioritySitesNone(t *testing.T) {
+	sites := []pantheon.SiteMetrics{{SiteName: "site1"}, {SiteName: "site2"}}
+	prioritySites, normalSites := partitionPrioritySites(sites, nil)
+	if len(prioritySites) != 0 || len(normalSites) != 2 {
+		t.Errorf("Expected every site in the rotation, got %d priority and %d normal", len(prioritySites), len(normalSites))
+	}
+}
+
+func TestRefreshSiteMetricsSkipHistory(t *testing.T) {
+	const account = "account@example.com"
+	client := newFakeClient()
+	client.accounts[testToken32] = account
+
+	coll := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "newsite", SiteID: "site-uuid-new", Account: account},
+	})
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Minute, coll, 0, "")
+	manager.InitializeAccountTokenMap()
+	manager.SetSkipHistory(true)
+
+	// A never-seen site would normally fetch InitialMetricsDuration
+	manager.refreshSiteMetrics(account, "newsite", "site-uuid-new")
+
+	if client.durations["site-uuid-new"] != RefreshMetricsDuration {
+		t.Errorf("Expected first fetch to use %s with history skipped, got %s", RefreshMetricsDuration, client.durations["site-uuid-new"])
+	}
+	if !manager.discoveredSites[account+":newsite"] {
+		t.Error("Expected site to be marked as discovered")
+	}
+}
+
+func TestSnapshotSavedAfterCycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	manager, _ := newDelayedManager(1, 0)
+	manager.SetTickerInterval(20 * time.Millisecond)
+	manager.SetSnapshotFile(path)
+	defer manager.Stop(5 * time.Second)
+
+	// A single site completes a cycle on every tick
+	go manager.refreshMetricsWithQueue()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected a snapshot to be saved after a refresh cycle")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAccountStatuses(t *testing.T) {
+	const badToken = "bad-token-0123456789abcdef0123456789"
+	client := newFakeClient()
+	client.accounts[testToken32] = "account@example.com"
+	client.sites[testToken32] = map[string]pantheon.SiteListEntry{
+		"site-uuid-1": {Name: "site1", ID: "site-uuid-1"},
+	}
+
+	manager := NewManager(client, []string{testToken32, badToken}, testEnvLive, time.Minute, collector.NewPantheonCollector(nil), 0, "")
+	manager.InitializeAccountTokenMap()
+
+	statuses := manager.AccountStatuses()
+	if len(statuses) != 2 {
+		t.Fatalf("Expected 2 account statuses, got %d", len(statuses))
+	}
+	// Sorted by account: the token-based ID sorts before the email
+	failing, healthy := statuses[0], statuses[1]
+	if failing.Account != pantheon.GetAccountID(badToken) || failing.Authenticated || failing.LastError == "" {
+		t.Errorf("Unexpected status for failing account: %+v", failing)
+	}
+	if healthy.Account != "account@example.com" || !healthy.Authenticated || !healthy.LastSiteListRefresh.IsZero() {
+		t.Errorf("Unexpected status for healthy account before a site list refresh: %+v", healthy)
+	}
+
+	manager.refreshAllSiteLists()
+	for _, status := range manager.AccountStatuses() {
+		if status.Account == "account@example.com" && status.LastSiteListRefresh.IsZero() {
+			t.Error("Expected site list refresh time to be recorded")
+		}
+	}
+}
+
+func TestRefreshSite(t *testing.T) {
+	const account = "account@example.com"
+
+	client := newFakeClient()
+	client.accounts[testToken32] = account
+	coll := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "site1", SiteID: "site-uuid-1", Account: account, MetricsData: make(map[string]pantheon.MetricData)},
+	})
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Minute, coll, 0, "")
+	manager.accountTokenMap[account] = testToken32
+
+	if err := manager.RefreshSite(account, "site1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if client.durations["site-uuid-1"] == "" {
+		t.Error("Expected metrics to be fetched for site-uuid-1")
+	}
+	site, _ := coll.GetSite(account, "site1")
+	if site.MetricsData["1762732800"].Visits != 10 {
+		t.Errorf("Expected refreshed metrics in the collector, got %v", site.MetricsData)
+	}
+
+	client.metricsErr = errors.New("api unavailable")
+	if err := manager.RefreshSite(account, "site1"); err == nil || errors.Is(err, ErrUnknownSite) {
+		t.Errorf("Expected the fetch error, got %v", err)
+	}
+}
+
+func TestRefreshSiteNotFound(t *testing.T) {
+	client := newFakeClient()
+	coll := collector.NewPantheonCollector(nil)
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Minute, coll, 0, "")
+
+	if err := manager.RefreshSite("account@example.com", "missing"); !errors.Is(err, ErrUnknownSite) {
+		t.Errorf("Expected ErrUnknownSite, got %v", err)
+	}
+	if client.metricsCalls != 0 {
+		t.Errorf("Expected no metrics fetch for an unknown site, got %d", client.metricsCalls)
+	}
+}
+
+func TestAccountCounts(t *testing.T) {
+	const validToken = "valid-token-00000000000000000000"
+
+	client := newFakeClient()
+	client.accounts[validToken] = "valid@example.com"
+	tokens := []string{validToken, "failing-token-1-0000000000000000", "failing-token-2-0000000000000000"}
+	manager := NewManager(client, tokens, testEnvLive, time.Minute, collector.NewPantheonCollector(nil), 0, "")
+
+	if authenticated, total := manager.AccountCounts(); authenticated != 0 || total != 3 {
+		t.Errorf("Expected 0 of 3 accounts before initialization, got %d of %d", authenticated, total)
+	}
+
+	manager.InitializeAccountTokenMap()
+	if authenticated, total := manager.AccountCounts(); authenticated != 1 || total != 3 {
+		t.Errorf("Expected 1 of 3 accounts authenticated, got %d of %d", authenticated, total)
+	}
+}
+
+func TestAccountUp(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         AccountStatus
+		metricsFetched bool
+		expected       bool
+	}{
+		{"healthy", AccountStatus{Authenticated: true}, true, true},
+		{"no metrics fetched yet", AccountStatus{Authenticated: true}, false, false},
+		{"site list failed", AccountStatus{Authenticated: true, SiteListFailed: true}, true, false},
+		{"authentication failed", AccountStatus{Authenticated: false}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := accountUp(tt.status, tt.metricsFetched); got != tt.expected {
+				t.Errorf("Expected accountUp = %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAccountsUp(t *testing.T) {
+	const badToken = "bad-token-0123456789abcdef0123456789"
+	const account = "account@example.com"
+
+	client := newFakeClient()
+	client.accounts[testToken32] = account
+	client.sites[testToken32] = map[string]pantheon.SiteListEntry{
+		"site-uuid-1": {Name: "site1", ID: "site-uuid-1"},
+	}
+	coll := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "site1", SiteID: "site-uuid-1", Account: account},
+	})
+	manager := NewManager(client, []string{testToken32, badToken}, testEnvLive, time.Minute, coll, 0, "")
+	manager.InitializeAccountTokenMap()
+
+	up := manager.AccountsUp()
+	if len(up) != 2 || up[account] || up[pantheon.GetAccountID(badToken)] {
+		t.Errorf("Expected both accounts down before any metrics fetch, got %v", up)
+	}
+
+	if err := manager.RefreshSite(account, "site1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if up := manager.AccountsUp(); !up[account] || up[pantheon.GetAccountID(badToken)] {
+		t.Errorf("Expected only %s up after a metrics fetch, got %v", account, up)
+	}
+
+	client.siteListErr = errors.New("api unavailable")
+	manager.refreshAllSiteLists()
+	if up := manager.AccountsUp(); up[account] {
+		t.Errorf("Expected %s down after a failed site list refresh, got %v", account, up)
+	}
+}
+
+func TestRefreshSiteMetricsForbidden(t *testing.T) {
+	const account = "account@example.com"
+
+	client := newFakeClient()
+	client.accounts[testToken32] = account
+	client.metricsErr = fmt.Errorf("failed to fetch metrics: %w", pantheon.ErrForbidden)
+	coll := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "site1", SiteID: "site-uuid-1", Account: account},
+	})
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Minute, coll, 0, "")
+	manager.accountTokenMap[account] = testToken32
+	now := time.Unix(1762732800, 0)
+	manager.unauthorized.now = func() time.Time { return now }
+
+	if err := manager.refreshSiteMetrics(account, "site1", "site-uuid-1"); !errors.Is(err, pantheon.ErrForbidden) {
+		t.Fatalf("Expected a forbidden error, got %v", err)
+	}
+	if sites := manager.UnauthorizedSites(); len(sites[account]) != 1 || sites[account][0] != "site1" {
+		t.Errorf("Expected site1 to be backed off, got %v", sites)
+	}
+
+	// The site isn't requested again during the cooldown, and the account stays usable
+	for i := 0; i < DefaultBreakerThreshold; i++ {
+		if err := manager.refreshSiteMetrics(account, "site1", "site-uuid-1"); err == nil {
+			t.Fatal("Expected the backed off site to be skipped")
+		}
+	}
+	if client.metricsCalls != 1 {
+		t.Errorf("Expected 1 metrics request during the cooldown, got %d", client.metricsCalls)
+	}
+	if open := manager.AccountCircuitsOpen(); open[account] {
+		t.Error("Expected a forbidden site not to open the account's circuit breaker")
+	}
+
+	// After the cooldown the site is retried, and a success clears it
+	now = now.Add(DefaultUnauthorizedCooldown)
+	client.metricsErr = nil
+	if err := manager.refreshSiteMetrics(account, "site1", "site-uuid-1"); err != nil {
+		t.Fatalf("Expected the site to be retried after the cooldown, got %v", err)
+	}
+	if sites := manager.UnauthorizedSites(); len(sites) != 0 {
+		t.Errorf("Expected no backed off sites after a successful retry, got %v", sites)
+	}
+}
+
+func TestRefreshSiteMetricsForbiddenProbe(t *testing.T) {
+	const account = "account@example.com"
+
+	client := newFakeClient()
+	client.accounts[testToken32] = account
+	coll := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "site1", SiteID: "site-uuid-1", Account: account},
+		{SiteName: "site2", SiteID: "site-uuid-2", Account: account},
+	})
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Minute, coll, 0, "")
+	manager.accountTokenMap[account] = testToken32
+	now := time.Unix(1762732800, 0)
+	manager.breaker.now = func() time.Time { return now }
+	manager.unauthorized.now = func() time.Time { return now }
+
+	openBreaker := func() {
+		t.Helper()
+		client.metricsErr = errors.New("api unavailable")
+		for i := 0; i < DefaultBreakerThreshold; i++ {
+			manager.refreshSiteMetrics(account, "site2", "site-uuid-2")
+		}
+		if open := manager.AccountCircuitsOpen(); !open[account] {
+			t.Fatal("Expected the account's circuit breaker to open")
+		}
+		now = now.Add(DefaultBreakerCooldown)
+	}
+
+	// The half-open probe hits a forbidden site
+	openBreaker()
+	client.metricsErr = pantheon.ErrForbidden
+	if err := manager.refreshSiteMetrics(account, "site1", "site-uuid-1"); !errors.Is(err, pantheon.ErrForbidden) {
+		t.Fatalf("Expected the probe to be forbidden, got %v", err)
+	}
+	client.metricsErr = nil
+	if err := manager.refreshSiteMetrics(account, "site2", "site-uuid-2"); err != nil {
+		t.Fatalf("Expected the next site to refresh after a forbidden probe, got %v", err)
+	}
+
+	// A site skipped for being forbidden doesn't take the probe
+	openBreaker()
+	if err := manager.refreshSiteMetrics(account, "site1", "site-uuid-1"); err == nil {
+		t.Fatal("Expected the forbidden site to still be skipped")
+	}
+	client.metricsErr = nil
+	if err := manager.refreshSiteMetrics(account, "site2", "site-uuid-2"); err != nil {
+		t.Fatalf("Expected the next site to take the probe, got %v", err)
+	}
+	if open := manager.AccountCircuitsOpen(); open[account] {
+		t.Error("Expected a successful probe to close the breaker")
+	}
+}
+
+func TestRefreshSiteMetricsForbiddenNoCooldown(t *testing.T) {
+	const account = "account@example.com"
+
+	client := newFakeClient()
+	client.accounts[testToken32] = account
+	client.metricsErr = pantheon.ErrForbidden
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Minute, collector.NewPantheonCollector(nil), 0, "")
+	manager.accountTokenMap[account] = testToken32
+	manager.SetUnauthorizedCooldown(0)
+
+	manager.refreshSiteMetrics(account, "site1", "site-uuid-1")
+	manager.refreshSiteMetrics(account, "site1", "site-uuid-1")
+	if client.metricsCalls != 2 {
+		t.Errorf("Expected the site to be retried without a cooldown, got %d requests", client.metricsCalls)
+	}
+	if sites := manager.UnauthorizedSites(); len(sites) != 0 {
+		t.Errorf("Expected no backed off sites without a cooldown, got %v", sites)
+	}
+}
+
+func TestNewSitesRefreshedAheadOfRotation(t *testing.T) {
+	const account = "account@example.com"
+	client := newFakeClient()
+	client.accounts[testToken32] = account
+	client.sites[testToken32] = map[string]pantheon.SiteListEntry{
+		"site-uuid-1": {Name: "site1", ID: "site-uuid-1"},
+		"site-uuid-2": {Name: "site2", ID: "site-uuid-2"},
+	}
+
+	coll := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "site1", SiteID: "site-uuid-1", Account: account},
+	})
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Hour, coll, 0, "")
+	manager.InitializeAccountTokenMap()
+	// The rotation never ticks during the test
+	manager.SetTickerInterval(time.Hour)
+	defer manager.Stop(5 * time.Second)
+	go manager.refreshMetricsWithQueue()
+
+	manager.refreshAllSiteLists()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if site, ok := coll.GetSite(account, "site2"); ok && len(site.MetricsData) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the newly added site to be refreshed ahead of the rotation")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if _, ok := client.durations["site-uuid-1"]; ok {
+		t.Error("Expected the existing site to wait for the rotation")
+	}
+	if got := client.durations["site-uuid-2"]; got != InitialMetricsDuration {
+		t.Errorf("Expected the new site to fetch %s, got %s", InitialMetricsDuration, got)
+	}
+}
+
+func TestNewSitesQueuedAfterMerge(t *testing.T) {
+	const account = "account@example.com"
+	client := newFakeClient()
+	client.accounts[testToken32] = account
+	client.sites[testToken32] = map[string]pantheon.SiteListEntry{
+		"site-uuid-1": {Name: "site1", ID: "site-uuid-1"},
+		"site-uuid-2": {Name: "site2", ID: "site-uuid-2"},
+	}
+
+	coll := collector.NewPantheonCollector([]pantheon.SiteMetrics{
+		{SiteName: "site1", SiteID: "site-uuid-1", Account: account},
+	})
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Hour, coll, 0, "")
+	manager.InitializeAccountTokenMap()
+	defer manager.Stop(5 * time.Second)
+	manager.refreshAllSiteLists()
+
+	// Sites are only queued once the collector knows them
+	newSites := manager.takeNewSites()
+	if len(newSites) != 1 || newSites[0].SiteName != "site2" {
+		t.Fatalf("Expected site2 to be queued once merged, got %v", newSites)
+	}
+
+	done := make(chan struct{})
+	manager.SetAfterRefresh(func() { close(done) })
+	if !manager.refreshBatch(newSites) {
+		t.Fatal("Expected the batch to start")
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the new site to be fetched")
+	}
+
+	client.mu.Lock()
+	duration := client.durations["site-uuid-2"]
+	client.mu.Unlock()
+	if duration != InitialMetricsDuration {
+		t.Errorf("Expected the new site's first fetch to use %s, got %s", InitialMetricsDuration, duration)
+	}
+	if site, ok := coll.GetSite(account, "site2"); !ok || len(site.MetricsData) == 0 {
+		t.Error("Expected the new site's first fetch to be kept")
+	}
+}
+
+func TestNewSitesNotQueuedForEmptyCollector(t *testing.T) {
+	const account = "account@example.com"
+	client := newFakeClient()
+	client.accounts[testToken32] = account
+	client.sites[testToken32] = map[string]pantheon.SiteListEntry{
+		"site-uuid-1": {Name: "site1", ID: "site-uuid-1"},
+	}
+
+	manager := NewManager(client, []string{testToken32}, testEnvLive, time.Hour, collector.NewPantheonCollector(nil), 0, "")
+	manager.InitializeAccountTokenMap()
+	manager.refreshAllSiteLists()
+
+	if sites := manager.takeNewSites(); len(sites) != 0 {
+		t.Errorf("Expected no sites queued when every site is new, got %d", len(sites))
+	}
+}
@@ -0,0 +1,105 @@
+package refresh
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Log formats for the per-cycle summary
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// ValidateLogFormat returns an error if format is not a supported log format.
+func ValidateLogFormat(format string) error {
+	if format != LogFormatText && format != LogFormatJSON {
+		return fmt.Errorf("invalid log format %q: must be %s or %s", format, LogFormatText, LogFormatJSON)
+	}
+	return nil
+}
+
+// AccountSummary counts the metrics refreshes for one account in a cycle
+type AccountSummary struct {
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+// CycleSummary describes one full cycle of the metrics refresh queue. Refreshes
+// are counted in the cycle they finish in.
+type CycleSummary struct {
+	Sites           int                        `json:"sites"`
+	Succeeded       int                        `json:"succeeded"`
+	Failed          int                        `json:"failed"`
+	DurationSeconds float64                    `json:"duration_seconds"`
+	Accounts        map[string]*AccountSummary `json:"accounts"`
+}
+
+// cycleStats accumulates refresh outcomes for the current cycle
+type cycleStats struct {
+	mu       sync.Mutex
+	start    time.Time
+	accounts map[string]*AccountSummary
+}
+
+// newCycleStats starts tracking a cycle beginning at start
+func newCycleStats(start time.Time) *cycleStats {
+	return &cycleStats{start: start, accounts: make(map[string]*AccountSummary)}
+}
+
+// record counts a finished metrics refresh for an account
+func (s *cycleStats) record(accountID string, succeeded bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accounts[accountID]
+	if !ok {
+		account = &AccountSummary{}
+		s.accounts[accountID] = account
+	}
+	if succeeded {
+		account.Succeeded++
+	} else {
+		account.Failed++
+	}
+}
+
+// finish returns the summary of the cycle ending at now, covering sites sites,
+// and starts a new cycle
+func (s *cycleStats) finish(sites int, now time.Time) CycleSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summary := CycleSummary{
+		Sites:           sites,
+		DurationSeconds: now.Sub(s.start).Seconds(),
+		Accounts:        s.accounts,
+	}
+	for _, account := range s.accounts {
+		summary.Succeeded += account.Succeeded
+		summary.Failed += account.Failed
+	}
+
+	s.start = now
+	s.accounts = make(map[string]*AccountSummary)
+	return summary
+}
+
+// logCycleSummary logs a cycle summary as a single line in the given format
+func logCycleSummary(summary CycleSummary, format string) {
+	if format != LogFormatJSON {
+		log.Printf("Metrics refresh cycle summary: %d sites, %d succeeded, %d failed in %.0fs across %d accounts",
+			summary.Sites, summary.Succeeded, summary.Failed, summary.DurationSeconds, len(summary.Accounts))
+		return
+	}
+
+	line, err := json.Marshal(summary)
+	if err != nil {
+		log.Printf("Warning: Failed to encode cycle summary: %v", err)
+		return
+	}
+	log.Print(string(line))
+}
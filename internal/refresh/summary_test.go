@@ -0,0 +1,82 @@
+package refresh
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/collector"
+	"github.com/deviantintegral/pantheon-metrics-prometheus/internal/pantheon"
+)
+
+func TestValidateLogFormat(t *testing.T) {
+	for _, format := range []string{LogFormatText, LogFormatJSON} {
+		if err := ValidateLogFormat(format); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", format, err)
+		}
+	}
+	for _, format := range []string{"", "JSON", "logfmt"} {
+		if err := ValidateLogFormat(format); err == nil {
+			t.Errorf("Expected %q to be invalid", format)
+		}
+	}
+}
+
+func TestCycleSummary(t *testing.T) {
+	client := newFakeClient()
+	client.accounts["token-a-0123456789abcdef0123456789"] = "a@example.com"
+	client.accounts["token-b-0123456789abcdef0123456789"] = "b@example.com"
+	sites := []pantheon.SiteMetrics{
+		{SiteName: "a1", SiteID: "a1-id", Account: "a@example.com"},
+		{SiteName: "a2", SiteID: "a2-id", Account: "a@example.com"},
+		{SiteName: "b1", SiteID: "b1-id", Account: "b@example.com"},
+	}
+
+	manager := NewManager(client, []string{"token-a-0123456789abcdef0123456789", "token-b-0123456789abcdef0123456789"},
+		testEnvLive, time.Minute, collector.NewPantheonCollector(sites), 0, "")
+	manager.InitializeAccountTokenMap()
+	start := time.Unix(1762732800, 0)
+	manager.cycle = newCycleStats(start)
+
+	// Simulate a cycle in which one of account a's sites fails
+	manager.refreshSiteMetrics("a@example.com", "a1", "a1-id")
+	manager.refreshSiteMetrics("b@example.com", "b1", "b1-id")
+	client.metricsErr = errors.New("boom")
+	manager.refreshSiteMetrics("a@example.com", "a2", "a2-id")
+
+	summary := manager.cycle.finish(len(sites), start.Add(3*time.Minute))
+	if summary.Sites != 3 || summary.Succeeded != 2 || summary.Failed != 1 {
+		t.Errorf("Expected 3 sites with 2 succeeded and 1 failed, got %+v", summary)
+	}
+	if summary.DurationSeconds != 180 {
+		t.Errorf("Expected a 180s cycle, got %v", summary.DurationSeconds)
+	}
+	if a := summary.Accounts["a@example.com"]; a == nil || a.Succeeded != 1 || a.Failed != 1 {
+		t.Errorf("Expected account a to have 1 success and 1 failure, got %+v", a)
+	}
+	if b := summary.Accounts["b@example.com"]; b == nil || b.Succeeded != 1 || b.Failed != 0 {
+		t.Errorf("Expected account b to have 1 success, got %+v", b)
+	}
+
+	// The summary serializes to a single JSON object
+	var decoded map[string]interface{}
+	line, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatalf("Failed to encode summary: %v", err)
+	}
+	if err := json.Unmarshal(line, &decoded); err != nil {
+		t.Fatalf("Failed to decode summary: %v", err)
+	}
+	for _, key := range []string{"sites", "succeeded", "failed", "duration_seconds", "accounts"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("Expected %q in JSON summary %s", key, line)
+		}
+	}
+
+	// Finishing starts a fresh cycle
+	next := manager.cycle.finish(len(sites), start.Add(4*time.Minute))
+	if next.Succeeded != 0 || next.Failed != 0 || len(next.Accounts) != 0 || next.DurationSeconds != 60 {
+		t.Errorf("Expected an empty 60s cycle, got %+v", next)
+	}
+}
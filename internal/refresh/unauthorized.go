@@ -0,0 +1,100 @@
+package refresh
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultUnauthorizedCooldown is how long a site whose metrics request was
+// forbidden is skipped before it is tried again.
+const DefaultUnauthorizedCooldown = 6 * time.Hour
+
+// siteKey identifies a site within an account
+type siteKey struct {
+	account string
+	site    string
+}
+
+// unauthorizedSites tracks sites a token can list but isn't allowed to read
+// metrics for, so they aren't requested again on every cycle
+type unauthorizedSites struct {
+	mu       sync.Mutex
+	cooldown time.Duration // 0 disables backing off
+	until    map[siteKey]time.Time
+	now      func() time.Time
+}
+
+// newUnauthorizedSites creates a tracker skipping forbidden sites for cooldown
+func newUnauthorizedSites(cooldown time.Duration) *unauthorizedSites {
+	return &unauthorizedSites{
+		cooldown: cooldown,
+		until:    make(map[siteKey]time.Time),
+		now:      time.Now,
+	}
+}
+
+// setCooldown changes how long forbidden sites are skipped
+func (u *unauthorizedSites) setCooldown(cooldown time.Duration) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.cooldown = cooldown
+}
+
+// backOff starts skipping a site after a forbidden metrics request, and
+// reports whether it is skipped. Nothing is skipped when backing off is disabled.
+func (u *unauthorizedSites) backOff(account, site string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.cooldown <= 0 {
+		return false
+	}
+	u.until[siteKey{account, site}] = u.now().Add(u.cooldown)
+	return true
+}
+
+// skip reports whether a site is still in its cooldown
+func (u *unauthorizedSites) skip(account, site string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	until, ok := u.until[siteKey{account, site}]
+	return ok && u.now().Before(until)
+}
+
+// clear forgets a site, once its metrics could be read again
+func (u *unauthorizedSites) clear(account, site string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.until, siteKey{account, site})
+}
+
+// active returns the names of the sites currently skipped, keyed by account
+func (u *unauthorizedSites) active() map[string][]string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	now := u.now()
+	sites := make(map[string][]string)
+	for key, until := range u.until {
+		if now.Before(until) {
+			sites[key.account] = append(sites[key.account], key.site)
+		}
+	}
+	for _, names := range sites {
+		sort.Strings(names)
+	}
+	return sites
+}
+
+// SetUnauthorizedCooldown sets how long a site is skipped after the Pantheon
+// API forbids reading its metrics, which happens when the token's role can
+// list the site but not read its metrics. 0 retries such sites every cycle.
+func (rm *Manager) SetUnauthorizedCooldown(cooldown time.Duration) {
+	rm.unauthorized.setCooldown(cooldown)
+}
+
+// UnauthorizedSites returns the sites currently skipped because reading their
+// metrics was forbidden, keyed by account (thread-safe)
+func (rm *Manager) UnauthorizedSites() map[string][]string {
+	return rm.unauthorized.active()
+}
@@ -16,6 +16,7 @@ package version
 
 import (
 	"fmt"
+	"io"
 	"runtime"
 )
 
@@ -46,3 +47,9 @@ func UserAgent() string {
 	return fmt.Sprintf("%s/%s (go_version=%s; os=%s; arch=%s)",
 		AppName, String(), runtime.Version(), runtime.GOOS, runtime.GOARCH)
 }
+
+// Print writes the version and build details to w, as shown by the -version flag.
+func Print(w io.Writer) error {
+	_, err := fmt.Fprintln(w, UserAgent())
+	return err
+}
@@ -1,6 +1,7 @@
 package version
 
 import (
+	"bytes"
 	"runtime"
 	"strings"
 	"testing"
@@ -102,3 +103,22 @@ func TestUserAgentWithVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestPrint(t *testing.T) {
+	originalVersion := version
+	defer func() { version = originalVersion }()
+
+	version = "1.2.3"
+	var buf bytes.Buffer
+	if err := Print(&buf); err != nil {
+		t.Fatalf("Print() returned error: %v", err)
+	}
+
+	want := UserAgent() + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Print() wrote %q, want %q", got, want)
+	}
+	if !strings.Contains(buf.String(), "pantheon-metrics-prometheus/1.2.3") {
+		t.Errorf("Print() output %q should contain the app name and version", buf.String())
+	}
+}